@@ -0,0 +1,262 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/admin_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServiceClient 是管理员服务的 gRPC 客户端
+var AdminServiceClient proto.AdminServiceClient
+
+// ListUsers 分页查询和搜索用户
+// 查询参数：q、role、include_disabled、pending、page、page_size
+func ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	includeDisabled, _ := strconv.ParseBool(c.DefaultQuery("include_disabled", "false"))
+	pendingOnly, _ := strconv.ParseBool(c.DefaultQuery("pending", "false"))
+
+	resp, err := AdminServiceClient.ListUsers(authContext(c), &proto.ListUsersRequest{
+		Query:           c.Query("q"),
+		Role:            c.Query("role"),
+		IncludeDisabled: includeDisabled,
+		PendingOnly:     pendingOnly,
+		Page:            int32(page),
+		PageSize:        int32(pageSize),
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	users := make([]gin.H, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		users = append(users, adminUserJSON(u))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users":     users,
+		"total":     resp.Total,
+		"page":      resp.Page,
+		"page_size": resp.PageSize,
+	})
+}
+
+// GetUser 查询单个用户
+func GetUser(c *gin.Context) {
+	resp, err := AdminServiceClient.GetUser(authContext(c), &proto.GetUserRequest{Username: c.Param("username")})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, adminUserJSON(resp))
+}
+
+// DisableUser 禁用用户
+func DisableUser(c *gin.Context) {
+	setUserDisabled(c, true)
+}
+
+// EnableUser 重新启用用户
+func EnableUser(c *gin.Context) {
+	setUserDisabled(c, false)
+}
+
+func setUserDisabled(c *gin.Context, disabled bool) {
+	resp, err := AdminServiceClient.SetUserDisabled(authContext(c), &proto.SetUserDisabledRequest{
+		Username: c.Param("username"),
+		Disabled: disabled,
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, adminUserJSON(resp))
+}
+
+// AssignRole 设置用户角色，Body：{"role": "teacher"}
+func AssignRole(c *gin.Context) {
+	var req proto.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Username = c.Param("username")
+
+	resp, err := AdminServiceClient.AssignRole(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, adminUserJSON(resp))
+}
+
+// ResetPassword 强制重置密码，Body 可为空，此时生成临时密码
+func ResetPassword(c *gin.Context) {
+	var req proto.ResetPasswordRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	req.Username = c.Param("username")
+
+	resp, err := AdminServiceClient.ResetPassword(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), errorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"temporary_password": resp.TemporaryPassword,
+		"message":            resp.Message,
+	})
+}
+
+// ApproveRegistration 审批注册，Body：{"approve": true}
+func ApproveRegistration(c *gin.Context) {
+	var req proto.ApproveRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Username = c.Param("username")
+
+	resp, err := AdminServiceClient.ApproveRegistration(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"username": resp.Username,
+		"message":  resp.Message,
+	})
+}
+
+// CreateInvite 签发邀请码，Body：{"role": "student", "class_id": "...", "max_uses": 30, "expires_in": 604800}
+func CreateInvite(c *gin.Context) {
+	var req proto.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AdminServiceClient.CreateInvite(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, inviteJSON(resp))
+}
+
+// ListInvites 查询邀请码，查询参数 include_inactive=true 时包含已失效的邀请码
+func ListInvites(c *gin.Context) {
+	includeInactive, _ := strconv.ParseBool(c.DefaultQuery("include_inactive", "false"))
+	resp, err := AdminServiceClient.ListInvites(authContext(c), &proto.ListInvitesRequest{IncludeInactive: includeInactive})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	invites := make([]gin.H, 0, len(resp.Invites))
+	for _, invite := range resp.Invites {
+		invites = append(invites, inviteJSON(invite))
+	}
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// RevokeInvite 撤销邀请码
+func RevokeInvite(c *gin.Context) {
+	resp, err := AdminServiceClient.RevokeInvite(authContext(c), &proto.RevokeInviteRequest{Code: c.Param("code")})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, inviteJSON(resp))
+}
+
+// ListErasures 查询删除账号申请，查询参数 status 按状态过滤
+func ListErasures(c *gin.Context) {
+	resp, err := AdminServiceClient.ListErasures(authContext(c), &proto.ListErasuresRequest{Status: c.Query("status")})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	erasures := make([]gin.H, 0, len(resp.Erasures))
+	for _, e := range resp.Erasures {
+		erasures = append(erasures, erasureJSON(e))
+	}
+	c.JSON(http.StatusOK, gin.H{"erasures": erasures})
+}
+
+// ReviewErasure 审批删除账号申请，Body：{"approve": true}
+func ReviewErasure(c *gin.Context) {
+	var req proto.ReviewErasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	req.Id = id
+
+	resp, err := AdminServiceClient.ReviewErasure(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, erasureJSON(resp))
+}
+
+// erasureJSON 将删除账号申请转换为响应体
+func erasureJSON(e *proto.Erasure) gin.H {
+	return gin.H{
+		"id":           e.Id,
+		"username":     e.Username,
+		"reason":       e.Reason,
+		"status":       e.Status,
+		"requested_at": e.RequestedAt,
+		"reviewed_by":  e.ReviewedBy,
+		"reviewed_at":  e.ReviewedAt,
+		"completed_at": e.CompletedAt,
+		"error":        e.Error,
+	}
+}
+
+// inviteJSON 将邀请码消息转换为响应体
+func inviteJSON(i *proto.Invite) gin.H {
+	return gin.H{
+		"code":       i.Code,
+		"role":       i.Role,
+		"class_id":   i.ClassId,
+		"max_uses":   i.MaxUses,
+		"uses":       i.Uses,
+		"expires_at": i.ExpiresAt,
+		"revoked":    i.Revoked,
+		"created_by": i.CreatedBy,
+		"created_at": i.CreatedAt,
+	}
+}
+
+// adminUserJSON 将用户消息转换为响应体
+func adminUserJSON(u *proto.AdminUser) gin.H {
+	return gin.H{
+		"username":       u.Username,
+		"display_name":   u.DisplayName,
+		"email":          u.Email,
+		"role":           u.Role,
+		"requested_role": u.RequestedRole,
+		"disabled":       u.Disabled,
+		"created_at":     u.CreatedAt,
+		"last_login_at":  u.LastLoginAt,
+		"pending":        u.Pending,
+		"invite_code":    u.InviteCode,
+	}
+}
@@ -0,0 +1,79 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/api_key_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAPIKey 为服务账号签发 API Key，密钥只在响应中出现一次
+func CreateAPIKey(c *gin.Context) {
+	var req proto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.CreateAPIKey(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	body := apiKeyJSON(resp.ApiKey)
+	body["key"] = resp.Key
+	c.JSON(http.StatusCreated, body)
+}
+
+// ListAPIKeys 查询 API Key，查询参数 include_revoked=true 时包含已撤销的 API Key，all=true 时管理员查询全部
+func ListAPIKeys(c *gin.Context) {
+	includeRevoked, _ := strconv.ParseBool(c.DefaultQuery("include_revoked", "false"))
+	all, _ := strconv.ParseBool(c.DefaultQuery("all", "false"))
+	resp, err := AuthServiceClient.ListAPIKeys(authContext(c), &proto.ListAPIKeysRequest{
+		IncludeRevoked: includeRevoked,
+		All:            all,
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	keys := make([]gin.H, 0, len(resp.ApiKeys))
+	for _, key := range resp.ApiKeys {
+		keys = append(keys, apiKeyJSON(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RevokeAPIKey 撤销 API Key
+func RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+	resp, err := AuthServiceClient.RevokeAPIKey(authContext(c), &proto.RevokeAPIKeyRequest{Id: id})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// apiKeyJSON 将 API Key 消息转换为响应体
+func apiKeyJSON(k *proto.APIKey) gin.H {
+	return gin.H{
+		"id":              k.Id,
+		"name":            k.Name,
+		"service_account": k.ServiceAccount,
+		"scopes":          k.Scopes,
+		"prefix":          k.Prefix,
+		"created_by":      k.CreatedBy,
+		"created_at":      k.CreatedAt,
+		"expires_at":      k.ExpiresAt,
+		"last_used_at":    k.LastUsedAt,
+		"revoked":         k.Revoked,
+	}
+}
@@ -0,0 +1,138 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/audit_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// ListAuditEvents 查询审计日志，format=csv 时导出全部符合条件的记录
+func ListAuditEvents(c *gin.Context) {
+	since, err := parseTimeQuery(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since: " + err.Error()})
+		return
+	}
+	until, err := parseTimeQuery(c.Query("until"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until: " + err.Error()})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	req := &proto.ListAuditEventsRequest{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Target:   c.Query("target"),
+		Outcome:  c.Query("outcome"),
+		Ip:       c.Query("ip"),
+		Since:    since,
+		Until:    until,
+		Page:     int32(page),
+		PageSize: int32(pageSize),
+	}
+
+	if c.Query("format") == "csv" {
+		exportAuditCSV(c, req)
+		return
+	}
+
+	resp, err := AdminServiceClient.ListAuditEvents(authContext(c), req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	events := make([]gin.H, 0, len(resp.Events))
+	for _, e := range resp.Events {
+		events = append(events, gin.H{
+			"id":         e.Id,
+			"created_at": e.CreatedAt,
+			"actor":      e.Actor,
+			"action":     e.Action,
+			"target":     e.Target,
+			"outcome":    e.Outcome,
+			"ip":         e.Ip,
+			"user_agent": e.UserAgent,
+			"detail":     e.Detail,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"events":    events,
+		"total":     resp.Total,
+		"page":      resp.Page,
+		"page_size": resp.PageSize,
+	})
+}
+
+// exportAuditCSV 将审计事件流式写出为 CSV 文件
+func exportAuditCSV(c *gin.Context, req *proto.ListAuditEventsRequest) {
+	stream, err := AdminServiceClient.ExportAuditEvents(authContext(c), req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	// 先读取第一条记录，权限不足等错误在写出响应头之前就能返回 JSON
+	first, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "time", "actor", "action", "target", "outcome", "ip", "user_agent", "detail"})
+	for event := first; event != nil; {
+		_ = w.Write([]string{
+			strconv.FormatUint(event.Id, 10),
+			time.Unix(event.CreatedAt, 0).Format(time.RFC3339),
+			event.Actor,
+			event.Action,
+			event.Target,
+			event.Outcome,
+			event.Ip,
+			event.UserAgent,
+			event.Detail,
+		})
+		event, err = stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				// 响应头已经发出，只能记录日志并截断文件
+				log.Printf("ExportAuditEvents failed: %v", err)
+			}
+			break
+		}
+	}
+	w.Flush()
+}
+
+// parseTimeQuery 解析查询参数中的时间，支持 Unix 时间戳和 RFC 3339 格式，空字符串返回 0
+func parseTimeQuery(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, errors.New("expected unix timestamp or RFC 3339 time")
+	}
+	return t.Unix(), nil
+}
@@ -3,9 +3,16 @@ package controllers
 
 import (
 	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // AuthServiceClient 是 gRPC 客户端
@@ -18,12 +25,24 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	resp, err := AuthServiceClient.Register(c, &req)
+	resp, err := AuthServiceClient.Register(requestContext(c), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(grpcHTTPStatus(err), errorBody(err))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": resp.Token, "message": resp.Message})
+	if resp.PendingApproval {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":          resp.Message,
+			"pending_approval": true,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":         resp.Token,
+		"message":       resp.Message,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+	})
 }
 
 // Login 登录接口
@@ -33,10 +52,171 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	resp, err := AuthServiceClient.Login(c, &req)
+	resp, err := AuthServiceClient.Login(requestContext(c), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// 登录被锁定时返回 429 并通过 Retry-After 告知重试时间
+		if retryAfter, ok := retryAfterSeconds(err); ok {
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		}
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	// 需要两步验证时只返回第二步凭证，客户端随后调用 /login/2fa
+	if resp.SecondFactorRequired {
+		body := gin.H{
+			"message":                resp.Message,
+			"second_factor_required": true,
+			"challenge_token":        resp.ChallengeToken,
+		}
+		if resp.TotpEnrollmentRequired {
+			body["totp_enrollment_required"] = true
+			body["totp_secret"] = resp.TotpSecret
+			body["totp_provisioning_uri"] = resp.TotpProvisioningUri
+		}
+		c.JSON(http.StatusOK, body)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": resp.Token, "message": resp.Message})
+	c.JSON(http.StatusOK, gin.H{
+		"token":         resp.Token,
+		"message":       resp.Message,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+	})
+}
+
+// RefreshToken 刷新 Token 接口
+func RefreshToken(c *gin.Context) {
+	var req proto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.RefreshToken(requestContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":         resp.Token,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+	})
+}
+
+// Logout 注销接口
+func Logout(c *gin.Context) {
+	var req proto.LogoutRequest
+	// 请求体可以为空，此时只注销当前访问 Token
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	resp, err := AuthServiceClient.Logout(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// ListRoleRequests 查询待审批的角色申请（仅管理员）
+func ListRoleRequests(c *gin.Context) {
+	resp, err := AuthServiceClient.ListRoleRequests(authContext(c), &proto.ListRoleRequestsRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	requests := make([]gin.H, 0, len(resp.Requests))
+	for _, r := range resp.Requests {
+		requests = append(requests, gin.H{
+			"username":       r.Username,
+			"current_role":   r.CurrentRole,
+			"requested_role": r.RequestedRole,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// ApproveRole 审批或驳回角色申请（仅管理员）
+func ApproveRole(c *gin.Context) {
+	var req proto.ApproveRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.ApproveRole(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"username": resp.Username,
+		"role":     resp.Role,
+		"message":  resp.Message,
+	})
+}
+
+// UnlockAccount 解除账号登录锁定（仅管理员）
+func UnlockAccount(c *gin.Context) {
+	var req proto.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.UnlockAccount(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// JWKS 公开当前所有验签公钥
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
+
+// requestContext 将客户端 IP 和 User-Agent 转发到 gRPC 元数据中
+func requestContext(c *gin.Context) context.Context {
+	return metadata.AppendToOutgoingContext(c.Request.Context(),
+		"x-forwarded-for", c.ClientIP(),
+		"x-forwarded-user-agent", c.Request.UserAgent(),
+	)
+}
+
+// authContext 在 requestContext 的基础上转发 Authorization 头
+func authContext(c *gin.Context) context.Context {
+	return metadata.AppendToOutgoingContext(requestContext(c), "authorization", c.GetHeader("Authorization"))
+}
+
+// errorBody 生成错误响应，参数校验失败时在 fields 中逐个列出不符合规则的字段
+func errorBody(err error) gin.H {
+	st := status.Convert(err)
+	body := gin.H{"error": st.Message()}
+	var fields []gin.H
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, gin.H{"field": v.Field, "description": v.Description})
+			}
+		}
+	}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	return body
+}
+
+// retryAfterSeconds 从 gRPC 错误详情中读取 RetryInfo
+func retryAfterSeconds(err error) (int64, bool) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return int64(math.Ceil(info.RetryDelay.AsDuration().Seconds())), true
+		}
+	}
+	return 0, false
 }
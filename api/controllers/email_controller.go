@@ -0,0 +1,62 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/email_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// RequestPasswordReset 忘记密码，发送重置密码邮件
+func RequestPasswordReset(c *gin.Context) {
+	var req proto.RequestPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.RequestPasswordReset(requestContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// ResetPasswordWithToken 使用邮件中的 Token 重置密码
+func ResetPasswordWithToken(c *gin.Context) {
+	var req proto.ResetPasswordWithTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.ResetPassword(requestContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), errorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// VerifyEmail 验证邮箱，验证邮件中的链接直接指向该接口
+func VerifyEmail(c *gin.Context) {
+	resp, err := AuthServiceClient.VerifyEmail(requestContext(c), &proto.VerifyEmailRequest{
+		Token: c.Query("token"),
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// SendVerificationEmail 重新发送验证邮件
+func SendVerificationEmail(c *gin.Context) {
+	resp, err := AuthServiceClient.SendVerificationEmail(authContext(c), &proto.SendVerificationEmailRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
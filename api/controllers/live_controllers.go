@@ -8,9 +8,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -59,6 +60,28 @@ func extractToken(c *gin.Context) (string, error) {
 	return parts[1], nil
 }
 
+// grpcHTTPStatus 将 gRPC 错误码转换为对应的 HTTP 状态码
+func grpcHTTPStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // GetMessages 获取消息
 func GetMessages(c *gin.Context) {
 	classID := c.Query("class_id")
@@ -93,7 +116,7 @@ func GetMessages(c *gin.Context) {
 		return
 	}
 
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
 
 	// 调用 gRPC 服务获取消息
 	resp, err := client.GetMessages(ctx, &proto.GetMessagesRequest{
@@ -102,7 +125,7 @@ func GetMessages(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("GetMessages gRPC call failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": "failed to get messages"})
 		return
 	}
 
@@ -165,11 +188,8 @@ func CreateLiveClass(c *gin.Context) {
 		authHeader = "Bearer " + authHeader
 	}
 
-	// 创建带认证信息的上下文
-	md := metadata.New(map[string]string{
-		"authorization": authHeader,
-	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	// 创建带认证信息的上下文，同时转发客户端 IP 和 User-Agent 用于审计
+	ctx := metadata.AppendToOutgoingContext(requestContext(c), "authorization", authHeader)
 
 	// 调用gRPC服务
 	resp, err := client.CreateLiveClass(ctx, &req)
@@ -184,7 +204,7 @@ func CreateLiveClass(c *gin.Context) {
 			errorMsg = err.Error()
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(grpcHTTPStatus(err), gin.H{
 			"error":   "failed to create live class",
 			"details": errorMsg,
 		})
@@ -193,9 +213,84 @@ func CreateLiveClass(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, gin.H{
 		"classID":   resp.ClassId,
+		"className": resp.ClassName,
 		"status":    resp.Status,
 		"streamKey": resp.StreamKey,
+		"state":     resp.State,
+	})
+}
+
+// GetLiveClass 按 ID 查询直播课详情
+func GetLiveClass(c *gin.Context) {
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.GetLiveClass(authContext(c), &proto.GetLiveClassRequest{ClassId: c.Param("class_id")})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, liveClassJSON(resp))
+}
+
+// ListLiveClasses 查询直播课列表
+// 查询参数：status（live / scheduled / ended / lobby / paused / archived）、teacher、q、sort（newest / oldest / name）、cursor、page_size
+func ListLiveClasses(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.ListLiveClasses(authContext(c), &proto.ListLiveClassesRequest{
+		Status:   c.Query("status"),
+		Teacher:  c.Query("teacher"),
+		Keyword:  c.Query("q"),
+		Sort:     c.Query("sort"),
+		Cursor:   c.Query("cursor"),
+		PageSize: int32(pageSize),
 	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	classes := make([]gin.H, 0, len(resp.Classes))
+	for _, lc := range resp.Classes {
+		classes = append(classes, liveClassJSON(lc))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"classes":     classes,
+		"next_cursor": resp.NextCursor,
+	})
+}
+
+// liveClassJSON 将直播课详情转换为响应体
+func liveClassJSON(lc *proto.LiveClass) gin.H {
+	body := gin.H{
+		"class_id":             lc.ClassId,
+		"class_name":           lc.ClassName,
+		"teacher_name":         lc.TeacherName,
+		"teacher_display_name": lc.TeacherDisplayName,
+		"room_name":            lc.RoomName,
+		"status":               lc.Status,
+		"created_at":           lc.CreatedAt,
+		"started_at":           lc.StartedAt,
+		"ended_at":             lc.EndedAt,
+		"scheduled_start":      lc.ScheduledStart,
+		"scheduled_end":        lc.ScheduledEnd,
+		"viewer_count":         lc.ViewerCount,
+		"online_count":         lc.OnlineCount,
+	}
+	if lc.StreamUrl != "" {
+		body["stream_url"] = lc.StreamUrl
+	}
+	return body
 }
 
 // JoinLiveClass 加入直播课
@@ -207,7 +302,7 @@ func JoinLiveClass(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Join request received: %+v", req)
+	log.Printf("Join request received: %+v", &req)
 
 	client, conn, err := createGRPCClient(c)
 	if err != nil {
@@ -231,7 +326,7 @@ func JoinLiveClass(c *gin.Context) {
 	resp, err := client.JoinLiveClass(ctx, &req)
 	if err != nil {
 		log.Printf("gRPC call failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(grpcHTTPStatus(err), gin.H{
 			"error":   "failed to join live class",
 			"details": err.Error(), // 添加详细错误信息
 		})
@@ -267,10 +362,7 @@ func SendMessage(c *gin.Context) {
 	}
 
 	// 解析 JWT Token 获取用户名
-	claims := &utils.Claims{}
-	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return utils.JwtSecret, nil
-	})
+	claims, err := utils.ParseToken(token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
@@ -281,7 +373,7 @@ func SendMessage(c *gin.Context) {
 
 	resp, err := client.SendMessage(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to call gRPC service"})
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": "failed to call gRPC service"})
 		return
 	}
 
@@ -320,33 +412,22 @@ func EndLiveClass(c *gin.Context) {
 		return
 	}
 
-	claims := &utils.Claims{}
-	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return utils.JwtSecret, nil
-	})
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		return
-	}
-	username := claims.Username
-
 	// 创建带有超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	// 创建带认证信息的上下文
-	md := metadata.New(map[string]string{"authorization": "Bearer " + token})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
 
+	// 服务端以 Token 中的用户为准，无需传递用户名
 	resp, err := client.EndLiveClass(ctx, &proto.EndLiveClassRequest{
-		ClassId:  req.ClassID,
-		Username: username,
+		ClassId: req.ClassID,
 	})
 	if err != nil {
 		log.Printf("EndLiveClass gRPC调用失败: %v", err)
 		// 尝试解析gRPC错误
 		if status, ok := status.FromError(err); ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": status.Message()})
+			c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Message()})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end live class"})
@@ -383,12 +464,12 @@ func PublishQuestion(c *gin.Context) {
 	}
 
 	// 直接传递整个Authorization头
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", authHeader)
+	ctx := metadata.AppendToOutgoingContext(requestContext(c), "authorization", authHeader)
 
 	resp, err := client.PublishQuestion(ctx, &req)
 	if err != nil {
 		log.Printf("PublishQuestion failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(grpcHTTPStatus(err), gin.H{
 			"error":   "failed to publish question",
 			"details": err.Error(), // 返回具体错误信息
 		})
@@ -401,6 +482,257 @@ func PublishQuestion(c *gin.Context) {
 	})
 }
 
+// KickUser 将用户移出直播课
+func KickUser(c *gin.Context) {
+	var req proto.KickUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ClassId == "" || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id and username are required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.KickUser(authContext(c), &req)
+	if err != nil {
+		log.Printf("KickUser failed: %v", err)
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": resp.Status})
+}
+
+// UpdateLiveClassState 切换直播课状态
+func UpdateLiveClassState(c *gin.Context) {
+	var req proto.UpdateLiveClassStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ClassId == "" || req.State == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id and state are required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.UpdateLiveClassState(authContext(c), &req)
+	if err != nil {
+		log.Printf("UpdateLiveClassState failed: %v", err)
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, liveClassJSON(resp))
+}
+
+// Heartbeat 直播课心跳，客户端在直播课中按返回的间隔定期调用
+func Heartbeat(c *gin.Context) {
+	var req proto.HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ClassId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id is required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.Heartbeat(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": resp.Status, "interval_seconds": resp.IntervalSeconds})
+}
+
+// LeaveLiveClass 离开直播课
+func LeaveLiveClass(c *gin.Context) {
+	var req proto.LeaveLiveClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ClassId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id is required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.LeaveLiveClass(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": resp.Status})
+}
+
+// GetAttendance 查询直播课出勤记录，format=csv 时导出已结束直播课的 CSV 文件
+func GetAttendance(c *gin.Context) {
+	classID := c.Query("class_id")
+	if classID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id is required"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if format == "csv" {
+		resp, err := client.ExportAttendance(authContext(c), &proto.ExportAttendanceRequest{ClassId: classID})
+		if err != nil {
+			c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", resp.Filename))
+		c.Data(http.StatusOK, resp.ContentType, resp.Data)
+		return
+	}
+
+	resp, err := client.GetAttendance(authContext(c), &proto.GetAttendanceRequest{ClassId: classID})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	students := make([]gin.H, 0, len(resp.Students))
+	for _, student := range resp.Students {
+		sessions := make([]gin.H, 0, len(student.Sessions))
+		for _, session := range student.Sessions {
+			sessions = append(sessions, gin.H{
+				"joined_at":        session.JoinedAt,
+				"left_at":          session.LeftAt,
+				"duration_seconds": session.DurationSeconds,
+			})
+		}
+		students = append(students, gin.H{
+			"username":      student.Username,
+			"display_name":  student.DisplayName,
+			"total_seconds": student.TotalSeconds,
+			"online":        student.Online,
+			"sessions":      sessions,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"class_id":     resp.ClassId,
+		"class_status": resp.ClassStatus,
+		"students":     students,
+	})
+}
+
+// ListParticipants 查询直播课当前在线的用户
+func ListParticipants(c *gin.Context) {
+	classID := c.Query("class_id")
+	if classID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id is required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	resp, err := client.ListParticipants(authContext(c), &proto.ListParticipantsRequest{ClassId: classID})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	participants := make([]gin.H, 0, len(resp.Participants))
+	for _, p := range resp.Participants {
+		participants = append(participants, participantJSON(p))
+	}
+	c.JSON(http.StatusOK, gin.H{"participants": participants, "online_count": len(participants)})
+}
+
+// WatchParticipants 订阅在线用户变化，以 NDJSON 流式返回：第一条为完整列表，之后每条为一次加入或离开
+func WatchParticipants(c *gin.Context) {
+	classID := c.Query("class_id")
+	if classID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "class_id is required"})
+		return
+	}
+
+	client, conn, err := createGRPCClient(c)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	stream, err := client.WatchParticipants(authContext(c), &proto.ListParticipantsRequest{ClassId: classID})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	// 鉴权等错误在收到第一条消息时才返回，此时还可以返回对应的状态码
+	ev, err := stream.Recv()
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	for {
+		participants := make([]gin.H, 0, len(ev.Participants))
+		for _, p := range ev.Participants {
+			participants = append(participants, participantJSON(p))
+		}
+		jsonData, err := json.Marshal(gin.H{
+			"type":         ev.Type,
+			"participants": participants,
+			"online_count": ev.OnlineCount,
+		})
+		if err != nil {
+			log.Printf("JSON编码错误: %v", err)
+			return
+		}
+		if _, err := c.Writer.Write(append(jsonData, '\n')); err != nil {
+			return
+		}
+		c.Writer.Flush()
+
+		if ev, err = stream.Recv(); err != nil {
+			if err != io.EOF {
+				log.Printf("WatchParticipants stream ended: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// participantJSON 将在线用户转换为响应体
+func participantJSON(p *proto.Participant) gin.H {
+	return gin.H{
+		"username":     p.Username,
+		"display_name": p.DisplayName,
+		"online_since": p.OnlineSince,
+	}
+}
+
 // SubmitAnswer 提交答案
 func SubmitAnswer(c *gin.Context) {
 	var req proto.SubmitAnswerRequest
@@ -423,10 +755,7 @@ func SubmitAnswer(c *gin.Context) {
 	}
 
 	// 解析 JWT Token 获取用户名
-	claims := &utils.Claims{}
-	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return utils.JwtSecret, nil
-	})
+	claims, err := utils.ParseToken(token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
@@ -434,11 +763,11 @@ func SubmitAnswer(c *gin.Context) {
 	req.StudentName = claims.Username
 
 	// 将 Token 传递给 gRPC 客户端
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
 
 	resp, err := client.SubmitAnswer(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to call gRPC service"})
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": "failed to call gRPC service"})
 		return
 	}
 
@@ -463,9 +792,10 @@ func GetAnswerStatistics(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	token, err := extractToken(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	// 直接转发 Authorization 头，同时支持 Bearer Token 和 API Key
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
 		return
 	}
 
@@ -474,8 +804,7 @@ func GetAnswerStatistics(c *gin.Context) {
 	defer cancel()
 
 	// 创建带认证信息的上下文
-	md := metadata.New(map[string]string{"authorization": "Bearer " + token})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authHeader)
 
 	// 调用gRPC流式方法
 	stream, err := client.GetAnswerStatistics(ctx, &proto.GetAnswerStatisticsRequest{
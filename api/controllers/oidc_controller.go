@@ -0,0 +1,338 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/oidc_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OIDCServiceClient 是单点登录服务的 gRPC 客户端
+var OIDCServiceClient proto.OIDCServiceClient
+
+// oidcLoginPage 单点登录页面，授权参数通过隐藏字段随表单提交
+var oidcLoginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>登录 LanshanClass</title>
+<style>
+body { font-family: sans-serif; max-width: 360px; margin: 60px auto; padding: 0 16px; }
+label { display: block; margin-top: 12px; }
+input[type=text], input[type=password] { width: 100%; padding: 8px; box-sizing: border-box; }
+button { margin-top: 16px; width: 100%; padding: 10px; }
+.error { color: #c00; }
+</style>
+</head>
+<body>
+{{if .Fatal}}
+<h2>无法登录</h2>
+<p class="error">{{.Error}}</p>
+{{else}}
+<h2>使用 LanshanClass 账号登录</h2>
+<p>「{{.ClientName}}」请求访问你的账号信息。</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/oidc/authorize">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}
+<label>用户名 <input type="text" name="username" value="{{.Username}}" autocomplete="username" required></label>
+<label>密码 <input type="password" name="password" autocomplete="current-password" required></label>
+<label>两步验证码（未启用可不填） <input type="text" name="totp_code" inputmode="numeric" autocomplete="one-time-code"></label>
+<button type="submit">登录并授权</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// oidcLoginView 登录页面的数据
+type oidcLoginView struct {
+	Fatal      bool // 客户端或回调地址无效，只能展示错误，不能返回客户端
+	Error      string
+	ClientName string
+	Username   string
+	Params     map[string]string
+}
+
+// OpenIDConfiguration 返回 OIDC 发现文档
+func OpenIDConfiguration(c *gin.Context) {
+	issuer := utils.OIDCIssuer()
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oidc/authorize",
+		"token_endpoint":                        issuer + "/oidc/token",
+		"userinfo_endpoint":                     issuer + "/oidc/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": utils.SigningAlgorithms(),
+		"scopes_supported":                      []string{utils.ScopeOpenID, utils.ScopeProfile, utils.ScopeEmail},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"preferred_username", "name", "picture", "role", "email", "email_verified",
+		},
+	})
+}
+
+// OIDCAuthorize 授权端点：校验授权请求并展示登录页面
+func OIDCAuthorize(c *gin.Context) {
+	params := authorizeParams(c.Query)
+	resp, err := OIDCServiceClient.CheckAuthorize(requestContext(c), params)
+	if err != nil {
+		authorizeError(c, params, err, "")
+		return
+	}
+	renderOIDCLogin(c, http.StatusOK, oidcLoginView{ClientName: resp.ClientName, Params: hiddenParams(params)})
+}
+
+// OIDCAuthorizeSubmit 登录页面提交：校验用户名和密码，成功后携带授权码重定向回客户端
+func OIDCAuthorizeSubmit(c *gin.Context) {
+	params := authorizeParams(c.PostForm)
+	username := c.PostForm("username")
+	resp, err := OIDCServiceClient.Authorize(requestContext(c), &proto.AuthorizeRequest{
+		Params:   params,
+		Username: username,
+		Password: c.PostForm("password"),
+		TotpCode: c.PostForm("totp_code"),
+	})
+	if err != nil {
+		authorizeError(c, params, err, username)
+		return
+	}
+	redirectToClient(c, params.RedirectUri, map[string]string{"code": resp.Code, "state": params.State})
+}
+
+// OIDCToken Token 端点：使用授权码换取 Access Token 和 ID Token
+func OIDCToken(c *gin.Context) {
+	req := &proto.TokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectUri:  c.PostForm("redirect_uri"),
+		ClientId:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+		CodeVerifier: c.PostForm("code_verifier"),
+	}
+	// client_secret_basic：按 RFC 6749 第 2.3.1 节，用户名和密码需先做表单编码
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientId, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	resp, err := OIDCServiceClient.Token(requestContext(c), req)
+	if err != nil {
+		reason, ok := oauthReason(err)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		code := http.StatusBadRequest
+		if reason == "invalid_client" {
+			code = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="LanshanClass"`)
+		}
+		c.JSON(code, gin.H{"error": reason, "error_description": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": resp.AccessToken,
+		"token_type":   resp.TokenType,
+		"expires_in":   resp.ExpiresIn,
+		"id_token":     resp.IdToken,
+		"scope":        resp.Scope,
+	})
+}
+
+// OIDCUserInfo userinfo 端点：使用 Access Token 查询用户信息
+func OIDCUserInfo(c *gin.Context) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	resp, err := OIDCServiceClient.UserInfo(requestContext(c), &proto.UserInfoRequest{AccessToken: token})
+	if err != nil {
+		if reason, ok := oauthReason(err); ok {
+			c.Header("WWW-Authenticate", `Bearer error="`+reason+`"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": reason, "error_description": status.Convert(err).Message()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	body := gin.H{"sub": resp.Sub}
+	if resp.PreferredUsername != "" {
+		body["preferred_username"] = resp.PreferredUsername
+		body["name"] = resp.Name
+		body["role"] = resp.Role
+		if resp.Picture != "" {
+			body["picture"] = resp.Picture
+		}
+	}
+	if resp.Email != "" {
+		body["email"] = resp.Email
+		body["email_verified"] = resp.EmailVerified
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// RegisterOIDCClient 注册单点登录客户端
+func RegisterOIDCClient(c *gin.Context) {
+	var req proto.RegisterClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := OIDCServiceClient.RegisterClient(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	body := oidcClientJSON(resp.Client)
+	if resp.ClientSecret != "" {
+		body["client_secret"] = resp.ClientSecret
+	}
+	c.JSON(http.StatusCreated, body)
+}
+
+// ListOIDCClients 查询单点登录客户端
+func ListOIDCClients(c *gin.Context) {
+	resp, err := OIDCServiceClient.ListClients(authContext(c), &proto.ListClientsRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	clients := make([]gin.H, 0, len(resp.Clients))
+	for _, client := range resp.Clients {
+		clients = append(clients, oidcClientJSON(client))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// DeleteOIDCClient 删除单点登录客户端
+func DeleteOIDCClient(c *gin.Context) {
+	resp, err := OIDCServiceClient.DeleteClient(authContext(c), &proto.DeleteClientRequest{ClientId: c.Param("client_id")})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
+
+// authorizeParams 从查询参数或表单中读取授权参数
+func authorizeParams(get func(string) string) *proto.AuthorizeParams {
+	return &proto.AuthorizeParams{
+		ClientId:            get("client_id"),
+		RedirectUri:         get("redirect_uri"),
+		ResponseType:        get("response_type"),
+		Scope:               get("scope"),
+		State:               get("state"),
+		Nonce:               get("nonce"),
+		CodeChallenge:       get("code_challenge"),
+		CodeChallengeMethod: get("code_challenge_method"),
+	}
+}
+
+// hiddenParams 登录表单中需要原样提交的授权参数
+func hiddenParams(p *proto.AuthorizeParams) map[string]string {
+	return map[string]string{
+		"client_id":             p.ClientId,
+		"redirect_uri":          p.RedirectUri,
+		"response_type":         p.ResponseType,
+		"scope":                 p.Scope,
+		"state":                 p.State,
+		"nonce":                 p.Nonce,
+		"code_challenge":        p.CodeChallenge,
+		"code_challenge_method": p.CodeChallengeMethod,
+	}
+}
+
+// authorizeError 处理授权端点的错误
+// 客户端或回调地址无效时只展示错误页面；其他协议错误按规范重定向回客户端；登录失败则重新展示登录页面
+func authorizeError(c *gin.Context, params *proto.AuthorizeParams, err error, username string) {
+	message := status.Convert(err).Message()
+	reason, ok := oauthReason(err)
+	switch {
+	case ok && (reason == "invalid_client" || reason == "invalid_redirect_uri"):
+		renderOIDCLogin(c, http.StatusBadRequest, oidcLoginView{Fatal: true, Error: message})
+	case ok:
+		redirectToClient(c, params.RedirectUri, map[string]string{
+			"error":             reason,
+			"error_description": message,
+			"state":             params.State,
+		})
+	case status.Code(err) == codes.Internal || status.Code(err) == codes.Unavailable:
+		renderOIDCLogin(c, http.StatusInternalServerError, oidcLoginView{Fatal: true, Error: "服务暂时不可用，请稍后重试"})
+	default:
+		// 登录失败时需要重新取得应用名称
+		view := oidcLoginView{Error: message, Username: username, Params: hiddenParams(params)}
+		if resp, err := OIDCServiceClient.CheckAuthorize(requestContext(c), params); err == nil {
+			view.ClientName = resp.ClientName
+		}
+		if retryAfter, ok := retryAfterSeconds(err); ok {
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		}
+		renderOIDCLogin(c, grpcHTTPStatus(err), view)
+	}
+}
+
+// redirectToClient 将参数追加到回调地址后重定向，空值参数不追加
+func redirectToClient(c *gin.Context, redirectURI string, values map[string]string) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		renderOIDCLogin(c, http.StatusBadRequest, oidcLoginView{Fatal: true, Error: "invalid redirect_uri"})
+		return
+	}
+	query := u.Query()
+	for k, v := range values {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, u.String())
+}
+
+// renderOIDCLogin 渲染登录页面，禁止被其他站点嵌入以防点击劫持
+func renderOIDCLogin(c *gin.Context, code int, view oidcLoginView) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Cache-Control", "no-store")
+	c.Status(code)
+	if err := oidcLoginPage.Execute(c.Writer, view); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// oauthReason 读取 gRPC 错误详情中的 OAuth 2.0 错误码
+func oauthReason(err error) (string, bool) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == "oauth2" {
+			return info.Reason, true
+		}
+	}
+	return "", false
+}
+
+// oidcClientJSON 将客户端消息转换为响应体
+func oidcClientJSON(client *proto.OIDCClient) gin.H {
+	return gin.H{
+		"client_id":     client.ClientId,
+		"name":          client.Name,
+		"redirect_uris": client.RedirectUris,
+		"public":        client.Public,
+		"created_by":    client.CreatedBy,
+		"created_at":    client.CreatedAt,
+	}
+}
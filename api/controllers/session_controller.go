@@ -0,0 +1,45 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/session_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// ListSessions 查询当前用户的登录设备
+func ListSessions(c *gin.Context) {
+	resp, err := AuthServiceClient.ListSessions(authContext(c), &proto.ListSessionsRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+
+	sessions := make([]gin.H, 0, len(resp.Sessions))
+	for _, s := range resp.Sessions {
+		sessions = append(sessions, gin.H{
+			"session_id":   s.SessionId,
+			"device":       s.Device,
+			"ip":           s.Ip,
+			"user_agent":   s.UserAgent,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"current":      s.Current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession 退出指定设备上的登录
+func RevokeSession(c *gin.Context) {
+	resp, err := AuthServiceClient.RevokeSession(authContext(c), &proto.RevokeSessionRequest{
+		SessionId: c.Param("session_id"),
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
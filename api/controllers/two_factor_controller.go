@@ -0,0 +1,84 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/two_factor_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// VerifySecondFactor 登录第二步，提交验证码或恢复码换取 Token
+func VerifySecondFactor(c *gin.Context) {
+	var req proto.VerifySecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.VerifySecondFactor(requestContext(c), &req)
+	if err != nil {
+		if retryAfter, ok := retryAfterSeconds(err); ok {
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		}
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	body := gin.H{
+		"token":         resp.Token,
+		"message":       resp.Message,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+	}
+	if len(resp.RecoveryCodes) > 0 {
+		body["recovery_codes"] = resp.RecoveryCodes
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// EnrollTOTP 获取待绑定的两步验证密钥
+func EnrollTOTP(c *gin.Context) {
+	resp, err := AuthServiceClient.EnrollTOTP(authContext(c), &proto.EnrollTOTPRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           resp.Secret,
+		"provisioning_uri": resp.ProvisioningUri,
+	})
+}
+
+// ConfirmTOTP 确认绑定两步验证
+func ConfirmTOTP(c *gin.Context) {
+	var req proto.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.ConfirmTOTP(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": resp.RecoveryCodes,
+		"message":        resp.Message,
+	})
+}
+
+// DisableTOTP 关闭两步验证
+func DisableTOTP(c *gin.Context) {
+	var req proto.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.DisableTOTP(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": resp.Message})
+}
@@ -0,0 +1,102 @@
+// FilePath: C:/LanshanClass1.3/api/controllers/user_controller.go
+package controllers
+
+import (
+	"LanshanClass1.3/proto"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/status"
+)
+
+// GetProfile 查询个人资料
+func GetProfile(c *gin.Context) {
+	resp, err := AuthServiceClient.GetProfile(authContext(c), &proto.GetProfileRequest{})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, profileJSON(resp))
+}
+
+// UpdateProfile 更新个人资料，只更新请求中出现的字段
+func UpdateProfile(c *gin.Context) {
+	var req proto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.UpdateProfile(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, profileJSON(resp))
+}
+
+// ChangePassword 修改密码
+func ChangePassword(c *gin.Context) {
+	var req proto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.ChangePassword(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), errorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":         resp.Token,
+		"refresh_token": resp.RefreshToken,
+		"expires_in":    resp.ExpiresIn,
+		"message":       resp.Message,
+	})
+}
+
+// DeleteAccount 注销账号
+func DeleteAccount(c *gin.Context) {
+	var req proto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := AuthServiceClient.DeleteAccount(authContext(c), &req)
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    resp.Message,
+		"request_id": resp.RequestId,
+		"status":     resp.Status,
+	})
+}
+
+// ExportMyData 导出个人数据，查询参数 format=json|zip，默认 json
+func ExportMyData(c *gin.Context) {
+	resp, err := AuthServiceClient.ExportMyData(authContext(c), &proto.ExportMyDataRequest{
+		Format: c.DefaultQuery("format", "json"),
+	})
+	if err != nil {
+		c.JSON(grpcHTTPStatus(err), gin.H{"error": status.Convert(err).Message()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", resp.Filename))
+	c.Data(http.StatusOK, resp.ContentType, resp.Data)
+}
+
+// profileJSON 将资料消息转换为响应体
+func profileJSON(p *proto.UserProfile) gin.H {
+	return gin.H{
+		"username":       p.Username,
+		"display_name":   p.DisplayName,
+		"avatar_url":     p.AvatarUrl,
+		"bio":            p.Bio,
+		"email":          p.Email,
+		"email_verified": p.EmailVerified,
+		"role":           p.Role,
+		"created_at":     p.CreatedAt,
+	}
+}
@@ -8,9 +8,9 @@ import (
 	"LanshanClass1.3/global/database"
 	"LanshanClass1.3/proto"
 	"LanshanClass1.3/utils"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-contrib/cors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +18,28 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// publicRoutes 无需登录即可访问的接口
+var publicRoutes = map[string]bool{
+	"POST /register":             true,
+	"POST /login":                true,
+	"POST /login/2fa":            true,
+	"POST /refresh":              true,
+	"POST /password/forgot":      true,
+	"POST /password/reset":       true,
+	"GET /email/verify":          true,
+	"GET /.well-known/jwks.json": true,
+	// OIDC 端点使用各自的凭证（登录表单、client_secret、OIDC Access Token）
+	"GET /.well-known/openid-configuration": true,
+	"GET /oidc/authorize":                   true,
+	"POST /oidc/authorize":                  true,
+	"POST /oidc/token":                      true,
+	"GET /oidc/userinfo":                    true,
+	"POST /oidc/userinfo":                   true,
+}
+
 func main() {
 	database.Init()
+	utils.InitJWT()
 	// 初始化 gRPC 客户端
 	conn, err := grpc.Dial("127.0.0.1:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -27,8 +47,14 @@ func main() {
 	}
 	defer conn.Close()
 	controllers.AuthServiceClient = proto.NewAuthServiceClient(conn)
+	controllers.AdminServiceClient = proto.NewAdminServiceClient(conn)
+	controllers.OIDCServiceClient = proto.NewOIDCServiceClient(conn)
 
 	r := gin.Default()
+	// 只信任配置的反向代理设置的 X-Forwarded-For，否则客户端可以伪造 IP 绕过按 IP 的登录限制
+	if err := r.SetTrustedProxies(database.Config.GetStringSlice("network.trusted_proxies")); err != nil {
+		log.Fatalf("invalid network.trusted_proxies: %v", err)
+	}
 
 	// 注册鉴权中间件
 	r.Use(func(c *gin.Context) {
@@ -37,8 +63,8 @@ func main() {
 		// 获取请求方法
 		method := c.Request.Method
 
-		// 如果是注册或登录请求，则跳过认证
-		if (path == "/register" || path == "/login") && method == "POST" {
+		// 公开接口跳过认证
+		if publicRoutes[method+" "+path] {
 			c.Next()
 			return
 		}
@@ -49,14 +75,22 @@ func main() {
 			c.Abort()
 			return
 		}
-		token := authHeader[len("Bearer "):]
-		claims := &utils.Claims{}
 
-		// 解析 JWT Token
-		_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-			return utils.JwtSecret, nil
-		})
-		if err != nil {
+		// 服务账号使用 API Key，权限范围由 gRPC 服务按接口校验
+		if key, ok := strings.CutPrefix(authHeader, utils.APIKeyScheme+" "); ok {
+			if _, err := utils.VerifyAPIKey(strings.TrimSpace(key)); err != nil {
+				c.JSON(401, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+
+		// 解析 JWT Token，已注销的 Token 同样拒绝
+		if _, err := utils.ParseToken(token); err != nil {
 			c.JSON(401, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
@@ -73,6 +107,8 @@ func main() {
 	}))
 	routers.AuthRouter(r)
 	routers.LiveRouter(r)
+	routers.UserRouter(r)
+	routers.AdminRouter(r)
 
 	r.Run(":8080")
 }
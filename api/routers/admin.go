@@ -0,0 +1,46 @@
+// FilePath: C:/LanshanClass1.3/api/routers/admin.go
+package routers
+
+import (
+	"LanshanClass1.3/api/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRouter 定义管理员相关的路由，权限由 gRPC 服务校验
+func AdminRouter(r *gin.Engine) {
+	admin := r.Group("/admin")
+	{
+		// 用户列表与搜索
+		admin.GET("/users", controllers.ListUsers)
+		// 查询单个用户
+		admin.GET("/users/:username", controllers.GetUser)
+		// 禁用用户
+		admin.POST("/users/:username/disable", controllers.DisableUser)
+		// 重新启用用户
+		admin.POST("/users/:username/enable", controllers.EnableUser)
+		// 设置角色
+		admin.PUT("/users/:username/role", controllers.AssignRole)
+		// 强制重置密码
+		admin.POST("/users/:username/reset-password", controllers.ResetPassword)
+		// 审批注册（approval 注册模式）
+		admin.POST("/users/:username/approve", controllers.ApproveRegistration)
+		// 解除登录锁定
+		admin.POST("/users/unlock", controllers.UnlockAccount)
+		// 角色申请审批
+		admin.GET("/roles/requests", controllers.ListRoleRequests)
+		admin.POST("/roles/approve", controllers.ApproveRole)
+		// 邀请码
+		admin.POST("/invites", controllers.CreateInvite)
+		admin.GET("/invites", controllers.ListInvites)
+		admin.DELETE("/invites/:code", controllers.RevokeInvite)
+		// 审计日志，format=csv 时导出
+		admin.GET("/audit", controllers.ListAuditEvents)
+		// 删除账号申请审批
+		admin.GET("/erasures", controllers.ListErasures)
+		admin.POST("/erasures/:id/review", controllers.ReviewErasure)
+		// 单点登录客户端
+		admin.POST("/oidc/clients", controllers.RegisterOIDCClient)
+		admin.GET("/oidc/clients", controllers.ListOIDCClients)
+		admin.DELETE("/oidc/clients/:client_id", controllers.DeleteOIDCClient)
+	}
+}
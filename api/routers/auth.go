@@ -9,4 +9,22 @@ import (
 func AuthRouter(r *gin.Engine) {
 	r.POST("/register", controllers.Register)
 	r.POST("/login", controllers.Login)
+	// 登录第二步：提交两步验证码或恢复码
+	r.POST("/login/2fa", controllers.VerifySecondFactor)
+	r.POST("/refresh", controllers.RefreshToken)
+	r.POST("/logout", controllers.Logout)
+	// 忘记密码：发送重置邮件、使用邮件中的 Token 设置新密码
+	r.POST("/password/forgot", controllers.RequestPasswordReset)
+	r.POST("/password/reset", controllers.ResetPasswordWithToken)
+	// 验证邮件中的链接
+	r.GET("/email/verify", controllers.VerifyEmail)
+	// 验签公钥，供其他服务验证 Token
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+	// OpenID Connect：发现文档、授权（登录页面）、Token、用户信息
+	r.GET("/.well-known/openid-configuration", controllers.OpenIDConfiguration)
+	r.GET("/oidc/authorize", controllers.OIDCAuthorize)
+	r.POST("/oidc/authorize", controllers.OIDCAuthorizeSubmit)
+	r.POST("/oidc/token", controllers.OIDCToken)
+	r.GET("/oidc/userinfo", controllers.OIDCUserInfo)
+	r.POST("/oidc/userinfo", controllers.OIDCUserInfo)
 }
@@ -15,16 +15,31 @@ func LiveRouter(r *gin.Engine) {
 		live.POST("/create", controllers.CreateLiveClass)
 		// 加入直播课
 		live.POST("/join", controllers.JoinLiveClass)
+		// 心跳与离开，用于记录出勤
+		live.POST("/heartbeat", controllers.Heartbeat)
+		live.POST("/leave", controllers.LeaveLiveClass)
+		// 查询或导出出勤记录
+		live.GET("/attendance", controllers.GetAttendance)
+		// 查询或订阅在线用户
+		live.GET("/participants", controllers.ListParticipants)
+		live.GET("/participants/watch", controllers.WatchParticipants)
 		// 发送消息
 		live.POST("/message/send", controllers.SendMessage)
 		// 结束直播课
 		live.POST("/end", controllers.EndLiveClass)
+		// 切换直播课状态（候场、直播中、暂停、结束）
+		live.POST("/state", controllers.UpdateLiveClassState)
 		// 发布题目
 		live.POST("/question/publish", controllers.PublishQuestion)
+		// 将用户移出直播课
+		live.POST("/kick", controllers.KickUser)
 		// 提交答案
 		live.POST("/question/submit", controllers.SubmitAnswer)
 		// 获取答题结果统计
 		live.GET("/question/statistics", controllers.GetAnswerStatistics)
 		live.GET("/message/get", controllers.GetMessages)
+		// 查询直播课列表与详情
+		live.GET("/classes", controllers.ListLiveClasses)
+		live.GET("/classes/:class_id", controllers.GetLiveClass)
 	}
 }
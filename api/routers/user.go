@@ -0,0 +1,37 @@
+// FilePath: C:/LanshanClass1.3/api/routers/user.go
+package routers
+
+import (
+	"LanshanClass1.3/api/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// UserRouter 定义个人资料与账号管理相关的路由
+func UserRouter(r *gin.Engine) {
+	user := r.Group("/user")
+	{
+		// 查询个人资料
+		user.GET("/profile", controllers.GetProfile)
+		// 更新个人资料
+		user.PUT("/profile", controllers.UpdateProfile)
+		// 修改密码
+		user.POST("/password", controllers.ChangePassword)
+		// 注销账号
+		user.DELETE("", controllers.DeleteAccount)
+		// 导出个人数据
+		user.GET("/export", controllers.ExportMyData)
+		// 重新发送验证邮件
+		user.POST("/email/verify", controllers.SendVerificationEmail)
+		// 两步验证：获取密钥、确认绑定、关闭
+		user.POST("/2fa/enroll", controllers.EnrollTOTP)
+		user.POST("/2fa/confirm", controllers.ConfirmTOTP)
+		user.POST("/2fa/disable", controllers.DisableTOTP)
+		// 登录设备管理：查询会话、远程退出
+		user.GET("/sessions", controllers.ListSessions)
+		user.DELETE("/sessions/:session_id", controllers.RevokeSession)
+		// 服务账号 API Key：签发、查询、撤销
+		user.POST("/api-keys", controllers.CreateAPIKey)
+		user.GET("/api-keys", controllers.ListAPIKeys)
+		user.DELETE("/api-keys/:id", controllers.RevokeAPIKey)
+	}
+}
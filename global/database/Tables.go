@@ -2,65 +2,143 @@
 package database
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 用户角色
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+// 账号来源
+const (
+	SourceLocal = "local"
+	SourceLDAP  = "ldap"
 )
 
 // User 表示用户表
 type User struct {
-	ID       uint   `gorm:"primaryKey;autoIncrement"`
-	Username string `gorm:"type:varchar(100);uniqueIndex;not null"`
-	Salt     string `gorm:"not_null"`
-	Hash     string `gorm:"not_null"`
+	ID             uint    `gorm:"primaryKey;autoIncrement"`
+	Username       string  `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Salt           string  `gorm:"not_null"`                                  // 仅旧版 SHA-256 哈希使用，新哈希串自带盐
+	Hash           string  `gorm:"not_null"`                                  // 自描述的密码哈希串，见 PasswordHasher
+	Role           string  `gorm:"type:varchar(20);not null;default:student"` // 当前角色
+	RequestedRole  string  `gorm:"type:varchar(20)"`                          // 注册时申请、待管理员审批的角色
+	DisplayName    string  `gorm:"type:varchar(50)"`                          // 昵称，聊天消息和课堂名单中展示
+	AvatarURL      string  `gorm:"type:varchar(500)"`                         // 头像地址
+	Bio            string  `gorm:"type:varchar(500)"`                         // 个人简介
+	Email          string  `gorm:"type:varchar(255);index"`                   // 邮箱
+	EmailVerified  bool    `gorm:"not null;default:false"`                    // 邮箱是否已验证
+	Disabled       bool    `gorm:"not null;default:false"`                    // 是否被管理员禁用
+	Pending        bool    `gorm:"not null;default:false"`                    // 审批注册模式下等待管理员审批，审批前不能登录
+	InviteID       *uint   `gorm:"index"`                                     // 注册时使用的邀请码
+	Invite         *Invite `gorm:"constraint:OnDelete:SET NULL"`
+	TOTPSecret     string  `gorm:"column:totp_secret;type:varchar(64)"` // 两步验证密钥（Base32）
+	TOTPEnabled    bool    `gorm:"column:totp_enabled;not null;default:false"`
+	ServiceAccount bool    `gorm:"not null;default:false"`                  // 服务账号，只能通过 API Key 调用接口，不能登录
+	Owner          string  `gorm:"type:varchar(100);index"`                 // 服务账号的管理者
+	Source         string  `gorm:"type:varchar(20);not null;default:local"` // 账号来源：local 本地注册，ldap 统一身份认证目录
+	LastLoginAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
-// GenerateSalt 生成随机盐
-func GenerateSalt() (string, error) {
-	// 生成 16 字节的随机盐
-	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
-	if err != nil {
-		return "", err
+// External 判断账号是否由外部目录管理，外部账号的密码不保存在本地
+func (u *User) External() bool {
+	return u.Source != "" && u.Source != SourceLocal
+}
+
+// Name 返回用于展示的名字，未设置昵称时使用用户名
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
 	}
-	return hex.EncodeToString(salt), nil
+	return u.Username
 }
 
-// HashPassword 生成哈希密码
-func HashPassword(password, salt string) string {
-	// 将密码和盐拼接后进行哈希
-	hash := sha256.Sum256([]byte(password + salt))
-	return hex.EncodeToString(hash[:])
+// RecoveryCode 两步验证恢复码，只保存哈希，每个恢复码只能使用一次
+type RecoveryCode struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement"`
+	UserID uint   `gorm:"index;not null"`
+	Hash   string `gorm:"type:char(64);not null"`
+	UsedAt *time.Time
 }
 
-// CreateUser 创建新用户
-func CreateUser(username, password string) error {
-	// 生成盐
-	salt, err := GenerateSalt()
+// ValidRole 判断角色名是否合法
+func ValidRole(role string) bool {
+	return role == RoleStudent || role == RoleTeacher || role == RoleAdmin
+}
+
+// NewUser 注册新用户所需的信息
+type NewUser struct {
+	Username      string
+	Password      string
+	RequestedRole string // 申请的角色，非学生角色需审批后生效
+	Email         string
+	InviteCode    string // 邀请码，可为空
+	Pending       bool   // 是否需要管理员审批后才能登录，使用邀请码注册时忽略
+}
+
+// CreateUser 创建新用户，新用户默认为学生，申请的教师或管理员角色需审批后生效
+// 使用邀请码时直接获得邀请码中的角色并加入其中的课程，邀请码不可用时返回 ErrInvalidInvite
+func CreateUser(params NewUser) (*User, error) {
+	// 生成自描述的哈希串，盐和算法参数都包含在其中
+	hash, err := HashPassword(params.Password)
 	if err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// 生成哈希密码
-	hash := HashPassword(password, salt)
-
-	// 创建用户
 	user := User{
-		Username: username,
-		Salt:     salt,
+		Username: params.Username,
 		Hash:     hash,
+		Role:     RoleStudent,
+		Email:    params.Email,
+		Pending:  params.Pending,
 	}
-
-	// 保存到数据库
-	if err := DB.Create(&user).Error; err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+	if params.RequestedRole != "" && params.RequestedRole != RoleStudent {
+		user.RequestedRole = params.RequestedRole
 	}
 
-	return nil
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var invite *Invite
+		if params.InviteCode != "" {
+			invite, err = useInvite(tx, params.InviteCode)
+			if err != nil {
+				return err
+			}
+			user.InviteID = &invite.ID
+			user.Role = invite.Role
+			user.RequestedRole = ""
+			user.Pending = false
+		}
+
+		// 保存到数据库
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		if invite != nil && invite.ClassID != "" {
+			enrollment := Enrollment{Username: user.Username, ClassID: invite.ClassID, InviteID: &invite.ID}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return fmt.Errorf("failed to enroll user: %w", err)
+			}
+		}
+		user.Invite = invite
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-// VerifyPassword 验证密码
+// VerifyPassword 验证密码，验证成功时将旧格式或参数过时的哈希升级为当前算法
 func VerifyPassword(username, password string) (bool, error) {
 	// 查询用户
 	var user User
@@ -68,9 +146,328 @@ func VerifyPassword(username, password string) (bool, error) {
 		return false, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	// 生成哈希密码
-	hash := HashPassword(password, user.Salt)
+	ok, needsRehash, err := checkPassword(&user, password)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if ok && needsRehash {
+		// 升级失败不影响本次登录，下次登录时会再次尝试
+		if err := SetPassword(user.Username, password); err != nil {
+			log.Printf("failed to upgrade password hash for %s: %v", username, err)
+		}
+	}
+	return ok, nil
+}
+
+// GetUser 根据用户名查询用户
+func GetUser(username string) (*User, error) {
+	var user User
+	if err := DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetDisplayName 返回用户的展示名，查询失败时退回用户名
+func GetDisplayName(username string) string {
+	user, err := GetUser(username)
+	if err != nil {
+		return username
+	}
+	return user.Name()
+}
+
+// GetDisplayNames 批量查询用户的展示名，查不到的用户使用用户名
+func GetDisplayNames(usernames []string) map[string]string {
+	names := make(map[string]string, len(usernames))
+	for _, username := range usernames {
+		names[username] = username
+	}
+	if len(usernames) == 0 {
+		return names
+	}
+	var users []User
+	if err := DB.Where("username IN ?", usernames).Find(&users).Error; err != nil {
+		return names
+	}
+	for i := range users {
+		names[users[i].Username] = users[i].Name()
+	}
+	return names
+}
+
+// ProfileUpdate 描述个人资料的部分更新，nil 字段保持不变
+type ProfileUpdate struct {
+	DisplayName *string
+	AvatarURL   *string
+	Bio         *string
+	Email       *string
+}
+
+// UpdateProfile 更新个人资料
+func UpdateProfile(username string, update ProfileUpdate) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	if update.DisplayName != nil {
+		user.DisplayName = *update.DisplayName
+		columns = append(columns, "display_name")
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = *update.AvatarURL
+		columns = append(columns, "avatar_url")
+	}
+	if update.Bio != nil {
+		user.Bio = *update.Bio
+		columns = append(columns, "bio")
+	}
+	if update.Email != nil && *update.Email != user.Email {
+		// 更换邮箱后需要重新验证
+		user.Email = *update.Email
+		user.EmailVerified = false
+		columns = append(columns, "email", "email_verified")
+	}
+	if len(columns) == 0 {
+		return user, nil
+	}
+
+	if err := DB.Model(user).Select(columns).Updates(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	return user, nil
+}
+
+// GetUserByEmail 根据邮箱查询用户
+func GetUserByEmail(email string) (*User, error) {
+	var user User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &user, nil
+}
+
+// EmailInUse 判断邮箱是否已被其他用户使用
+func EmailInUse(email, exceptUsername string) (bool, error) {
+	var count int64
+	if err := DB.Model(&User{}).Where("email = ? AND username <> ?", email, exceptUsername).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return count > 0, nil
+}
+
+// MarkEmailVerified 将用户的邮箱标记为已验证，邮箱已被更换时返回 gorm.ErrRecordNotFound
+func MarkEmailVerified(username, email string) error {
+	result := DB.Model(&User{}).Where("username = ? AND email = ?", username, email).Update("email_verified", true)
+	if result.Error != nil {
+		return fmt.Errorf("failed to verify email: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to find user: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// SetPassword 使用当前算法重新设置用户密码
+func SetPassword(username, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	result := DB.Model(&User{}).Where("username = ?", username).
+		Select("hash", "salt").Updates(User{Hash: hash, Salt: ""})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update password: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to find user: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// UserFilter 用户列表的查询条件
+type UserFilter struct {
+	Query           string // 按用户名、昵称或邮箱模糊搜索
+	Role            string
+	IncludeDisabled bool
+	PendingOnly     bool // 只查询等待审批的注册
+	Page            int  // 从 1 开始
+	PageSize        int
+}
 
-	// 比较哈希值
-	return hash == user.Hash, nil
+// ListUsers 分页查询用户，返回当前页和总数
+func ListUsers(filter UserFilter) ([]User, int64, error) {
+	query := DB.Model(&User{})
+	if filter.Query != "" {
+		like := containsPattern(filter.Query)
+		query = query.Where(`username LIKE ? ESCAPE '\\' OR display_name LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\'`, like, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if !filter.IncludeDisabled {
+		query = query.Where("disabled = ?", false)
+	}
+	if filter.PendingOnly {
+		query = query.Where("pending = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var users []User
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Preload("Invite").Order("id").Offset(offset).Limit(filter.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// SetUserDisabled 禁用或启用用户
+func SetUserDisabled(username string, disabled bool) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Disabled = disabled
+	if err := DB.Model(user).Select("disabled").Updates(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// AssignRole 直接设置用户角色，并清除待审批的角色申请
+func AssignRole(username, role string) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	user.RequestedRole = ""
+	if err := DB.Model(user).Select("role", "requested_role").Updates(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	return user, nil
+}
+
+// RecordLogin 记录用户最近一次登录时间
+func RecordLogin(username string) error {
+	return DB.Model(&User{}).Where("username = ?", username).Update("last_login_at", time.Now()).Error
+}
+
+// EnableTOTP 启用两步验证，并用新的恢复码替换旧恢复码
+func EnableTOTP(username, secret string, recoveryCodeHashes []string) error {
+	user, err := GetUser(username)
+	if err != nil {
+		return err
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		user.TOTPSecret = secret
+		user.TOTPEnabled = true
+		if err := tx.Model(user).Select("totp_secret", "totp_enabled").Updates(user).Error; err != nil {
+			return fmt.Errorf("failed to enable TOTP: %w", err)
+		}
+		return replaceRecoveryCodes(tx, user.ID, recoveryCodeHashes)
+	})
+}
+
+// DisableTOTP 关闭两步验证并删除恢复码
+func DisableTOTP(username string) error {
+	user, err := GetUser(username)
+	if err != nil {
+		return err
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+		if err := tx.Model(user).Select("totp_secret", "totp_enabled").Updates(user).Error; err != nil {
+			return fmt.Errorf("failed to disable TOTP: %w", err)
+		}
+		return replaceRecoveryCodes(tx, user.ID, nil)
+	})
+}
+
+// replaceRecoveryCodes 删除用户的全部恢复码并写入新的恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	codes := make([]RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, RecoveryCode{UserID: userID, Hash: hash})
+	}
+	if err := tx.Create(&codes).Error; err != nil {
+		return fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode 使用一个未用过的恢复码，成功返回 true
+func UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := DB.Model(&RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ResolvePendingUser 审批等待审批的注册：通过后可以登录，驳回则删除该账号
+func ResolvePendingUser(username string, approve bool) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Pending {
+		return nil, fmt.Errorf("user %s is not pending approval", username)
+	}
+
+	if !approve {
+		if err := DB.Delete(user).Error; err != nil {
+			return nil, fmt.Errorf("failed to delete user: %w", err)
+		}
+		return user, nil
+	}
+	user.Pending = false
+	if err := DB.Model(user).Update("pending", false).Error; err != nil {
+		return nil, fmt.Errorf("failed to approve user: %w", err)
+	}
+	return user, nil
+}
+
+// ListRoleRequests 查询所有待审批的角色申请
+func ListRoleRequests() ([]User, error) {
+	var users []User
+	if err := DB.Where("requested_role <> ''").Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list role requests: %w", err)
+	}
+	return users, nil
+}
+
+// ResolveRoleRequest 审批用户的角色申请，approve 为 false 时驳回申请
+func ResolveRoleRequest(username string, approve bool) (*User, error) {
+	user, err := GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.RequestedRole == "" {
+		return nil, fmt.Errorf("user %s has no pending role request", username)
+	}
+
+	if approve {
+		user.Role = user.RequestedRole
+	}
+	user.RequestedRole = ""
+	if err := DB.Model(user).Select("role", "requested_role").Updates(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	return user, nil
 }
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API Key 权限范围
+const (
+	ScopeQuestionsWrite = "questions:write" // 发布题目
+	ScopeStatsRead      = "stats:read"      // 查询答题统计
+)
+
+// ValidScope 判断权限范围是否合法
+func ValidScope(scope string) bool {
+	return scope == ScopeQuestionsWrite || scope == ScopeStatsRead
+}
+
+// ErrNotServiceAccount 表示用户名已被普通用户或其他人的服务账号占用
+var ErrNotServiceAccount = errors.New("username is taken by another account")
+
+// APIKey 服务账号的长期凭证，只保存密钥的哈希
+type APIKey struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	Prefix     string `gorm:"type:varchar(16);uniqueIndex;not null"` // 密钥中的公开前缀，用于查找
+	Hash       string `gorm:"type:char(64);not null"`                // 完整密钥的 SHA-256
+	Name       string `gorm:"type:varchar(100);not null"`
+	Username   string `gorm:"type:varchar(100);index;not null"` // 所属服务账号
+	Scopes     string `gorm:"type:varchar(255);not null"`       // 逗号分隔的权限范围
+	CreatedBy  string `gorm:"type:varchar(100);not null"`
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time // 为空表示永不过期
+	LastUsedAt *time.Time
+	Revoked    bool `gorm:"not null;default:false"`
+}
+
+// Active 判断 API Key 当前是否有效
+func (k *APIKey) Active(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// EnsureServiceAccount 查询 owner 管理的服务账号，不存在时以 role 创建
+// 服务账号的密码是随机生成后丢弃的，因此无法通过密码登录
+func EnsureServiceAccount(username, owner, role, randomPassword string) (*User, error) {
+	user, err := GetUser(username)
+	if err == nil {
+		if !user.ServiceAccount || user.Owner != owner {
+			return nil, ErrNotServiceAccount
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := HashPassword(randomPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user = &User{
+		Username:       username,
+		Hash:           hash,
+		Role:           role,
+		ServiceAccount: true,
+		Owner:          owner,
+	}
+	if err := DB.Create(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+	return user, nil
+}
+
+// CreateAPIKey 保存 API Key
+func CreateAPIKey(key *APIKey) error {
+	if err := DB.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys 查询 owner 管理的服务账号的 API Key，owner 为空时查询全部
+func ListAPIKeys(owner string, includeRevoked bool) ([]APIKey, error) {
+	query := DB.Model(&APIKey{})
+	if owner != "" {
+		query = query.Where("username IN (?)", DB.Model(&User{}).Select("username").Where("owner = ?", owner))
+	}
+	if !includeRevoked {
+		query = query.Where("revoked = ?", false)
+	}
+	var keys []APIKey
+	if err := query.Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetAPIKey 根据 ID 查询 API Key
+func GetAPIKey(id uint) (*APIKey, error) {
+	var key APIKey
+	if err := DB.First(&key, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetAPIKeyByPrefix 根据公开前缀查询 API Key
+func GetAPIKeyByPrefix(prefix string) (*APIKey, error) {
+	var key APIKey
+	if err := DB.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey 撤销 API Key
+func RevokeAPIKey(id uint) error {
+	if err := DB.Model(&APIKey{}).Where("id = ?", id).Update("revoked", true).Error; err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// TouchAPIKey 记录 API Key 最近使用时间
+func TouchAPIKey(id uint, at time.Time) error {
+	if err := DB.Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error; err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttendanceRecord 用户在直播课中的一段在线记录，从加入到离开；同一用户多次进出会有多条记录
+type AttendanceRecord struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	ClassID    string     `gorm:"type:varchar(64);index;not null"`
+	Username   string     `gorm:"type:varchar(100);index;not null"` // 账号删除后替换为化名
+	JoinedAt   time.Time  `gorm:"not null"`
+	LastSeenAt time.Time  // 最后一次心跳时间，超时未收到心跳时以此作为离开时间
+	LeftAt     *time.Time `gorm:"index"` // 仍在线时为空
+}
+
+// Duration 在线时长，仍在线的记录计算到 now
+func (r *AttendanceRecord) Duration(now time.Time) time.Duration {
+	end := now
+	if r.LeftAt != nil {
+		end = *r.LeftAt
+	}
+	if end.Before(r.JoinedAt) {
+		return 0
+	}
+	return end.Sub(r.JoinedAt)
+}
+
+// RecordAttendance 记录用户加入直播课，用户仍在线（例如刷新页面）时只更新心跳时间
+func RecordAttendance(classID, username string) error {
+	if _, err := TouchAttendance(classID, username); err != nil {
+		return fmt.Errorf("failed to record attendance: %w", err)
+	}
+	return nil
+}
+
+// TouchAttendance 更新用户的心跳时间，用户没有在线记录（已离开或超时）时新建一条，返回是否新建
+// 在事务中锁定直播课记录，同一直播课的加入和心跳依次执行，并发请求不会为同一用户创建两条在线记录
+func TouchAttendance(classID, username string) (bool, error) {
+	created := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var class LiveClass
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", classID).
+			Limit(1).Find(&class).Error; err != nil {
+			return fmt.Errorf("failed to lock live class: %w", err)
+		}
+
+		// 先查询再更新：心跳时间未变化时 MySQL 的影响行数为 0，不能据此判断是否有在线记录
+		now := time.Now()
+		var open []AttendanceRecord
+		if err := tx.Select("id").Where("class_id = ? AND username = ? AND left_at IS NULL", classID, username).
+			Find(&open).Error; err != nil {
+			return fmt.Errorf("failed to load attendance: %w", err)
+		}
+		if len(open) > 0 {
+			if err := tx.Model(&AttendanceRecord{}).Where("id = ?", open[0].ID).Update("last_seen_at", now).Error; err != nil {
+				return fmt.Errorf("failed to update attendance: %w", err)
+			}
+			return nil
+		}
+		record := AttendanceRecord{ClassID: classID, Username: username, JoinedAt: now, LastSeenAt: now}
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to create attendance: %w", err)
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// LeaveAttendance 记录用户离开直播课
+func LeaveAttendance(classID, username string) error {
+	now := time.Now()
+	err := DB.Model(&AttendanceRecord{}).
+		Where("class_id = ? AND username = ? AND left_at IS NULL", classID, username).
+		Updates(map[string]interface{}{"left_at": &now, "last_seen_at": now}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record leave: %w", err)
+	}
+	return nil
+}
+
+// CloseClassAttendance 直播课结束时记录所有在线用户离开
+func CloseClassAttendance(classID string, at time.Time) error {
+	err := DB.Model(&AttendanceRecord{}).Where("class_id = ? AND left_at IS NULL", classID).
+		Updates(map[string]interface{}{"left_at": &at, "last_seen_at": at}).Error
+	if err != nil {
+		return fmt.Errorf("failed to close attendance: %w", err)
+	}
+	return nil
+}
+
+// CloseStaleAttendance 将最后心跳早于 before 的在线记录视为已离开，离开时间为最后心跳时间，返回关闭的记录数
+func CloseStaleAttendance(before time.Time) (int64, error) {
+	result := DB.Model(&AttendanceRecord{}).Where("left_at IS NULL AND last_seen_at < ?", before).
+		Update("left_at", gorm.Expr("last_seen_at"))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to close stale attendance: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListAttendance 查询直播课的全部在线记录，按用户名和加入时间排序
+func ListAttendance(classID string) ([]AttendanceRecord, error) {
+	var records []AttendanceRecord
+	if err := DB.Where("class_id = ?", classID).Order("username, joined_at").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list attendance: %w", err)
+	}
+	return records, nil
+}
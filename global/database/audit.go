@@ -0,0 +1,131 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计事件类型
+const (
+	AuditRegister         = "register"
+	AuditLogin            = "login"
+	AuditLogout           = "logout"
+	AuditPasswordChange   = "password_change"
+	AuditRoleChange       = "role_change"
+	AuditClassCreate      = "class_create"
+	AuditClassEnd         = "class_end"
+	AuditClassState       = "class_state"
+	AuditQuestionPublish  = "question_publish"
+	AuditUserKick         = "user_kick"
+	AuditAttendanceExport = "attendance_export"
+	AuditAPIKeyCreate     = "api_key_create"
+	AuditAPIKeyRevoke     = "api_key_revoke"
+	AuditDataExport       = "data_export"
+	AuditErasureRequest   = "erasure_request"
+	AuditErasureReview    = "erasure_review"
+	AuditErasure          = "erasure"
+)
+
+// 审计事件结果
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure" // 凭证错误、参数错误等
+	AuditDenied  = "denied"  // 权限不足、账号被禁用或锁定
+)
+
+// AuditEvent 安全相关操作的审计记录，只追加，不删除；只有删除账号时会将其中的用户名替换为化名
+type AuditEvent struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `gorm:"index"`
+	Actor     string    `gorm:"type:varchar(255);index"` // 操作者用户名，登录失败时为尝试登录的用户名
+	Action    string    `gorm:"type:varchar(32);index;not null"`
+	Target    string    `gorm:"type:varchar(255);index"` // 操作对象，如用户名或直播课 ID
+	Outcome   string    `gorm:"type:varchar(16);not null"`
+	IP        string    `gorm:"type:varchar(64)"`
+	UserAgent string    `gorm:"type:varchar(512)"`
+	Detail    string    `gorm:"type:varchar(1000)"`
+}
+
+// AuditFilter 审计事件的查询条件，零值字段不参与过滤
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Target   string
+	Outcome  string
+	IP       string
+	Since    time.Time
+	Until    time.Time
+	Page     int // 从 1 开始
+	PageSize int
+}
+
+// RecordAuditEvent 写入一条审计事件
+func RecordAuditEvent(event *AuditEvent) error {
+	if err := DB.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEvents 按时间倒序分页查询审计事件
+func ListAuditEvents(filter AuditFilter) ([]AuditEvent, int64, error) {
+	query := auditQuery(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	var events []AuditEvent
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(filter.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return events, total, nil
+}
+
+// EachAuditEvent 按时间顺序分批读取全部符合条件的审计事件，用于导出
+func EachAuditEvent(filter AuditFilter, fn func(*AuditEvent) error) error {
+	var batch []AuditEvent
+	result := auditQuery(filter).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to export audit events: %w", result.Error)
+	}
+	return nil
+}
+
+// auditQuery 根据过滤条件构造查询
+func auditQuery(filter AuditFilter) *gorm.DB {
+	query := DB.Model(&AuditEvent{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	return query
+}
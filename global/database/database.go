@@ -45,10 +45,73 @@ func initConfig() {
 	}
 
 	// 设置默认值
-	Config.SetDefault("mysql.dsn", "user:password@tcp(127.0.0.1:3306)/dbname")
-	Config.SetDefault("redis.addr", "localhost:6379")
-	Config.SetDefault("redis.password", "")
-	Config.SetDefault("redis.db", 0)
+	SetDefaults(Config)
+}
+
+// SetDefaults 设置配置项的默认值，测试中可以用于不读取配置文件的 Viper 实例
+func SetDefaults(c *viper.Viper) {
+	c.SetDefault("mysql.dsn", "user:password@tcp(127.0.0.1:3306)/dbname")
+	c.SetDefault("redis.addr", "localhost:6379")
+	c.SetDefault("redis.password", "")
+	c.SetDefault("redis.db", 0)
+	c.SetDefault("jwt.access_ttl", "15m")
+	c.SetDefault("jwt.refresh_ttl", "168h")
+	c.SetDefault("password.algorithm", "argon2id")
+	c.SetDefault("password.argon2id.memory", 64*1024)
+	c.SetDefault("password.argon2id.iterations", 3)
+	c.SetDefault("password.argon2id.parallelism", 2)
+	c.SetDefault("password.argon2id.salt_length", 16)
+	c.SetDefault("password.argon2id.key_length", 32)
+	c.SetDefault("password.bcrypt.cost", 12)
+	c.SetDefault("login_limit.window", "15m")
+	c.SetDefault("login_limit.max_failures_per_user", 5)
+	c.SetDefault("login_limit.max_failures_per_ip", 20)
+	c.SetDefault("login_limit.lockout_base", "1m")
+	c.SetDefault("login_limit.lockout_max", "1h")
+	c.SetDefault("two_factor.issuer", "LanshanClass")
+	c.SetDefault("two_factor.required_roles", []string{})
+	c.SetDefault("two_factor.challenge_ttl", "5m")
+	c.SetDefault("two_factor.max_attempts", 5)
+	c.SetDefault("mail.driver", "log")
+	c.SetDefault("mail.from", "LanshanClass <noreply@localhost>")
+	c.SetDefault("mail.base_url", "http://localhost:8080")
+	c.SetDefault("mail.file_path", "./mail.log")
+	c.SetDefault("mail.smtp.port", 587)
+	c.SetDefault("mail.verification_ttl", "24h")
+	c.SetDefault("mail.reset_ttl", "30m")
+	c.SetDefault("mail.require_verification", false)
+	c.SetDefault("registration.mode", "open")
+	c.SetDefault("oidc.issuer", "http://localhost:8080")
+	c.SetDefault("oidc.code_ttl", "1m")
+	c.SetDefault("oidc.access_token_ttl", "1h")
+	c.SetDefault("oidc.id_token_ttl", "1h")
+	c.SetDefault("username_policy.min_length", 3)
+	c.SetDefault("username_policy.max_length", 32)
+	c.SetDefault("username_policy.pattern", "^[A-Za-z0-9_.-]+$")
+	c.SetDefault("username_policy.charset_description", "字母、数字、下划线、点和连字符")
+	c.SetDefault("password_policy.min_length", 8)
+	c.SetDefault("password_policy.max_length", 128)
+	c.SetDefault("password_policy.required_classes", []string{"letter", "digit"})
+	c.SetDefault("password_policy.blocklist_file", "")
+	c.SetDefault("erasure.poll_interval", "1m")
+	c.SetDefault("live.scheduler_interval", "30s")
+	c.SetDefault("live.lobby_lead", "10m")
+	c.SetDefault("live.archive_after", "168h")
+	c.SetDefault("live.heartbeat_timeout", "90s")
+	c.SetDefault("live.presence_sweep_interval", "5s")
+	c.SetDefault("auth.backend", "mysql")
+	c.SetDefault("network.trusted_proxies", []string{})
+	c.SetDefault("network.trusted_gateways", []string{"127.0.0.1", "::1"})
+	c.SetDefault("auth.ldap.url", "ldap://localhost:389")
+	c.SetDefault("auth.ldap.timeout", "5s")
+	c.SetDefault("auth.ldap.user_filter", "(uid={username})")
+	c.SetDefault("auth.ldap.username_attribute", "uid")
+	c.SetDefault("auth.ldap.display_name_attribute", "cn")
+	c.SetDefault("auth.ldap.email_attribute", "mail")
+	c.SetDefault("auth.ldap.group_attribute", "memberOf")
+	c.SetDefault("auth.ldap.group_filter", "(member={dn})")
+	c.SetDefault("auth.ldap.default_role", "student")
+	c.SetDefault("auth.ldap.allow_local", true)
 }
 
 func initMySQL() {
@@ -74,7 +137,16 @@ func initMySQL() {
 	}
 
 	log.Println("MySQL connected successfully")
-	DB.AutoMigrate(&User{})
+	if err := Migrate(); err != nil {
+		log.Printf("Migrate failed: %v", err)
+	}
+}
+
+// Migrate 创建或更新全部数据表
+func Migrate() error {
+	return DB.AutoMigrate(&Invite{}, &User{}, &RecoveryCode{}, &Enrollment{}, &AuditEvent{}, &APIKey{}, &OIDCClient{},
+		&ChatMessage{}, &QuizAnswer{}, &AttendanceRecord{}, &ErasureRequest{},
+		&LiveClass{}, &LiveQuestion{}, &ClassKick{})
 }
 func initRedis() {
 	// 从配置文件中获取 Redis 配置
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrSourceConflict 外部目录中的账号与本地已有的其他来源账号重名
+var ErrSourceConflict = errors.New("username belongs to another account source")
+
+// ExternalUser 外部目录中的账号信息
+type ExternalUser struct {
+	Username    string
+	Source      string // 账号来源，如 SourceLDAP
+	DisplayName string
+	Email       string
+	Role        string // 按目录中的组映射得到的角色
+}
+
+// SyncExternalUser 首次登录时为外部账号创建本地用户，之后每次登录同步角色
+// 外部账号的密码是随机生成后丢弃的，不能通过本地密码登录；返回同步前的角色，新建用户时为空
+func SyncExternalUser(ext ExternalUser, randomPassword string) (*User, string, error) {
+	user, err := GetUser(ext.Username)
+	if err == nil {
+		if user.Source != ext.Source || user.ServiceAccount {
+			return nil, "", ErrSourceConflict
+		}
+		previousRole := user.Role
+		if user.Role != ext.Role {
+			user.Role = ext.Role
+			user.RequestedRole = ""
+			if err := DB.Model(user).Select("role", "requested_role").Updates(user).Error; err != nil {
+				return nil, "", fmt.Errorf("failed to update role: %w", err)
+			}
+		}
+		return user, previousRole, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	hash, err := HashPassword(randomPassword)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	user = &User{
+		Username:      ext.Username,
+		Hash:          hash,
+		Role:          ext.Role,
+		DisplayName:   ext.DisplayName,
+		Email:         ext.Email,
+		EmailVerified: ext.Email != "", // 目录中的邮箱由学校维护，视为已验证
+		Source:        ext.Source,
+	}
+	if err := DB.Create(user).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, "", nil
+}
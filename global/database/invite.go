@@ -0,0 +1,150 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 注册模式，由配置 registration.mode 选择
+const (
+	RegistrationOpen     = "open"     // 任何人都可以注册
+	RegistrationInvite   = "invite"   // 必须使用邀请码注册
+	RegistrationApproval = "approval" // 没有邀请码的注册需管理员审批后才能登录
+)
+
+// ErrInvalidInvite 表示邀请码不存在、已撤销、已过期或已用完
+var ErrInvalidInvite = errors.New("invalid, expired or used up invite code")
+
+// Invite 管理员签发的邀请码，使用邀请码注册的用户直接获得其中的角色并加入指定课程
+type Invite struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	Code      string     `gorm:"type:varchar(32);uniqueIndex;not null"`
+	Role      string     `gorm:"type:varchar(20);not null;default:student"`
+	ClassID   string     `gorm:"type:varchar(64)"`   // 注册后自动加入的课程，可为空
+	MaxUses   int        `gorm:"not null;default:0"` // 最多可使用次数，0 表示不限
+	Uses      int        `gorm:"not null;default:0"`
+	ExpiresAt *time.Time // 为空表示永不过期
+	Revoked   bool       `gorm:"not null;default:false"`
+	CreatedBy string     `gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time
+}
+
+// Enrollment 用户通过邀请码加入课程的记录，仅限报名用户参与的直播课只允许已报名的用户加入
+type Enrollment struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Username  string `gorm:"type:varchar(255);uniqueIndex:idx_enrollment;not null"`
+	ClassID   string `gorm:"type:varchar(64);uniqueIndex:idx_enrollment;not null"`
+	InviteID  *uint  // 通过邀请码加入时记录邀请码
+	CreatedAt time.Time
+}
+
+// Usable 判断邀请码当前是否可用
+func (i *Invite) Usable(now time.Time) bool {
+	if i.Revoked {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// RegistrationMode 返回当前的注册模式
+func RegistrationMode() string {
+	switch mode := Config.GetString("registration.mode"); mode {
+	case RegistrationInvite, RegistrationApproval:
+		return mode
+	default:
+		return RegistrationOpen
+	}
+}
+
+// CreateInvite 保存邀请码，指定了直播课时将该直播课标记为仅限报名用户参与
+func CreateInvite(invite *Invite) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(invite).Error; err != nil {
+			return fmt.Errorf("failed to create invite: %w", err)
+		}
+		if invite.ClassID == "" {
+			return nil
+		}
+		if err := tx.Model(&LiveClass{}).Where("id = ?", invite.ClassID).Update("invite_only", true).Error; err != nil {
+			return fmt.Errorf("failed to mark live class invite-only: %w", err)
+		}
+		return nil
+	})
+}
+
+// IsEnrolled 检查用户是否已报名直播课
+func IsEnrolled(classID, username string) (bool, error) {
+	var count int64
+	if err := DB.Model(&Enrollment{}).Where("class_id = ? AND username = ?", classID, username).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ListInvites 查询邀请码，includeInactive 为 false 时只返回当前可用的邀请码
+func ListInvites(includeInactive bool) ([]Invite, error) {
+	var invites []Invite
+	if err := DB.Order("id DESC").Find(&invites).Error; err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	if includeInactive {
+		return invites, nil
+	}
+	now := time.Now()
+	active := invites[:0]
+	for _, invite := range invites {
+		if invite.Usable(now) {
+			active = append(active, invite)
+		}
+	}
+	return active, nil
+}
+
+// RevokeInvite 撤销邀请码
+func RevokeInvite(code string) (*Invite, error) {
+	var invite Invite
+	if err := DB.Where("code = ?", code).First(&invite).Error; err != nil {
+		return nil, fmt.Errorf("failed to find invite: %w", err)
+	}
+	invite.Revoked = true
+	if err := DB.Model(&invite).Update("revoked", true).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	return &invite, nil
+}
+
+// GetInviteByID 根据 ID 查询邀请码
+func GetInviteByID(id uint) (*Invite, error) {
+	var invite Invite
+	if err := DB.First(&invite, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to find invite: %w", err)
+	}
+	return &invite, nil
+}
+
+// useInvite 在事务中占用一次邀请码，条件更新保证并发注册时不会超出使用次数
+func useInvite(tx *gorm.DB, code string) (*Invite, error) {
+	now := time.Now()
+	result := tx.Model(&Invite{}).
+		Where("code = ? AND revoked = ? AND (expires_at IS NULL OR expires_at > ?) AND (max_uses = 0 OR uses < max_uses)",
+			code, false, now).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to use invite: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidInvite
+	}
+
+	var invite Invite
+	if err := tx.Where("code = ?", code).First(&invite).Error; err != nil {
+		return nil, fmt.Errorf("failed to find invite: %w", err)
+	}
+	return &invite, nil
+}
@@ -0,0 +1,369 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 直播课状态
+const (
+	ClassScheduled = "scheduled" // 已预约，尚未开放
+	ClassLobby     = "lobby"     // 候场中，可以加入和聊天，尚未开始直播
+	ClassLive      = "live"      // 直播中
+	ClassPaused    = "paused"    // 暂停中
+	ClassEnded     = "ended"     // 已结束，数据保留供教师回看
+	ClassArchived  = "archived"  // 结束一段时间后归档
+)
+
+// OpenClassStatuses 开放中的直播课状态，这些直播课同时保存在 Redis 中，可以加入和聊天
+var OpenClassStatuses = []string{ClassLobby, ClassLive, ClassPaused}
+
+// ClosedClassStatuses 已结束的直播课状态
+var ClosedClassStatuses = []string{ClassEnded, ClassArchived}
+
+// classTransitions 每个状态允许切换到的状态
+var classTransitions = map[string][]string{
+	ClassScheduled: {ClassLobby, ClassLive, ClassEnded},
+	ClassLobby:     {ClassLive, ClassEnded},
+	ClassLive:      {ClassPaused, ClassEnded},
+	ClassPaused:    {ClassLive, ClassEnded},
+	ClassEnded:     {ClassArchived},
+}
+
+var (
+	// ErrClassExists 直播课 ID 已被使用
+	ErrClassExists = errors.New("live class already exists")
+	// ErrClassNotFound 直播课不存在
+	ErrClassNotFound = errors.New("live class not found")
+	// ErrInvalidTransition 当前状态不能切换到目标状态，或状态已被其他请求修改
+	ErrInvalidTransition = errors.New("invalid live class state transition")
+	// ErrRoomBooked 同一房间中已有时间重叠的预约或开放中的直播课
+	ErrRoomBooked = errors.New("room is already booked for an overlapping time")
+)
+
+// CanTransition 判断直播课能否从 from 状态切换到 to 状态
+func CanTransition(from, to string) bool {
+	for _, next := range classTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpenStatus 判断直播课是否开放中
+func IsOpenStatus(status string) bool {
+	for _, s := range OpenClassStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// LiveClass 直播课，结束后归档而不删除
+type LiveClass struct {
+	ID          string `gorm:"primaryKey;type:varchar(64)"`      // 服务端生成
+	Name        string `gorm:"type:varchar(100)"`                // 直播课名称，只用于展示
+	TeacherName string `gorm:"type:varchar(100);index;not null"` // 发起人用户名，账号删除后替换为化名
+	RoomName    string `gorm:"type:varchar(100);index"`
+	StreamURL   string `gorm:"type:varchar(255)"` // 开放时才向 LiveGo 申请推流密钥
+	Status      string `gorm:"type:varchar(16);index;not null"`
+	CreatedAt   time.Time
+	// 预约的开始和结束时间，到达开始时间前由调度任务开放候场，到达结束时间后自动结束
+	ScheduledStart *time.Time `gorm:"index"`
+	ScheduledEnd   *time.Time `gorm:"index"`
+	StartedAt      *time.Time // 第一次进入直播中的时间
+	EndedAt        *time.Time `gorm:"index"`
+	InviteOnly     bool       `gorm:"not null;default:false"` // 签发过指定该直播课的邀请码后只允许已报名的用户参与
+}
+
+// LiveQuestion 直播课中发布的题目
+type LiveQuestion struct {
+	ID        string `gorm:"primaryKey;type:varchar(64)"`
+	ClassID   string `gorm:"type:varchar(64);index;not null"`
+	Text      string `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+// ClassKick 被移出直播课的用户
+type ClassKick struct {
+	ClassID   string `gorm:"primaryKey;type:varchar(64)"`
+	Username  string `gorm:"primaryKey;type:varchar(100)"` // 账号删除后替换为化名
+	KickedBy  string `gorm:"type:varchar(100)"`
+	CreatedAt time.Time
+}
+
+// AnswerCount 某道题某个答案的提交次数
+type AnswerCount struct {
+	QuestionID string
+	Answer     string
+	Count      int32
+}
+
+// LiveClassData 直播课的消息、题目、答案统计和移出名单
+type LiveClassData struct {
+	Messages  []ChatMessage
+	Questions []LiveQuestion
+	Answers   []AnswerCount
+	Kicked    []string
+}
+
+// CreateLiveClass 保存新建的直播课，ID 已被使用（包括已归档的直播课）时返回 ErrClassExists，
+// 同一房间中已有时间重叠的未结束直播课时返回 ErrRoomBooked
+func CreateLiveClass(class *LiveClass) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&LiveClass{}).Where("id = ?", class.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check live class: %w", err)
+		}
+		if count > 0 {
+			return ErrClassExists
+		}
+		if class.Status == "" {
+			class.Status = ClassScheduled
+		}
+		if err := checkRoomAvailable(tx, class); err != nil {
+			return err
+		}
+		if err := tx.Create(class).Error; err != nil {
+			return fmt.Errorf("failed to create live class: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkRoomAvailable 检查房间在直播课的时间段内没有被其他未结束的直播课占用
+// 直播课占用房间的时间段从预约开始时间（未预约时为开始直播的时间）到预约结束时间，没有预约结束时间的一直占用到结束为止
+// 查询时锁定同一房间的记录，并发创建的直播课中只有一个能预约成功
+func checkRoomAvailable(tx *gorm.DB, class *LiveClass) error {
+	start := time.Now()
+	if class.ScheduledStart != nil && class.ScheduledStart.After(start) {
+		start = *class.ScheduledStart
+	}
+	query := tx.Model(&LiveClass{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("room_name = ? AND status IN ?", class.RoomName, append([]string{ClassScheduled}, OpenClassStatuses...)).
+		Where("scheduled_end IS NULL OR scheduled_end > ?", start)
+	if class.ScheduledEnd != nil {
+		query = query.Where("COALESCE(scheduled_start, started_at, created_at) < ?", *class.ScheduledEnd)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check room bookings: %w", err)
+	}
+	if count > 0 {
+		return ErrRoomBooked
+	}
+	return nil
+}
+
+// GetLiveClass 按 ID 查询直播课，包括已归档的直播课
+func GetLiveClass(id string) (*LiveClass, error) {
+	var class LiveClass
+	if err := DB.Where("id = ?", id).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, fmt.Errorf("failed to find live class: %w", err)
+	}
+	return &class, nil
+}
+
+// ListOpenLiveClasses 查询全部开放中的直播课
+func ListOpenLiveClasses() ([]LiveClass, error) {
+	var classes []LiveClass
+	if err := DB.Where("status IN ?", OpenClassStatuses).Order("created_at").Find(&classes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list live classes: %w", err)
+	}
+	return classes, nil
+}
+
+// ListClassesDue 查询处于 statuses 状态且 column 列的时间不晚于 before 的直播课，供调度任务使用
+func ListClassesDue(statuses []string, column string, before time.Time) ([]LiveClass, error) {
+	var classes []LiveClass
+	err := DB.Where("status IN ?", statuses).Where(column+" IS NOT NULL AND "+column+" <= ?", before).
+		Order(column).Limit(100).Find(&classes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due live classes: %w", err)
+	}
+	return classes, nil
+}
+
+// TransitionLiveClass 将直播课切换到 to 状态，updates 为需要同时更新的其他列
+// 使用条件更新，多个实例同时切换同一个直播课时只有一个成功，其余返回 ErrInvalidTransition
+func TransitionLiveClass(class *LiveClass, to string, updates map[string]interface{}) error {
+	if !CanTransition(class.Status, to) {
+		return ErrInvalidTransition
+	}
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+	now := time.Now()
+	updates["status"] = to
+	if to == ClassLive && class.StartedAt == nil {
+		updates["started_at"] = &now
+	}
+	if to == ClassEnded {
+		updates["ended_at"] = &now
+	}
+
+	result := DB.Model(&LiveClass{}).Where("id = ? AND status = ?", class.ID, class.Status).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update live class state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTransition
+	}
+	return DB.Where("id = ?", class.ID).First(class).Error
+}
+
+// 直播课列表的排序方式
+const (
+	ClassSortNewest = "newest" // 按开始时间倒序（默认）
+	ClassSortOldest = "oldest" // 按开始时间正序
+	ClassSortName   = "name"   // 按名称
+)
+
+// classStartsAt 直播课的开始时间，预约的直播课为预约开始时间，否则为创建时间
+const classStartsAt = "COALESCE(scheduled_start, created_at)"
+
+// LiveClassCursor 翻页位置，即上一页最后一条记录的排序值和 ID
+type LiveClassCursor struct {
+	StartsAt time.Time `json:"t,omitempty"`
+	Name     string    `json:"n,omitempty"`
+	ID       string    `json:"id"`
+}
+
+// LiveClassFilter 直播课列表的查询条件
+type LiveClassFilter struct {
+	Statuses []string // 为空时不按状态过滤
+	Teacher  string   // 发起人用户名
+	Keyword  string   // 按名称或发起人模糊搜索
+	Sort     string
+	After    *LiveClassCursor // 从该位置之后开始查询
+	Limit    int
+}
+
+// likeEscaper 转义 LIKE 中的通配符，使用户输入的 % 和 _ 按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern 生成匹配包含 s 的 LIKE 模式，查询时需指定 ESCAPE '\\'
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// ListLiveClasses 按条件查询直播课，使用游标翻页，翻页期间新建的直播课不会导致重复或遗漏
+func ListLiveClasses(filter LiveClassFilter) ([]LiveClass, error) {
+	query := DB.Model(&LiveClass{})
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Teacher != "" {
+		query = query.Where("teacher_name = ?", filter.Teacher)
+	}
+	if filter.Keyword != "" {
+		like := containsPattern(filter.Keyword)
+		query = query.Where(`name LIKE ? ESCAPE '\\' OR teacher_name LIKE ? ESCAPE '\\'`, like, like)
+	}
+
+	after := filter.After
+	switch filter.Sort {
+	case ClassSortOldest:
+		if after != nil {
+			query = query.Where(classStartsAt+" > ? OR ("+classStartsAt+" = ? AND id > ?)", after.StartsAt, after.StartsAt, after.ID)
+		}
+		query = query.Order(classStartsAt + " ASC, id ASC")
+	case ClassSortName:
+		if after != nil {
+			query = query.Where("name > ? OR (name = ? AND id > ?)", after.Name, after.Name, after.ID)
+		}
+		query = query.Order("name ASC, id ASC")
+	default:
+		if after != nil {
+			query = query.Where(classStartsAt+" < ? OR ("+classStartsAt+" = ? AND id < ?)", after.StartsAt, after.StartsAt, after.ID)
+		}
+		query = query.Order(classStartsAt + " DESC, id DESC")
+	}
+
+	var classes []LiveClass
+	if err := query.Limit(filter.Limit).Find(&classes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list live classes: %w", err)
+	}
+	return classes, nil
+}
+
+// CursorAfter 返回指向该直播课之后的翻页位置
+func (c *LiveClass) CursorAfter() LiveClassCursor {
+	return LiveClassCursor{StartsAt: c.StartsAt(), Name: c.Name, ID: c.ID}
+}
+
+// StartsAt 返回排序使用的开始时间，与 classStartsAt 一致
+func (c *LiveClass) StartsAt() time.Time {
+	if c.ScheduledStart != nil {
+		return *c.ScheduledStart
+	}
+	return c.CreatedAt
+}
+
+// CountViewers 统计每个直播课加入过的不同用户数
+func CountViewers(classIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(classIDs))
+	if len(classIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		ClassID string
+		Viewers int64
+	}
+	err := DB.Model(&AttendanceRecord{}).Select("class_id, COUNT(DISTINCT username) AS viewers").
+		Where("class_id IN ?", classIDs).Group("class_id").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count viewers: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.ClassID] = row.Viewers
+	}
+	return counts, nil
+}
+
+// SaveLiveQuestion 保存发布的题目
+func SaveLiveQuestion(question *LiveQuestion) error {
+	if err := DB.Create(question).Error; err != nil {
+		return fmt.Errorf("failed to save question: %w", err)
+	}
+	return nil
+}
+
+// KickFromClass 记录被移出直播课的用户，重复移出不报错
+func KickFromClass(classID, username, kickedBy string) error {
+	kick := ClassKick{ClassID: classID, Username: username, KickedBy: kickedBy}
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&kick).Error; err != nil {
+		return fmt.Errorf("failed to save kick: %w", err)
+	}
+	return nil
+}
+
+// LoadLiveClassData 读取直播课的全部消息、题目、答案统计和移出名单
+func LoadLiveClassData(classID string) (*LiveClassData, error) {
+	var data LiveClassData
+	if err := DB.Where("class_id = ?", classID).Order("id").Find(&data.Messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to load chat messages: %w", err)
+	}
+	if err := DB.Where("class_id = ?", classID).Order("created_at").Find(&data.Questions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load questions: %w", err)
+	}
+	err := DB.Model(&QuizAnswer{}).Select("question_id, answer, COUNT(*) AS count").
+		Where("class_id = ?", classID).Group("question_id, answer").Scan(&data.Answers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load answer counts: %w", err)
+	}
+	if err := DB.Model(&ClassKick{}).Where("class_id = ?", classID).Pluck("username", &data.Kicked).Error; err != nil {
+		return nil, fmt.Errorf("failed to load kicked users: %w", err)
+	}
+	return &data, nil
+}
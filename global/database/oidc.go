@@ -0,0 +1,69 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OIDCClient 接入单点登录的应用
+type OIDCClient struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	ClientID     string `gorm:"type:varchar(64);uniqueIndex;not null"`
+	SecretHash   string `gorm:"type:char(64)"` // client_secret 的 SHA-256，公开客户端为空
+	Name         string `gorm:"type:varchar(100);not null"`
+	RedirectURIs string `gorm:"type:text;not null"` // 换行分隔的回调地址
+	Public       bool   `gorm:"not null;default:false"`
+	CreatedBy    string `gorm:"type:varchar(100);not null"`
+	CreatedAt    time.Time
+}
+
+// RedirectURIList 返回回调地址列表
+func (c *OIDCClient) RedirectURIList() []string {
+	return strings.Split(c.RedirectURIs, "\n")
+}
+
+// AllowsRedirectURI 判断回调地址是否已登记，必须完全一致
+func (c *OIDCClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIList() {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateOIDCClient 保存客户端
+func CreateOIDCClient(client *OIDCClient) error {
+	if err := DB.Create(client).Error; err != nil {
+		return fmt.Errorf("failed to create oidc client: %w", err)
+	}
+	return nil
+}
+
+// GetOIDCClient 根据 client_id 查询客户端
+func GetOIDCClient(clientID string) (*OIDCClient, error) {
+	var client OIDCClient
+	if err := DB.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, fmt.Errorf("failed to find oidc client: %w", err)
+	}
+	return &client, nil
+}
+
+// ListOIDCClients 查询全部客户端
+func ListOIDCClients() ([]OIDCClient, error) {
+	var clients []OIDCClient
+	if err := DB.Order("id DESC").Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to list oidc clients: %w", err)
+	}
+	return clients, nil
+}
+
+// DeleteOIDCClient 删除客户端，返回是否存在
+func DeleteOIDCClient(clientID string) (bool, error) {
+	result := DB.Where("client_id = ?", clientID).Delete(&OIDCClient{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete oidc client: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashFormat 表示无法识别的密码哈希格式
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher 是可插拔的密码哈希算法
+// 生成的哈希串自带算法标识和参数，例如 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type PasswordHasher interface {
+	// Name 返回算法名称，与配置项 password.algorithm 对应
+	Name() string
+	// Hash 生成自描述的哈希串
+	Hash(password string) (string, error)
+	// Verify 校验密码是否与哈希串匹配
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash 判断哈希串的算法参数是否与当前配置不一致
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher 使用 argon2id 算法
+type Argon2idHasher struct {
+	Memory      uint32 // 内存开销（KiB）
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Name 返回算法名称
+func (h *Argon2idHasher) Name() string { return "argon2id" }
+
+// Hash 生成 argon2id 哈希串
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 按哈希串中记录的参数重新计算并比较
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash 判断参数是否与当前配置不一致
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory || params.Iterations != h.Iterations || params.Parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength || uint32(len(key)) != h.KeyLength
+}
+
+// decodeArgon2id 解析 argon2id 哈希串
+func decodeArgon2id(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	// 格式：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnknownHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher 使用 bcrypt 算法
+type BcryptHasher struct {
+	Cost int
+}
+
+// Name 返回算法名称
+func (h *BcryptHasher) Name() string { return "bcrypt" }
+
+// Hash 生成 bcrypt 哈希串
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 校验 bcrypt 哈希串
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NeedsRehash 判断 cost 是否与当前配置不一致
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// CurrentHasher 返回配置项 password.algorithm 指定的哈希算法
+func CurrentHasher() PasswordHasher {
+	if Config != nil && Config.GetString("password.algorithm") == "bcrypt" {
+		return newBcryptHasher()
+	}
+	return newArgon2idHasher()
+}
+
+// hasherFor 根据哈希串前缀选择对应的算法
+func hasherFor(encoded string) (PasswordHasher, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return newArgon2idHasher(), nil
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return newBcryptHasher(), nil
+	default:
+		return nil, ErrUnknownHashFormat
+	}
+}
+
+func newArgon2idHasher() *Argon2idHasher {
+	if Config == nil {
+		return &Argon2idHasher{Memory: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+	}
+	return &Argon2idHasher{
+		Memory:      Config.GetUint32("password.argon2id.memory"),
+		Iterations:  Config.GetUint32("password.argon2id.iterations"),
+		Parallelism: uint8(Config.GetUint("password.argon2id.parallelism")),
+		SaltLength:  Config.GetUint32("password.argon2id.salt_length"),
+		KeyLength:   Config.GetUint32("password.argon2id.key_length"),
+	}
+}
+
+func newBcryptHasher() *BcryptHasher {
+	if Config == nil {
+		return &BcryptHasher{Cost: 12}
+	}
+	return &BcryptHasher{Cost: Config.GetInt("password.bcrypt.cost")}
+}
+
+// HashPassword 使用当前配置的算法生成密码哈希串
+func HashPassword(password string) (string, error) {
+	return CurrentHasher().Hash(password)
+}
+
+// legacySHA256 是旧版的加盐 SHA-256 哈希，仅用于校验未迁移的密码
+func legacySHA256(password, salt string) string {
+	hash := sha256.Sum256([]byte(password + salt))
+	return hex.EncodeToString(hash[:])
+}
+
+// checkPassword 校验密码，返回是否匹配以及是否需要按当前配置重新哈希
+func checkPassword(user *User, password string) (bool, bool, error) {
+	// 旧版哈希：十六进制 SHA-256，盐单独存放在 Salt 列
+	if user.Salt != "" && !strings.HasPrefix(user.Hash, "$") {
+		expected := legacySHA256(password, user.Salt)
+		ok := subtle.ConstantTimeCompare([]byte(expected), []byte(user.Hash)) == 1
+		return ok, ok, nil
+	}
+
+	hasher, err := hasherFor(user.Hash)
+	if err != nil {
+		return false, false, err
+	}
+	ok, err := hasher.Verify(password, user.Hash)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	current := CurrentHasher()
+	return true, hasher.Name() != current.Name() || current.NeedsRehash(user.Hash), nil
+}
@@ -0,0 +1,283 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChatMessage 直播课中发送的聊天消息
+type ChatMessage struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	ClassID    string    `gorm:"type:varchar(64);index;not null"`
+	Username   string    `gorm:"type:varchar(100);index;not null"` // 账号删除后替换为化名
+	SenderName string    `gorm:"type:varchar(100)"`                // 发送时的展示名
+	Content    string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// QuizAnswer 学生提交的答案，每个学生每道题只能提交一次
+type QuizAnswer struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	ClassID    string `gorm:"type:varchar(64);index;uniqueIndex:idx_quiz_answer_user;not null"`
+	QuestionID string `gorm:"type:varchar(64);index;uniqueIndex:idx_quiz_answer_user;not null"`
+	Username   string `gorm:"type:varchar(100);index;uniqueIndex:idx_quiz_answer_user;not null"` // 账号删除后替换为化名
+	Answer     string `gorm:"type:varchar(255)"`
+	CreatedAt  time.Time
+}
+
+// SaveChatMessage 保存聊天消息
+func SaveChatMessage(msg *ChatMessage) error {
+	if err := DB.Create(msg).Error; err != nil {
+		return fmt.Errorf("failed to save chat message: %w", err)
+	}
+	return nil
+}
+
+// ErrAnswerExists 表示用户已经提交过该题的答案
+var ErrAnswerExists = errors.New("answer already submitted")
+
+// SaveQuizAnswer 保存答案
+func SaveQuizAnswer(answer *QuizAnswer) error {
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(answer)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save quiz answer: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnswerExists
+	}
+	return nil
+}
+
+// PersonalData 某个用户在各表中的数据，用于导出
+type PersonalData struct {
+	Enrollments []Enrollment
+	Messages    []ChatMessage
+	Answers     []QuizAnswer
+	Attendance  []AttendanceRecord
+}
+
+// GetPersonalData 查询用户的课程、聊天消息、答案和出勤记录
+func GetPersonalData(username string) (*PersonalData, error) {
+	var data PersonalData
+	queries := []struct {
+		name string
+		dest interface{}
+	}{
+		{"enrollments", &data.Enrollments},
+		{"chat messages", &data.Messages},
+		{"quiz answers", &data.Answers},
+		{"attendance", &data.Attendance},
+	}
+	for _, q := range queries {
+		if err := DB.Where("username = ?", username).Order("id").Find(q.dest).Error; err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", q.name, err)
+		}
+	}
+	return &data, nil
+}
+
+// 删除账号申请的状态
+const (
+	ErasurePending   = "pending"   // 等待管理员审批
+	ErasureApproved  = "approved"  // 已批准，等待后台任务执行
+	ErasureRunning   = "running"   // 后台任务执行中
+	ErasureCompleted = "completed" // 已删除
+	ErasureRejected  = "rejected"
+	ErasureFailed    = "failed"
+)
+
+var (
+	// ErrErasureNotFound 删除申请不存在
+	ErrErasureNotFound = errors.New("erasure request not found")
+	// ErrErasureNotPending 删除申请已经审批过，只有执行失败的申请可以再次批准
+	ErrErasureNotPending = errors.New("erasure request is not pending")
+)
+
+// ErasureRequest 删除账号及个人数据的申请，管理员批准后由后台任务执行
+type ErasureRequest struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	Username    string `gorm:"type:varchar(100);index;not null"` // 执行完成后替换为化名
+	Reason      string `gorm:"type:varchar(500)"`
+	Status      string `gorm:"type:varchar(16);index;not null"`
+	RequestedAt time.Time
+	ReviewedBy  string `gorm:"type:varchar(100)"`
+	ReviewedAt  *time.Time
+	Pseudonym   string `gorm:"type:varchar(100)"`
+	CompletedAt *time.Time
+	Error       string `gorm:"type:varchar(500)"` // 执行失败的原因
+}
+
+// CreateErasureRequest 提交删除申请，已有未完成的申请时直接返回该申请
+func CreateErasureRequest(username, reason string) (*ErasureRequest, error) {
+	var existing ErasureRequest
+	err := DB.Where("username = ? AND status IN ?", username, []string{ErasurePending, ErasureApproved, ErasureRunning}).
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find erasure request: %w", err)
+	}
+
+	req := &ErasureRequest{Username: username, Reason: reason, Status: ErasurePending, RequestedAt: time.Now()}
+	if err := DB.Create(req).Error; err != nil {
+		return nil, fmt.Errorf("failed to create erasure request: %w", err)
+	}
+	return req, nil
+}
+
+// ListErasureRequests 按状态查询删除申请，status 为空时查询全部
+func ListErasureRequests(status string) ([]ErasureRequest, error) {
+	var reqs []ErasureRequest
+	query := DB.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Limit(500).Find(&reqs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list erasure requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// ReviewErasureRequest 审批删除申请，再次批准执行失败的申请即为重试
+func ReviewErasureRequest(id uint, reviewer string, approve bool) (*ErasureRequest, error) {
+	var req ErasureRequest
+	if err := DB.First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrErasureNotFound
+		}
+		return nil, fmt.Errorf("failed to find erasure request: %w", err)
+	}
+	previous := req.Status
+	if previous != ErasurePending && !(approve && previous == ErasureFailed) {
+		return nil, ErrErasureNotPending
+	}
+
+	now := time.Now()
+	req.Status = ErasureRejected
+	if approve {
+		req.Status = ErasureApproved
+	}
+	req.ReviewedBy = reviewer
+	req.ReviewedAt = &now
+	result := DB.Model(&req).Where("status = ?", previous).
+		Select("status", "reviewed_by", "reviewed_at").Updates(&req)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to review erasure request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrErasureNotPending
+	}
+	return &req, nil
+}
+
+// ClaimApprovedErasure 取出一个已批准的申请并标记为执行中，没有待执行的申请时返回 nil
+// 多个实例同时执行时，条件更新保证每个申请只会被一个实例取出
+func ClaimApprovedErasure() (*ErasureRequest, error) {
+	for {
+		var req ErasureRequest
+		err := DB.Where("status = ?", ErasureApproved).Order("id").First(&req).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find approved erasure: %w", err)
+		}
+		result := DB.Model(&ErasureRequest{}).Where("id = ? AND status = ?", req.ID, ErasureApproved).
+			Update("status", ErasureRunning)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim erasure: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			req.Status = ErasureRunning
+			return &req, nil
+		}
+	}
+}
+
+// FinishErasure 记录删除任务的结果，成功时申请中的用户名同样替换为化名
+func FinishErasure(req *ErasureRequest, pseudonym string, runErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{"completed_at": &now}
+	if runErr != nil {
+		updates["status"] = ErasureFailed
+		updates["error"] = truncateString(runErr.Error(), 500)
+	} else {
+		updates["status"] = ErasureCompleted
+		updates["pseudonym"] = pseudonym
+		updates["username"] = pseudonym
+		updates["error"] = ""
+	}
+	if err := DB.Model(req).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to finish erasure: %w", err)
+	}
+	return nil
+}
+
+// EraseUser 删除用户账号，并将保留的聊天记录、答题统计、出勤和审计记录中的用户名替换为化名
+func EraseUser(username, pseudonym string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var user User
+		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+			return fmt.Errorf("failed to find user: %w", err)
+		}
+
+		renames := []struct {
+			model  interface{}
+			column string
+			extra  map[string]interface{}
+		}{
+			{&ChatMessage{}, "username", map[string]interface{}{"sender_name": pseudonym}},
+			{&QuizAnswer{}, "username", nil},
+			{&AttendanceRecord{}, "username", nil},
+			{&LiveClass{}, "teacher_name", nil},
+			{&ClassKick{}, "username", nil},
+			{&ClassKick{}, "kicked_by", nil},
+			// 审计记录保留操作本身，去掉能识别个人的用户名、IP 和 User-Agent
+			{&AuditEvent{}, "actor", map[string]interface{}{"ip": "", "user_agent": ""}},
+			{&AuditEvent{}, "target", nil},
+			{&APIKey{}, "created_by", nil},
+			{&Invite{}, "created_by", nil},
+			{&OIDCClient{}, "created_by", nil},
+			{&User{}, "owner", nil},
+			{&ErasureRequest{}, "username", nil},
+			{&ErasureRequest{}, "reviewed_by", nil},
+		}
+		for _, r := range renames {
+			updates := map[string]interface{}{r.column: pseudonym}
+			for k, v := range r.extra {
+				updates[k] = v
+			}
+			if err := tx.Model(r.model).Where(r.column+" = ?", username).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to pseudonymize %T.%s: %w", r.model, r.column, err)
+			}
+		}
+
+		if err := tx.Where("username = ?", username).Delete(&Enrollment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete enrollments: %w", err)
+		}
+		if err := tx.Where("username = ?", username).Delete(&APIKey{}).Error; err != nil {
+			return fmt.Errorf("failed to delete api keys: %w", err)
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return nil
+	})
+}
+
+// truncateString 按字符截断字符串
+func truncateString(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
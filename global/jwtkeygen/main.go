@@ -0,0 +1,76 @@
+// FilePath: C:/LanshanClass1.3/global/jwtkeygen\main.go
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 生成 JWT 签名密钥对：
+//
+//	go run ./global/jwtkeygen -alg EdDSA -kid 2026-10 -out ./global/config/keys
+//
+// 会生成 <kid>.pem（私钥）和 <kid>.pub.pem（公钥），然后在配置文件中修改
+// jwt.signing_key_id / jwt.signing_key_file，并把旧公钥加入 jwt.verification_keys
+func main() {
+	alg := flag.String("alg", "EdDSA", "签名算法：RS256 或 EdDSA")
+	kid := flag.String("kid", "", "密钥 ID，写入 Token 的 kid 头")
+	out := flag.String("out", "./global/config/keys", "输出目录")
+	flag.Parse()
+
+	if *kid == "" {
+		fmt.Println("-kid is required")
+		os.Exit(1)
+	}
+
+	var private crypto.Signer
+	var err error
+	switch *alg {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported algorithm %s", *alg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to generate key: %v\n", err)
+		os.Exit(1)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		fmt.Printf("Failed to encode private key: %v\n", err)
+		os.Exit(1)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		fmt.Printf("Failed to encode public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*out, 0700); err != nil {
+		fmt.Printf("Failed to create output directory: %v\n", err)
+		os.Exit(1)
+	}
+	privatePath := filepath.Join(*out, *kid+".pem")
+	publicPath := filepath.Join(*out, *kid+".pub.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		fmt.Printf("Failed to write private key: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		fmt.Printf("Failed to write public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Private key: %s\nPublic key:  %s\n", privatePath, publicPath)
+}
@@ -23,12 +23,15 @@ const (
 
 // 创建直播课请求
 type CreateLiveClassRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TeacherName   string                 `protobuf:"bytes,1,opt,name=teacher_name,json=teacherName,proto3" json:"teacher_name,omitempty"` // 教师名称
-	ClassName     string                 `protobuf:"bytes,2,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"`       // 直播课名称
-	RoomName      string                 `protobuf:"bytes,3,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in live.proto.
+	TeacherName    string `protobuf:"bytes,1,opt,name=teacher_name,json=teacherName,proto3" json:"teacher_name,omitempty"`           // 已废弃：服务端只信任 Token 中的用户
+	ClassName      string `protobuf:"bytes,2,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"`                 // 直播课名称，只用于展示，可以重复
+	RoomName       string `protobuf:"bytes,3,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`                    // LiveGo 房间名，不能与开放中的直播课重复
+	ScheduledStart int64  `protobuf:"varint,4,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"` // 预约开始时间（Unix 时间戳），为 0 或已过时立即开始直播
+	ScheduledEnd   int64  `protobuf:"varint,5,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`       // 预约结束时间（Unix 时间戳），到达后自动结束，为 0 表示手动结束
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CreateLiveClassRequest) Reset() {
@@ -61,6 +64,7 @@ func (*CreateLiveClassRequest) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{0}
 }
 
+// Deprecated: Marked as deprecated in live.proto.
 func (x *CreateLiveClassRequest) GetTeacherName() string {
 	if x != nil {
 		return x.TeacherName
@@ -82,12 +86,28 @@ func (x *CreateLiveClassRequest) GetRoomName() string {
 	return ""
 }
 
+func (x *CreateLiveClassRequest) GetScheduledStart() int64 {
+	if x != nil {
+		return x.ScheduledStart
+	}
+	return 0
+}
+
+func (x *CreateLiveClassRequest) GetScheduledEnd() int64 {
+	if x != nil {
+		return x.ScheduledEnd
+	}
+	return 0
+}
+
 // 创建直播课响应
 type CreateLiveClassResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                  // 状态信息
-	StreamKey     string                 `protobuf:"bytes,3,opt,name=stream_key,json=streamKey,proto3" json:"stream_key,omitempty"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`       // 直播课ID，由服务端生成
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                        // 状态信息
+	StreamKey     string                 `protobuf:"bytes,3,opt,name=stream_key,json=streamKey,proto3" json:"stream_key,omitempty"` // 推流密钥，预约的直播课在开放候场时才生成
+	ClassName     string                 `protobuf:"bytes,4,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"` // 直播课名称
+	State         string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`                          // 直播课状态：live 或 scheduled
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -143,29 +163,43 @@ func (x *CreateLiveClassResponse) GetStreamKey() string {
 	return ""
 }
 
-// 加入直播课请求
-type JoinLiveClassRequest struct {
+func (x *CreateLiveClassResponse) GetClassName() string {
+	if x != nil {
+		return x.ClassName
+	}
+	return ""
+}
+
+func (x *CreateLiveClassResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// 切换直播课状态请求
+type UpdateLiveClassStateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`             // 直播课ID
-	StudentName   string                 `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"` // 学生名称
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`                    // 目标状态：lobby / live / paused / ended / archived
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *JoinLiveClassRequest) Reset() {
-	*x = JoinLiveClassRequest{}
+func (x *UpdateLiveClassStateRequest) Reset() {
+	*x = UpdateLiveClassStateRequest{}
 	mi := &file_live_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *JoinLiveClassRequest) String() string {
+func (x *UpdateLiveClassStateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JoinLiveClassRequest) ProtoMessage() {}
+func (*UpdateLiveClassStateRequest) ProtoMessage() {}
 
-func (x *JoinLiveClassRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateLiveClassStateRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -177,48 +211,47 @@ func (x *JoinLiveClassRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JoinLiveClassRequest.ProtoReflect.Descriptor instead.
-func (*JoinLiveClassRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateLiveClassStateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateLiveClassStateRequest) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *JoinLiveClassRequest) GetClassId() string {
+func (x *UpdateLiveClassStateRequest) GetClassId() string {
 	if x != nil {
 		return x.ClassId
 	}
 	return ""
 }
 
-func (x *JoinLiveClassRequest) GetStudentName() string {
+func (x *UpdateLiveClassStateRequest) GetState() string {
 	if x != nil {
-		return x.StudentName
+		return x.State
 	}
 	return ""
 }
 
-// 获取消息请求
-type GetMessagesRequest struct {
+// 查询直播课请求
+type GetLiveClassRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`                    // 直播课ID
-	LastTimestamp int64                  `protobuf:"varint,2,opt,name=last_timestamp,json=lastTimestamp,proto3" json:"last_timestamp,omitempty"` // 最后收到消息的时间戳
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMessagesRequest) Reset() {
-	*x = GetMessagesRequest{}
+func (x *GetLiveClassRequest) Reset() {
+	*x = GetLiveClassRequest{}
 	mi := &file_live_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessagesRequest) String() string {
+func (x *GetLiveClassRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessagesRequest) ProtoMessage() {}
+func (*GetLiveClassRequest) ProtoMessage() {}
 
-func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
+func (x *GetLiveClassRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -230,47 +263,53 @@ func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessagesRequest.ProtoReflect.Descriptor instead.
-func (*GetMessagesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetLiveClassRequest.ProtoReflect.Descriptor instead.
+func (*GetLiveClassRequest) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetMessagesRequest) GetClassId() string {
+func (x *GetLiveClassRequest) GetClassId() string {
 	if x != nil {
 		return x.ClassId
 	}
 	return ""
 }
 
-func (x *GetMessagesRequest) GetLastTimestamp() int64 {
-	if x != nil {
-		return x.LastTimestamp
-	}
-	return 0
-}
-
-// 获取消息响应
-type GetMessagesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"` // 消息列表
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GetMessagesResponse) Reset() {
-	*x = GetMessagesResponse{}
+// 直播课详情
+type LiveClass struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ClassId            string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`                                    // 直播课ID
+	ClassName          string                 `protobuf:"bytes,2,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"`                              // 直播课名称
+	TeacherName        string                 `protobuf:"bytes,3,opt,name=teacher_name,json=teacherName,proto3" json:"teacher_name,omitempty"`                        // 发起人用户名
+	TeacherDisplayName string                 `protobuf:"bytes,4,opt,name=teacher_display_name,json=teacherDisplayName,proto3" json:"teacher_display_name,omitempty"` // 发起人昵称
+	RoomName           string                 `protobuf:"bytes,5,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`                                 // LiveGo 房间名
+	Status             string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`                                                     // scheduled / lobby / live / paused / ended / archived
+	CreatedAt          int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                             // Unix 时间戳
+	EndedAt            int64                  `protobuf:"varint,8,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`                                   // 结束时间，进行中为 0
+	StreamUrl          string                 `protobuf:"bytes,9,opt,name=stream_url,json=streamUrl,proto3" json:"stream_url,omitempty"`                              // 推流地址，只返回给发起人和管理员
+	ViewerCount        int64                  `protobuf:"varint,10,opt,name=viewer_count,json=viewerCount,proto3" json:"viewer_count,omitempty"`                      // 观看人数
+	StartedAt          int64                  `protobuf:"varint,11,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`                            // 第一次进入直播中的时间，Unix 时间戳，尚未开始为 0
+	ScheduledStart     int64                  `protobuf:"varint,12,opt,name=scheduled_start,json=scheduledStart,proto3" json:"scheduled_start,omitempty"`             // 预约开始时间，未预约为 0
+	ScheduledEnd       int64                  `protobuf:"varint,13,opt,name=scheduled_end,json=scheduledEnd,proto3" json:"scheduled_end,omitempty"`                   // 预约结束时间，未设置为 0
+	OnlineCount        int64                  `protobuf:"varint,14,opt,name=online_count,json=onlineCount,proto3" json:"online_count,omitempty"`                      // 当前在线人数，直播课未开放时为 0
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *LiveClass) Reset() {
+	*x = LiveClass{}
 	mi := &file_live_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMessagesResponse) String() string {
+func (x *LiveClass) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMessagesResponse) ProtoMessage() {}
+func (*LiveClass) ProtoMessage() {}
 
-func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
+func (x *LiveClass) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -282,42 +321,136 @@ func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMessagesResponse.ProtoReflect.Descriptor instead.
-func (*GetMessagesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use LiveClass.ProtoReflect.Descriptor instead.
+func (*LiveClass) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *GetMessagesResponse) GetMessages() []*Message {
+func (x *LiveClass) GetClassId() string {
 	if x != nil {
-		return x.Messages
+		return x.ClassId
 	}
-	return nil
+	return ""
 }
 
-// 加入直播课响应
-type JoinLiveClassResponse struct {
+func (x *LiveClass) GetClassName() string {
+	if x != nil {
+		return x.ClassName
+	}
+	return ""
+}
+
+func (x *LiveClass) GetTeacherName() string {
+	if x != nil {
+		return x.TeacherName
+	}
+	return ""
+}
+
+func (x *LiveClass) GetTeacherDisplayName() string {
+	if x != nil {
+		return x.TeacherDisplayName
+	}
+	return ""
+}
+
+func (x *LiveClass) GetRoomName() string {
+	if x != nil {
+		return x.RoomName
+	}
+	return ""
+}
+
+func (x *LiveClass) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *LiveClass) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *LiveClass) GetEndedAt() int64 {
+	if x != nil {
+		return x.EndedAt
+	}
+	return 0
+}
+
+func (x *LiveClass) GetStreamUrl() string {
+	if x != nil {
+		return x.StreamUrl
+	}
+	return ""
+}
+
+func (x *LiveClass) GetViewerCount() int64 {
+	if x != nil {
+		return x.ViewerCount
+	}
+	return 0
+}
+
+func (x *LiveClass) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *LiveClass) GetScheduledStart() int64 {
+	if x != nil {
+		return x.ScheduledStart
+	}
+	return 0
+}
+
+func (x *LiveClass) GetScheduledEnd() int64 {
+	if x != nil {
+		return x.ScheduledEnd
+	}
+	return 0
+}
+
+func (x *LiveClass) GetOnlineCount() int64 {
+	if x != nil {
+		return x.OnlineCount
+	}
+	return 0
+}
+
+// 查询直播课列表请求
+type ListLiveClassesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                        // 状态信息
-	StreamUrl     string                 `protobuf:"bytes,2,opt,name=stream_url,json=streamUrl,proto3" json:"stream_url,omitempty"` // 播放地址
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                      // 附加消息（改为普通字符串）
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                      // live（候场、直播中和暂停）/ scheduled / ended（已结束和已归档），也可以指定 lobby / paused / archived，为空时返回全部
+	Teacher       string                 `protobuf:"bytes,2,opt,name=teacher,proto3" json:"teacher,omitempty"`                    // 发起人用户名
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`                    // 按直播课名称或发起人模糊搜索
+	Sort          string                 `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"`                          // newest（默认）/ oldest / name
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`                      // 上一页返回的 next_cursor，为空时从第一页开始
+	PageSize      int32                  `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // 默认 20，最大 100
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *JoinLiveClassResponse) Reset() {
-	*x = JoinLiveClassResponse{}
+func (x *ListLiveClassesRequest) Reset() {
+	*x = ListLiveClassesRequest{}
 	mi := &file_live_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *JoinLiveClassResponse) String() string {
+func (x *ListLiveClassesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JoinLiveClassResponse) ProtoMessage() {}
+func (*ListLiveClassesRequest) ProtoMessage() {}
 
-func (x *JoinLiveClassResponse) ProtoReflect() protoreflect.Message {
+func (x *ListLiveClassesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -329,57 +462,76 @@ func (x *JoinLiveClassResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JoinLiveClassResponse.ProtoReflect.Descriptor instead.
-func (*JoinLiveClassResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListLiveClassesRequest.ProtoReflect.Descriptor instead.
+func (*ListLiveClassesRequest) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *JoinLiveClassResponse) GetStatus() string {
+func (x *ListLiveClassesRequest) GetStatus() string {
 	if x != nil {
 		return x.Status
 	}
 	return ""
 }
 
-func (x *JoinLiveClassResponse) GetStreamUrl() string {
+func (x *ListLiveClassesRequest) GetTeacher() string {
 	if x != nil {
-		return x.StreamUrl
+		return x.Teacher
 	}
 	return ""
 }
 
-func (x *JoinLiveClassResponse) GetMessage() string {
+func (x *ListLiveClassesRequest) GetKeyword() string {
 	if x != nil {
-		return x.Message
+		return x.Keyword
 	}
 	return ""
 }
 
-// 发送消息请求
-type SendMessageRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	ClassId        string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`                      // 直播课ID
-	SenderName     string                 `protobuf:"bytes,2,opt,name=sender_name,json=senderName,proto3" json:"sender_name,omitempty"`             // 发送者名称
-	MessageContent string                 `protobuf:"bytes,3,opt,name=message_content,json=messageContent,proto3" json:"message_content,omitempty"` // 消息内容
-	Message        *Message               `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *ListLiveClassesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
 }
 
-func (x *SendMessageRequest) Reset() {
-	*x = SendMessageRequest{}
+func (x *ListLiveClassesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListLiveClassesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// 查询直播课列表响应
+type ListLiveClassesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Classes       []*LiveClass           `protobuf:"bytes,1,rep,name=classes,proto3" json:"classes,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // 为空表示没有下一页
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLiveClassesResponse) Reset() {
+	*x = ListLiveClassesResponse{}
 	mi := &file_live_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendMessageRequest) String() string {
+func (x *ListLiveClassesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendMessageRequest) ProtoMessage() {}
+func (*ListLiveClassesResponse) ProtoMessage() {}
 
-func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+func (x *ListLiveClassesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -391,61 +543,48 @@ func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
-func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListLiveClassesResponse.ProtoReflect.Descriptor instead.
+func (*ListLiveClassesResponse) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *SendMessageRequest) GetClassId() string {
-	if x != nil {
-		return x.ClassId
-	}
-	return ""
-}
-
-func (x *SendMessageRequest) GetSenderName() string {
+func (x *ListLiveClassesResponse) GetClasses() []*LiveClass {
 	if x != nil {
-		return x.SenderName
+		return x.Classes
 	}
-	return ""
+	return nil
 }
 
-func (x *SendMessageRequest) GetMessageContent() string {
+func (x *ListLiveClassesResponse) GetNextCursor() string {
 	if x != nil {
-		return x.MessageContent
+		return x.NextCursor
 	}
 	return ""
 }
 
-func (x *SendMessageRequest) GetMessage() *Message {
-	if x != nil {
-		return x.Message
-	}
-	return nil
-}
-
-// 发送消息响应
-type SendMessageResponse struct {
+// 加入直播课请求
+type JoinLiveClassRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`             // 直播课ID
+	StudentName   string                 `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"` // 学生名称
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendMessageResponse) Reset() {
-	*x = SendMessageResponse{}
+func (x *JoinLiveClassRequest) Reset() {
+	*x = JoinLiveClassRequest{}
 	mi := &file_live_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendMessageResponse) String() string {
+func (x *JoinLiveClassRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendMessageResponse) ProtoMessage() {}
+func (*JoinLiveClassRequest) ProtoMessage() {}
 
-func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
+func (x *JoinLiveClassRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_live_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -457,43 +596,1202 @@ func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
-func (*SendMessageResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use JoinLiveClassRequest.ProtoReflect.Descriptor instead.
+func (*JoinLiveClassRequest) Descriptor() ([]byte, []int) {
 	return file_live_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SendMessageResponse) GetStatus() string {
+func (x *JoinLiveClassRequest) GetClassId() string {
 	if x != nil {
-		return x.Status
+		return x.ClassId
 	}
 	return ""
 }
 
-// 消息类型
-type Message struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	SenderName     string                 `protobuf:"bytes,1,opt,name=sender_name,json=senderName,proto3" json:"sender_name,omitempty"`             // 发送者名称
-	MessageContent string                 `protobuf:"bytes,2,opt,name=message_content,json=messageContent,proto3" json:"message_content,omitempty"` // 消息内容
-	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                // 时间戳
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *JoinLiveClassRequest) GetStudentName() string {
+	if x != nil {
+		return x.StudentName
+	}
+	return ""
+}
+
+// 获取消息请求
+type GetMessagesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`                    // 直播课ID
+	LastTimestamp int64                  `protobuf:"varint,2,opt,name=last_timestamp,json=lastTimestamp,proto3" json:"last_timestamp,omitempty"` // 最后收到消息的时间戳
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMessagesRequest) Reset() {
+	*x = GetMessagesRequest{}
+	mi := &file_live_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessagesRequest) ProtoMessage() {}
+
+func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessagesRequest.ProtoReflect.Descriptor instead.
+func (*GetMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetMessagesRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetLastTimestamp() int64 {
+	if x != nil {
+		return x.LastTimestamp
+	}
+	return 0
+}
+
+// 获取消息响应
+type GetMessagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"` // 消息列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMessagesResponse) Reset() {
+	*x = GetMessagesResponse{}
+	mi := &file_live_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessagesResponse) ProtoMessage() {}
+
+func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessagesResponse.ProtoReflect.Descriptor instead.
+func (*GetMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetMessagesResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+// 加入直播课响应
+type JoinLiveClassResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                        // 状态信息
+	StreamUrl     string                 `protobuf:"bytes,2,opt,name=stream_url,json=streamUrl,proto3" json:"stream_url,omitempty"` // 播放地址
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                      // 附加消息（改为普通字符串）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinLiveClassResponse) Reset() {
+	*x = JoinLiveClassResponse{}
+	mi := &file_live_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinLiveClassResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinLiveClassResponse) ProtoMessage() {}
+
+func (x *JoinLiveClassResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinLiveClassResponse.ProtoReflect.Descriptor instead.
+func (*JoinLiveClassResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *JoinLiveClassResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *JoinLiveClassResponse) GetStreamUrl() string {
+	if x != nil {
+		return x.StreamUrl
+	}
+	return ""
+}
+
+func (x *JoinLiveClassResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// 发送消息请求
+type SendMessageRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ClassId        string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`                      // 直播课ID
+	SenderName     string                 `protobuf:"bytes,2,opt,name=sender_name,json=senderName,proto3" json:"sender_name,omitempty"`             // 发送者名称
+	MessageContent string                 `protobuf:"bytes,3,opt,name=message_content,json=messageContent,proto3" json:"message_content,omitempty"` // 消息内容
+	Message        *Message               `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SendMessageRequest) Reset() {
+	*x = SendMessageRequest{}
+	mi := &file_live_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageRequest) ProtoMessage() {}
+
+func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SendMessageRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetSenderName() string {
+	if x != nil {
+		return x.SenderName
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetMessageContent() string {
+	if x != nil {
+		return x.MessageContent
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+// 发送消息响应
+type SendMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendMessageResponse) Reset() {
+	*x = SendMessageResponse{}
+	mi := &file_live_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageResponse) ProtoMessage() {}
+
+func (x *SendMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessageResponse.ProtoReflect.Descriptor instead.
+func (*SendMessageResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SendMessageResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 消息类型
+type Message struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SenderName     string                 `protobuf:"bytes,1,opt,name=sender_name,json=senderName,proto3" json:"sender_name,omitempty"`             // 发送者名称
+	MessageContent string                 `protobuf:"bytes,2,opt,name=message_content,json=messageContent,proto3" json:"message_content,omitempty"` // 消息内容
+	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                // 时间戳
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_live_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Message) GetSenderName() string {
+	if x != nil {
+		return x.SenderName
+	}
+	return ""
+}
+
+func (x *Message) GetMessageContent() string {
+	if x != nil {
+		return x.MessageContent
+	}
+	return ""
+}
+
+func (x *Message) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// 结束直播课请求
+type EndLiveClassRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ClassId string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	// Deprecated: Marked as deprecated in live.proto.
+	Username      string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"` // 已废弃：服务端只信任 Token 中的用户
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndLiveClassRequest) Reset() {
+	*x = EndLiveClassRequest{}
+	mi := &file_live_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndLiveClassRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndLiveClassRequest) ProtoMessage() {}
+
+func (x *EndLiveClassRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndLiveClassRequest.ProtoReflect.Descriptor instead.
+func (*EndLiveClassRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EndLiveClassRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in live.proto.
+func (x *EndLiveClassRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// 结束直播课响应
+type EndLiveClassResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndLiveClassResponse) Reset() {
+	*x = EndLiveClassResponse{}
+	mi := &file_live_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndLiveClassResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndLiveClassResponse) ProtoMessage() {}
+
+func (x *EndLiveClassResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndLiveClassResponse.ProtoReflect.Descriptor instead.
+func (*EndLiveClassResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *EndLiveClassResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 发布题目请求
+type PublishQuestionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	Question      string                 `protobuf:"bytes,2,opt,name=question,proto3" json:"question,omitempty"`              // 题目内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishQuestionRequest) Reset() {
+	*x = PublishQuestionRequest{}
+	mi := &file_live_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishQuestionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishQuestionRequest) ProtoMessage() {}
+
+func (x *PublishQuestionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishQuestionRequest.ProtoReflect.Descriptor instead.
+func (*PublishQuestionRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PublishQuestionRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *PublishQuestionRequest) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+// 发布题目响应
+type PublishQuestionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                           // 状态信息
+	QuestionId    string                 `protobuf:"bytes,2,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"` // 题目ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishQuestionResponse) Reset() {
+	*x = PublishQuestionResponse{}
+	mi := &file_live_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishQuestionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishQuestionResponse) ProtoMessage() {}
+
+func (x *PublishQuestionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishQuestionResponse.ProtoReflect.Descriptor instead.
+func (*PublishQuestionResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PublishQuestionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PublishQuestionResponse) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+// 提交答案请求
+type SubmitAnswerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`             // 直播课ID
+	StudentName   string                 `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"` // 学生名称
+	Answer        string                 `protobuf:"bytes,3,opt,name=answer,proto3" json:"answer,omitempty"`                              // 答案内容
+	QuestionId    string                 `protobuf:"bytes,4,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`    // 题目ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitAnswerRequest) Reset() {
+	*x = SubmitAnswerRequest{}
+	mi := &file_live_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitAnswerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitAnswerRequest) ProtoMessage() {}
+
+func (x *SubmitAnswerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitAnswerRequest.ProtoReflect.Descriptor instead.
+func (*SubmitAnswerRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubmitAnswerRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *SubmitAnswerRequest) GetStudentName() string {
+	if x != nil {
+		return x.StudentName
+	}
+	return ""
+}
+
+func (x *SubmitAnswerRequest) GetAnswer() string {
+	if x != nil {
+		return x.Answer
+	}
+	return ""
+}
+
+func (x *SubmitAnswerRequest) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+// 提交答案响应
+type SubmitAnswerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitAnswerResponse) Reset() {
+	*x = SubmitAnswerResponse{}
+	mi := &file_live_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitAnswerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitAnswerResponse) ProtoMessage() {}
+
+func (x *SubmitAnswerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitAnswerResponse.ProtoReflect.Descriptor instead.
+func (*SubmitAnswerResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SubmitAnswerResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 获取答题结果统计请求
+type GetAnswerStatisticsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`          // 直播课ID
+	QuestionId    string                 `protobuf:"bytes,2,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"` // 题目ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAnswerStatisticsRequest) Reset() {
+	*x = GetAnswerStatisticsRequest{}
+	mi := &file_live_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAnswerStatisticsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAnswerStatisticsRequest) ProtoMessage() {}
+
+func (x *GetAnswerStatisticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAnswerStatisticsRequest.ProtoReflect.Descriptor instead.
+func (*GetAnswerStatisticsRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetAnswerStatisticsRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *GetAnswerStatisticsRequest) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+// 答题结果统计
+type AnswerStatistics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`                                                                                  // 题目ID
+	AnswerCounts  map[string]int32       `protobuf:"bytes,2,rep,name=answer_counts,json=answerCounts,proto3" json:"answer_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 答案统计
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnswerStatistics) Reset() {
+	*x = AnswerStatistics{}
+	mi := &file_live_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnswerStatistics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnswerStatistics) ProtoMessage() {}
+
+func (x *AnswerStatistics) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnswerStatistics.ProtoReflect.Descriptor instead.
+func (*AnswerStatistics) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AnswerStatistics) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *AnswerStatistics) GetAnswerCounts() map[string]int32 {
+	if x != nil {
+		return x.AnswerCounts
+	}
+	return nil
+}
+
+// 题目类型
+type Question struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`       // 题目ID
+	QuestionText  string                 `protobuf:"bytes,2,opt,name=question_text,json=questionText,proto3" json:"question_text,omitempty"` // 题目内容
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Question) Reset() {
+	*x = Question{}
+	mi := &file_live_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Question) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Question) ProtoMessage() {}
+
+func (x *Question) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Question.ProtoReflect.Descriptor instead.
+func (*Question) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Question) GetQuestionId() string {
+	if x != nil {
+		return x.QuestionId
+	}
+	return ""
+}
+
+func (x *Question) GetQuestionText() string {
+	if x != nil {
+		return x.QuestionText
+	}
+	return ""
+}
+
+// 移出用户请求
+type KickUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`              // 被移出的用户名
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                  // 移出原因，记录在审计日志中
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickUserRequest) Reset() {
+	*x = KickUserRequest{}
+	mi := &file_live_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickUserRequest) ProtoMessage() {}
+
+func (x *KickUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickUserRequest.ProtoReflect.Descriptor instead.
+func (*KickUserRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *KickUserRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+func (x *KickUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *KickUserRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// 移出用户响应
+type KickUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickUserResponse) Reset() {
+	*x = KickUserResponse{}
+	mi := &file_live_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickUserResponse) ProtoMessage() {}
+
+func (x *KickUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickUserResponse.ProtoReflect.Descriptor instead.
+func (*KickUserResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *KickUserResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 心跳请求
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_live_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *HeartbeatRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+// 心跳响应
+type HeartbeatResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Status          string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	IntervalSeconds int32                  `protobuf:"varint,2,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"` // 建议的心跳间隔
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_live_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *HeartbeatResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeartbeatResponse) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+// 离开直播课请求
+type LeaveLiveClassRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveLiveClassRequest) Reset() {
+	*x = LeaveLiveClassRequest{}
+	mi := &file_live_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveLiveClassRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveLiveClassRequest) ProtoMessage() {}
+
+func (x *LeaveLiveClassRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveLiveClassRequest.ProtoReflect.Descriptor instead.
+func (*LeaveLiveClassRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *LeaveLiveClassRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
+}
+
+// 离开直播课响应
+type LeaveLiveClassResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveLiveClassResponse) Reset() {
+	*x = LeaveLiveClassResponse{}
+	mi := &file_live_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveLiveClassResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveLiveClassResponse) ProtoMessage() {}
+
+func (x *LeaveLiveClassResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveLiveClassResponse.ProtoReflect.Descriptor instead.
+func (*LeaveLiveClassResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LeaveLiveClassResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// 查询出勤记录请求
+type GetAttendanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAttendanceRequest) Reset() {
+	*x = GetAttendanceRequest{}
+	mi := &file_live_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAttendanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAttendanceRequest) ProtoMessage() {}
+
+func (x *GetAttendanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAttendanceRequest.ProtoReflect.Descriptor instead.
+func (*GetAttendanceRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetAttendanceRequest) GetClassId() string {
+	if x != nil {
+		return x.ClassId
+	}
+	return ""
 }
 
-func (x *Message) Reset() {
-	*x = Message{}
-	mi := &file_live_proto_msgTypes[8]
+// 一段在线记录
+type AttendanceSession struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	JoinedAt        int64                  `protobuf:"varint,1,opt,name=joined_at,json=joinedAt,proto3" json:"joined_at,omitempty"`                      // 加入时间，Unix 时间戳
+	LeftAt          int64                  `protobuf:"varint,2,opt,name=left_at,json=leftAt,proto3" json:"left_at,omitempty"`                            // 离开时间，仍在线为 0
+	DurationSeconds int64                  `protobuf:"varint,3,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"` // 在线时长，仍在线的计算到当前时间
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AttendanceSession) Reset() {
+	*x = AttendanceSession{}
+	mi := &file_live_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Message) String() string {
+func (x *AttendanceSession) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Message) ProtoMessage() {}
+func (*AttendanceSession) ProtoMessage() {}
 
-func (x *Message) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[8]
+func (x *AttendanceSession) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -504,56 +1802,59 @@ func (x *Message) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Message.ProtoReflect.Descriptor instead.
-func (*Message) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use AttendanceSession.ProtoReflect.Descriptor instead.
+func (*AttendanceSession) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *Message) GetSenderName() string {
+func (x *AttendanceSession) GetJoinedAt() int64 {
 	if x != nil {
-		return x.SenderName
+		return x.JoinedAt
 	}
-	return ""
+	return 0
 }
 
-func (x *Message) GetMessageContent() string {
+func (x *AttendanceSession) GetLeftAt() int64 {
 	if x != nil {
-		return x.MessageContent
+		return x.LeftAt
 	}
-	return ""
+	return 0
 }
 
-func (x *Message) GetTimestamp() int64 {
+func (x *AttendanceSession) GetDurationSeconds() int64 {
 	if x != nil {
-		return x.Timestamp
+		return x.DurationSeconds
 	}
 	return 0
 }
 
-// 结束直播课请求
-type EndLiveClassRequest struct {
+// 一个学生的出勤情况
+type StudentAttendance struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
-	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`              // 用户名
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	TotalSeconds  int64                  `protobuf:"varint,3,opt,name=total_seconds,json=totalSeconds,proto3" json:"total_seconds,omitempty"` // 累计在线时长
+	Online        bool                   `protobuf:"varint,4,opt,name=online,proto3" json:"online,omitempty"`                                 // 当前是否在线
+	Sessions      []*AttendanceSession   `protobuf:"bytes,5,rep,name=sessions,proto3" json:"sessions,omitempty"`                              // 按加入时间排序
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EndLiveClassRequest) Reset() {
-	*x = EndLiveClassRequest{}
-	mi := &file_live_proto_msgTypes[9]
+func (x *StudentAttendance) Reset() {
+	*x = StudentAttendance{}
+	mi := &file_live_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EndLiveClassRequest) String() string {
+func (x *StudentAttendance) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EndLiveClassRequest) ProtoMessage() {}
+func (*StudentAttendance) ProtoMessage() {}
 
-func (x *EndLiveClassRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[9]
+func (x *StudentAttendance) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,94 +1865,71 @@ func (x *EndLiveClassRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EndLiveClassRequest.ProtoReflect.Descriptor instead.
-func (*EndLiveClassRequest) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use StudentAttendance.ProtoReflect.Descriptor instead.
+func (*StudentAttendance) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *EndLiveClassRequest) GetClassId() string {
+func (x *StudentAttendance) GetUsername() string {
 	if x != nil {
-		return x.ClassId
+		return x.Username
 	}
 	return ""
 }
 
-func (x *EndLiveClassRequest) GetUsername() string {
+func (x *StudentAttendance) GetDisplayName() string {
 	if x != nil {
-		return x.Username
+		return x.DisplayName
 	}
 	return ""
 }
 
-// 结束直播课响应
-type EndLiveClassResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *EndLiveClassResponse) Reset() {
-	*x = EndLiveClassResponse{}
-	mi := &file_live_proto_msgTypes[10]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *EndLiveClassResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*EndLiveClassResponse) ProtoMessage() {}
-
-func (x *EndLiveClassResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[10]
+func (x *StudentAttendance) GetTotalSeconds() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.TotalSeconds
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use EndLiveClassResponse.ProtoReflect.Descriptor instead.
-func (*EndLiveClassResponse) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{10}
+func (x *StudentAttendance) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
 }
 
-func (x *EndLiveClassResponse) GetStatus() string {
+func (x *StudentAttendance) GetSessions() []*AttendanceSession {
 	if x != nil {
-		return x.Status
+		return x.Sessions
 	}
-	return ""
+	return nil
 }
 
-// 发布题目请求
-type PublishQuestionRequest struct {
+// 查询出勤记录响应
+type GetAttendanceResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
-	Question      string                 `protobuf:"bytes,2,opt,name=question,proto3" json:"question,omitempty"`              // 题目内容
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`
+	ClassStatus   string                 `protobuf:"bytes,2,opt,name=class_status,json=classStatus,proto3" json:"class_status,omitempty"` // 直播课状态
+	Students      []*StudentAttendance   `protobuf:"bytes,3,rep,name=students,proto3" json:"students,omitempty"`                          // 按用户名排序，不含发起人
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PublishQuestionRequest) Reset() {
-	*x = PublishQuestionRequest{}
-	mi := &file_live_proto_msgTypes[11]
+func (x *GetAttendanceResponse) Reset() {
+	*x = GetAttendanceResponse{}
+	mi := &file_live_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PublishQuestionRequest) String() string {
+func (x *GetAttendanceResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PublishQuestionRequest) ProtoMessage() {}
+func (*GetAttendanceResponse) ProtoMessage() {}
 
-func (x *PublishQuestionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[11]
+func (x *GetAttendanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -662,49 +1940,55 @@ func (x *PublishQuestionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PublishQuestionRequest.ProtoReflect.Descriptor instead.
-func (*PublishQuestionRequest) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use GetAttendanceResponse.ProtoReflect.Descriptor instead.
+func (*GetAttendanceResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *PublishQuestionRequest) GetClassId() string {
+func (x *GetAttendanceResponse) GetClassId() string {
 	if x != nil {
 		return x.ClassId
 	}
 	return ""
 }
 
-func (x *PublishQuestionRequest) GetQuestion() string {
+func (x *GetAttendanceResponse) GetClassStatus() string {
 	if x != nil {
-		return x.Question
+		return x.ClassStatus
 	}
 	return ""
 }
 
-// 发布题目响应
-type PublishQuestionResponse struct {
+func (x *GetAttendanceResponse) GetStudents() []*StudentAttendance {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+// 导出出勤记录请求
+type ExportAttendanceRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                           // 状态信息
-	QuestionId    string                 `protobuf:"bytes,2,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"` // 题目ID
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PublishQuestionResponse) Reset() {
-	*x = PublishQuestionResponse{}
-	mi := &file_live_proto_msgTypes[12]
+func (x *ExportAttendanceRequest) Reset() {
+	*x = ExportAttendanceRequest{}
+	mi := &file_live_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PublishQuestionResponse) String() string {
+func (x *ExportAttendanceRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PublishQuestionResponse) ProtoMessage() {}
+func (*ExportAttendanceRequest) ProtoMessage() {}
 
-func (x *PublishQuestionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[12]
+func (x *ExportAttendanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -715,51 +1999,43 @@ func (x *PublishQuestionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PublishQuestionResponse.ProtoReflect.Descriptor instead.
-func (*PublishQuestionResponse) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{12}
-}
-
-func (x *PublishQuestionResponse) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
+// Deprecated: Use ExportAttendanceRequest.ProtoReflect.Descriptor instead.
+func (*ExportAttendanceRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *PublishQuestionResponse) GetQuestionId() string {
+func (x *ExportAttendanceRequest) GetClassId() string {
 	if x != nil {
-		return x.QuestionId
+		return x.ClassId
 	}
 	return ""
 }
 
-// 提交答案请求
-type SubmitAnswerRequest struct {
+// 导出出勤记录响应
+type ExportAttendanceResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`             // 直播课ID
-	StudentName   string                 `protobuf:"bytes,2,opt,name=student_name,json=studentName,proto3" json:"student_name,omitempty"` // 学生名称
-	Answer        string                 `protobuf:"bytes,3,opt,name=answer,proto3" json:"answer,omitempty"`                              // 答案内容
-	QuestionId    string                 `protobuf:"bytes,4,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`    // 题目ID
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubmitAnswerRequest) Reset() {
-	*x = SubmitAnswerRequest{}
-	mi := &file_live_proto_msgTypes[13]
+func (x *ExportAttendanceResponse) Reset() {
+	*x = ExportAttendanceResponse{}
+	mi := &file_live_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubmitAnswerRequest) String() string {
+func (x *ExportAttendanceResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubmitAnswerRequest) ProtoMessage() {}
+func (*ExportAttendanceResponse) ProtoMessage() {}
 
-func (x *SubmitAnswerRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[13]
+func (x *ExportAttendanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -770,62 +2046,55 @@ func (x *SubmitAnswerRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubmitAnswerRequest.ProtoReflect.Descriptor instead.
-func (*SubmitAnswerRequest) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *SubmitAnswerRequest) GetClassId() string {
-	if x != nil {
-		return x.ClassId
-	}
-	return ""
+// Deprecated: Use ExportAttendanceResponse.ProtoReflect.Descriptor instead.
+func (*ExportAttendanceResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *SubmitAnswerRequest) GetStudentName() string {
+func (x *ExportAttendanceResponse) GetFilename() string {
 	if x != nil {
-		return x.StudentName
+		return x.Filename
 	}
 	return ""
 }
 
-func (x *SubmitAnswerRequest) GetAnswer() string {
+func (x *ExportAttendanceResponse) GetContentType() string {
 	if x != nil {
-		return x.Answer
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *SubmitAnswerRequest) GetQuestionId() string {
+func (x *ExportAttendanceResponse) GetData() []byte {
 	if x != nil {
-		return x.QuestionId
+		return x.Data
 	}
-	return ""
+	return nil
 }
 
-// 提交答案响应
-type SubmitAnswerResponse struct {
+// 查询在线用户请求
+type ListParticipantsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 状态信息
+	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"` // 直播课ID
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubmitAnswerResponse) Reset() {
-	*x = SubmitAnswerResponse{}
-	mi := &file_live_proto_msgTypes[14]
+func (x *ListParticipantsRequest) Reset() {
+	*x = ListParticipantsRequest{}
+	mi := &file_live_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubmitAnswerResponse) String() string {
+func (x *ListParticipantsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubmitAnswerResponse) ProtoMessage() {}
+func (*ListParticipantsRequest) ProtoMessage() {}
 
-func (x *SubmitAnswerResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[14]
+func (x *ListParticipantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -836,42 +2105,43 @@ func (x *SubmitAnswerResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubmitAnswerResponse.ProtoReflect.Descriptor instead.
-func (*SubmitAnswerResponse) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use ListParticipantsRequest.ProtoReflect.Descriptor instead.
+func (*ListParticipantsRequest) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *SubmitAnswerResponse) GetStatus() string {
+func (x *ListParticipantsRequest) GetClassId() string {
 	if x != nil {
-		return x.Status
+		return x.ClassId
 	}
 	return ""
 }
 
-// 获取答题结果统计请求
-type GetAnswerStatisticsRequest struct {
+// 在线用户
+type Participant struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClassId       string                 `protobuf:"bytes,1,opt,name=class_id,json=classId,proto3" json:"class_id,omitempty"`          // 直播课ID
-	QuestionId    string                 `protobuf:"bytes,2,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"` // 题目ID
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	OnlineSince   int64                  `protobuf:"varint,3,opt,name=online_since,json=onlineSince,proto3" json:"online_since,omitempty"` // 本次上线时间，Unix 时间戳
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetAnswerStatisticsRequest) Reset() {
-	*x = GetAnswerStatisticsRequest{}
-	mi := &file_live_proto_msgTypes[15]
+func (x *Participant) Reset() {
+	*x = Participant{}
+	mi := &file_live_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAnswerStatisticsRequest) String() string {
+func (x *Participant) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAnswerStatisticsRequest) ProtoMessage() {}
+func (*Participant) ProtoMessage() {}
 
-func (x *GetAnswerStatisticsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[15]
+func (x *Participant) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -882,49 +2152,55 @@ func (x *GetAnswerStatisticsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetAnswerStatisticsRequest.ProtoReflect.Descriptor instead.
-func (*GetAnswerStatisticsRequest) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use Participant.ProtoReflect.Descriptor instead.
+func (*Participant) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *GetAnswerStatisticsRequest) GetClassId() string {
+func (x *Participant) GetUsername() string {
 	if x != nil {
-		return x.ClassId
+		return x.Username
 	}
 	return ""
 }
 
-func (x *GetAnswerStatisticsRequest) GetQuestionId() string {
+func (x *Participant) GetDisplayName() string {
 	if x != nil {
-		return x.QuestionId
+		return x.DisplayName
 	}
 	return ""
 }
 
-// 答题结果统计
-type AnswerStatistics struct {
+func (x *Participant) GetOnlineSince() int64 {
+	if x != nil {
+		return x.OnlineSince
+	}
+	return 0
+}
+
+// 查询在线用户响应
+type ListParticipantsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`                                                                                  // 题目ID
-	AnswerCounts  map[string]int32       `protobuf:"bytes,2,rep,name=answer_counts,json=answerCounts,proto3" json:"answer_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 答案统计
+	Participants  []*Participant         `protobuf:"bytes,1,rep,name=participants,proto3" json:"participants,omitempty"` // 按上线时间排序
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AnswerStatistics) Reset() {
-	*x = AnswerStatistics{}
-	mi := &file_live_proto_msgTypes[16]
+func (x *ListParticipantsResponse) Reset() {
+	*x = ListParticipantsResponse{}
+	mi := &file_live_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AnswerStatistics) String() string {
+func (x *ListParticipantsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AnswerStatistics) ProtoMessage() {}
+func (*ListParticipantsResponse) ProtoMessage() {}
 
-func (x *AnswerStatistics) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[16]
+func (x *ListParticipantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -935,49 +2211,43 @@ func (x *AnswerStatistics) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AnswerStatistics.ProtoReflect.Descriptor instead.
-func (*AnswerStatistics) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{16}
-}
-
-func (x *AnswerStatistics) GetQuestionId() string {
-	if x != nil {
-		return x.QuestionId
-	}
-	return ""
+// Deprecated: Use ListParticipantsResponse.ProtoReflect.Descriptor instead.
+func (*ListParticipantsResponse) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *AnswerStatistics) GetAnswerCounts() map[string]int32 {
+func (x *ListParticipantsResponse) GetParticipants() []*Participant {
 	if x != nil {
-		return x.AnswerCounts
+		return x.Participants
 	}
 	return nil
 }
 
-// 题目类型
-type Question struct {
+// 在线用户变化
+type ParticipantEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	QuestionId    string                 `protobuf:"bytes,1,opt,name=question_id,json=questionId,proto3" json:"question_id,omitempty"`       // 题目ID
-	QuestionText  string                 `protobuf:"bytes,2,opt,name=question_text,json=questionText,proto3" json:"question_text,omitempty"` // 题目内容
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                                   // snapshot（完整列表）/ join / leave
+	Participants  []*Participant         `protobuf:"bytes,2,rep,name=participants,proto3" json:"participants,omitempty"`                   // snapshot 时为全部在线用户，join / leave 时为变化的用户
+	OnlineCount   int64                  `protobuf:"varint,3,opt,name=online_count,json=onlineCount,proto3" json:"online_count,omitempty"` // 变化后的在线人数
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Question) Reset() {
-	*x = Question{}
-	mi := &file_live_proto_msgTypes[17]
+func (x *ParticipantEvent) Reset() {
+	*x = ParticipantEvent{}
+	mi := &file_live_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Question) String() string {
+func (x *ParticipantEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Question) ProtoMessage() {}
+func (*ParticipantEvent) ProtoMessage() {}
 
-func (x *Question) ProtoReflect() protoreflect.Message {
-	mi := &file_live_proto_msgTypes[17]
+func (x *ParticipantEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_live_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -988,23 +2258,30 @@ func (x *Question) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Question.ProtoReflect.Descriptor instead.
-func (*Question) Descriptor() ([]byte, []int) {
-	return file_live_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use ParticipantEvent.ProtoReflect.Descriptor instead.
+func (*ParticipantEvent) Descriptor() ([]byte, []int) {
+	return file_live_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *Question) GetQuestionId() string {
+func (x *ParticipantEvent) GetType() string {
 	if x != nil {
-		return x.QuestionId
+		return x.Type
 	}
 	return ""
 }
 
-func (x *Question) GetQuestionText() string {
+func (x *ParticipantEvent) GetParticipants() []*Participant {
 	if x != nil {
-		return x.QuestionText
+		return x.Participants
 	}
-	return ""
+	return nil
+}
+
+func (x *ParticipantEvent) GetOnlineCount() int64 {
+	if x != nil {
+		return x.OnlineCount
+	}
+	return 0
 }
 
 var File_live_proto protoreflect.FileDescriptor
@@ -1012,17 +2289,58 @@ var File_live_proto protoreflect.FileDescriptor
 const file_live_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"live.proto\x12\x05proto\"w\n" +
-	"\x16CreateLiveClassRequest\x12!\n" +
-	"\fteacher_name\x18\x01 \x01(\tR\vteacherName\x12\x1d\n" +
+	"live.proto\x12\x05proto\"\xc9\x01\n" +
+	"\x16CreateLiveClassRequest\x12%\n" +
+	"\fteacher_name\x18\x01 \x01(\tB\x02\x18\x01R\vteacherName\x12\x1d\n" +
 	"\n" +
 	"class_name\x18\x02 \x01(\tR\tclassName\x12\x1b\n" +
-	"\troom_name\x18\x03 \x01(\tR\broomName\"k\n" +
+	"\troom_name\x18\x03 \x01(\tR\broomName\x12'\n" +
+	"\x0fscheduled_start\x18\x04 \x01(\x03R\x0escheduledStart\x12#\n" +
+	"\rscheduled_end\x18\x05 \x01(\x03R\fscheduledEnd\"\xa0\x01\n" +
 	"\x17CreateLiveClassResponse\x12\x19\n" +
 	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12\x1d\n" +
 	"\n" +
-	"stream_key\x18\x03 \x01(\tR\tstreamKey\"T\n" +
+	"stream_key\x18\x03 \x01(\tR\tstreamKey\x12\x1d\n" +
+	"\n" +
+	"class_name\x18\x04 \x01(\tR\tclassName\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\"N\n" +
+	"\x1bUpdateLiveClassStateRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\"0\n" +
+	"\x13GetLiveClassRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"\xdb\x03\n" +
+	"\tLiveClass\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x1d\n" +
+	"\n" +
+	"class_name\x18\x02 \x01(\tR\tclassName\x12!\n" +
+	"\fteacher_name\x18\x03 \x01(\tR\vteacherName\x120\n" +
+	"\x14teacher_display_name\x18\x04 \x01(\tR\x12teacherDisplayName\x12\x1b\n" +
+	"\troom_name\x18\x05 \x01(\tR\broomName\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x19\n" +
+	"\bended_at\x18\b \x01(\x03R\aendedAt\x12\x1d\n" +
+	"\n" +
+	"stream_url\x18\t \x01(\tR\tstreamUrl\x12!\n" +
+	"\fviewer_count\x18\n" +
+	" \x01(\x03R\vviewerCount\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\v \x01(\x03R\tstartedAt\x12'\n" +
+	"\x0fscheduled_start\x18\f \x01(\x03R\x0escheduledStart\x12#\n" +
+	"\rscheduled_end\x18\r \x01(\x03R\fscheduledEnd\x12!\n" +
+	"\fonline_count\x18\x0e \x01(\x03R\vonlineCount\"\xad\x01\n" +
+	"\x16ListLiveClassesRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\ateacher\x18\x02 \x01(\tR\ateacher\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\x12\x1b\n" +
+	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\"f\n" +
+	"\x17ListLiveClassesResponse\x12*\n" +
+	"\aclasses\x18\x01 \x03(\v2\x10.proto.LiveClassR\aclasses\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"T\n" +
 	"\x14JoinLiveClassRequest\x12\x19\n" +
 	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12!\n" +
 	"\fstudent_name\x18\x02 \x01(\tR\vstudentName\"V\n" +
@@ -1048,10 +2366,10 @@ const file_live_proto_rawDesc = "" +
 	"\vsender_name\x18\x01 \x01(\tR\n" +
 	"senderName\x12'\n" +
 	"\x0fmessage_content\x18\x02 \x01(\tR\x0emessageContent\x12\x1c\n" +
-	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"L\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"P\n" +
 	"\x13EndLiveClassRequest\x12\x19\n" +
-	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\".\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x1e\n" +
+	"\busername\x18\x02 \x01(\tB\x02\x18\x01R\busername\".\n" +
 	"\x14EndLiveClassResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\"O\n" +
 	"\x16PublishQuestionRequest\x12\x19\n" +
@@ -1083,7 +2401,57 @@ const file_live_proto_rawDesc = "" +
 	"\bQuestion\x12\x1f\n" +
 	"\vquestion_id\x18\x01 \x01(\tR\n" +
 	"questionId\x12#\n" +
-	"\rquestion_text\x18\x02 \x01(\tR\fquestionText2\xf7\x04\n" +
+	"\rquestion_text\x18\x02 \x01(\tR\fquestionText\"`\n" +
+	"\x0fKickUserRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"*\n" +
+	"\x10KickUserResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"-\n" +
+	"\x10HeartbeatRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"V\n" +
+	"\x11HeartbeatResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12)\n" +
+	"\x10interval_seconds\x18\x02 \x01(\x05R\x0fintervalSeconds\"2\n" +
+	"\x15LeaveLiveClassRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"0\n" +
+	"\x16LeaveLiveClassResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"1\n" +
+	"\x14GetAttendanceRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"t\n" +
+	"\x11AttendanceSession\x12\x1b\n" +
+	"\tjoined_at\x18\x01 \x01(\x03R\bjoinedAt\x12\x17\n" +
+	"\aleft_at\x18\x02 \x01(\x03R\x06leftAt\x12)\n" +
+	"\x10duration_seconds\x18\x03 \x01(\x03R\x0fdurationSeconds\"\xc5\x01\n" +
+	"\x11StudentAttendance\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12#\n" +
+	"\rtotal_seconds\x18\x03 \x01(\x03R\ftotalSeconds\x12\x16\n" +
+	"\x06online\x18\x04 \x01(\bR\x06online\x124\n" +
+	"\bsessions\x18\x05 \x03(\v2\x18.proto.AttendanceSessionR\bsessions\"\x8b\x01\n" +
+	"\x15GetAttendanceResponse\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\x12!\n" +
+	"\fclass_status\x18\x02 \x01(\tR\vclassStatus\x124\n" +
+	"\bstudents\x18\x03 \x03(\v2\x18.proto.StudentAttendanceR\bstudents\"4\n" +
+	"\x17ExportAttendanceRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"m\n" +
+	"\x18ExportAttendanceResponse\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"4\n" +
+	"\x17ListParticipantsRequest\x12\x19\n" +
+	"\bclass_id\x18\x01 \x01(\tR\aclassId\"o\n" +
+	"\vParticipant\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12!\n" +
+	"\fonline_since\x18\x03 \x01(\x03R\vonlineSince\"R\n" +
+	"\x18ListParticipantsResponse\x126\n" +
+	"\fparticipants\x18\x01 \x03(\v2\x12.proto.ParticipantR\fparticipants\"\x81\x01\n" +
+	"\x10ParticipantEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x126\n" +
+	"\fparticipants\x18\x02 \x03(\v2\x12.proto.ParticipantR\fparticipants\x12!\n" +
+	"\fonline_count\x18\x03 \x01(\x03R\vonlineCount2\xe7\n" +
+	"\n" +
 	"\x10LiveClassService\x12P\n" +
 	"\x0fCreateLiveClass\x12\x1d.proto.CreateLiveClassRequest\x1a\x1e.proto.CreateLiveClassResponse\x12L\n" +
 	"\rJoinLiveClass\x12\x1b.proto.JoinLiveClassRequest\x1a\x1c.proto.JoinLiveClassResponse\"\x00\x12D\n" +
@@ -1092,7 +2460,17 @@ const file_live_proto_rawDesc = "" +
 	"\x0fPublishQuestion\x12\x1d.proto.PublishQuestionRequest\x1a\x1e.proto.PublishQuestionResponse\x12G\n" +
 	"\fSubmitAnswer\x12\x1a.proto.SubmitAnswerRequest\x1a\x1b.proto.SubmitAnswerResponse\x12D\n" +
 	"\vGetMessages\x12\x19.proto.GetMessagesRequest\x1a\x1a.proto.GetMessagesResponse\x12S\n" +
-	"\x13GetAnswerStatistics\x12!.proto.GetAnswerStatisticsRequest\x1a\x17.proto.AnswerStatistics0\x01B\tZ\a.;protob\x06proto3"
+	"\x13GetAnswerStatistics\x12!.proto.GetAnswerStatisticsRequest\x1a\x17.proto.AnswerStatistics0\x01\x12;\n" +
+	"\bKickUser\x12\x16.proto.KickUserRequest\x1a\x17.proto.KickUserResponse\x12<\n" +
+	"\fGetLiveClass\x12\x1a.proto.GetLiveClassRequest\x1a\x10.proto.LiveClass\x12P\n" +
+	"\x0fListLiveClasses\x12\x1d.proto.ListLiveClassesRequest\x1a\x1e.proto.ListLiveClassesResponse\x12L\n" +
+	"\x14UpdateLiveClassState\x12\".proto.UpdateLiveClassStateRequest\x1a\x10.proto.LiveClass\x12>\n" +
+	"\tHeartbeat\x12\x17.proto.HeartbeatRequest\x1a\x18.proto.HeartbeatResponse\x12M\n" +
+	"\x0eLeaveLiveClass\x12\x1c.proto.LeaveLiveClassRequest\x1a\x1d.proto.LeaveLiveClassResponse\x12J\n" +
+	"\rGetAttendance\x12\x1b.proto.GetAttendanceRequest\x1a\x1c.proto.GetAttendanceResponse\x12S\n" +
+	"\x10ExportAttendance\x12\x1e.proto.ExportAttendanceRequest\x1a\x1f.proto.ExportAttendanceResponse\x12S\n" +
+	"\x10ListParticipants\x12\x1e.proto.ListParticipantsRequest\x1a\x1f.proto.ListParticipantsResponse\x12N\n" +
+	"\x11WatchParticipants\x12\x1e.proto.ListParticipantsRequest\x1a\x17.proto.ParticipantEvent0\x01B\tZ\a.;protob\x06proto3"
 
 var (
 	file_live_proto_rawDescOnce sync.Once
@@ -1106,53 +2484,99 @@ func file_live_proto_rawDescGZIP() []byte {
 	return file_live_proto_rawDescData
 }
 
-var file_live_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_live_proto_msgTypes = make([]protoimpl.MessageInfo, 40)
 var file_live_proto_goTypes = []any{
-	(*CreateLiveClassRequest)(nil),     // 0: proto.CreateLiveClassRequest
-	(*CreateLiveClassResponse)(nil),    // 1: proto.CreateLiveClassResponse
-	(*JoinLiveClassRequest)(nil),       // 2: proto.JoinLiveClassRequest
-	(*GetMessagesRequest)(nil),         // 3: proto.GetMessagesRequest
-	(*GetMessagesResponse)(nil),        // 4: proto.GetMessagesResponse
-	(*JoinLiveClassResponse)(nil),      // 5: proto.JoinLiveClassResponse
-	(*SendMessageRequest)(nil),         // 6: proto.SendMessageRequest
-	(*SendMessageResponse)(nil),        // 7: proto.SendMessageResponse
-	(*Message)(nil),                    // 8: proto.Message
-	(*EndLiveClassRequest)(nil),        // 9: proto.EndLiveClassRequest
-	(*EndLiveClassResponse)(nil),       // 10: proto.EndLiveClassResponse
-	(*PublishQuestionRequest)(nil),     // 11: proto.PublishQuestionRequest
-	(*PublishQuestionResponse)(nil),    // 12: proto.PublishQuestionResponse
-	(*SubmitAnswerRequest)(nil),        // 13: proto.SubmitAnswerRequest
-	(*SubmitAnswerResponse)(nil),       // 14: proto.SubmitAnswerResponse
-	(*GetAnswerStatisticsRequest)(nil), // 15: proto.GetAnswerStatisticsRequest
-	(*AnswerStatistics)(nil),           // 16: proto.AnswerStatistics
-	(*Question)(nil),                   // 17: proto.Question
-	nil,                                // 18: proto.AnswerStatistics.AnswerCountsEntry
+	(*CreateLiveClassRequest)(nil),      // 0: proto.CreateLiveClassRequest
+	(*CreateLiveClassResponse)(nil),     // 1: proto.CreateLiveClassResponse
+	(*UpdateLiveClassStateRequest)(nil), // 2: proto.UpdateLiveClassStateRequest
+	(*GetLiveClassRequest)(nil),         // 3: proto.GetLiveClassRequest
+	(*LiveClass)(nil),                   // 4: proto.LiveClass
+	(*ListLiveClassesRequest)(nil),      // 5: proto.ListLiveClassesRequest
+	(*ListLiveClassesResponse)(nil),     // 6: proto.ListLiveClassesResponse
+	(*JoinLiveClassRequest)(nil),        // 7: proto.JoinLiveClassRequest
+	(*GetMessagesRequest)(nil),          // 8: proto.GetMessagesRequest
+	(*GetMessagesResponse)(nil),         // 9: proto.GetMessagesResponse
+	(*JoinLiveClassResponse)(nil),       // 10: proto.JoinLiveClassResponse
+	(*SendMessageRequest)(nil),          // 11: proto.SendMessageRequest
+	(*SendMessageResponse)(nil),         // 12: proto.SendMessageResponse
+	(*Message)(nil),                     // 13: proto.Message
+	(*EndLiveClassRequest)(nil),         // 14: proto.EndLiveClassRequest
+	(*EndLiveClassResponse)(nil),        // 15: proto.EndLiveClassResponse
+	(*PublishQuestionRequest)(nil),      // 16: proto.PublishQuestionRequest
+	(*PublishQuestionResponse)(nil),     // 17: proto.PublishQuestionResponse
+	(*SubmitAnswerRequest)(nil),         // 18: proto.SubmitAnswerRequest
+	(*SubmitAnswerResponse)(nil),        // 19: proto.SubmitAnswerResponse
+	(*GetAnswerStatisticsRequest)(nil),  // 20: proto.GetAnswerStatisticsRequest
+	(*AnswerStatistics)(nil),            // 21: proto.AnswerStatistics
+	(*Question)(nil),                    // 22: proto.Question
+	(*KickUserRequest)(nil),             // 23: proto.KickUserRequest
+	(*KickUserResponse)(nil),            // 24: proto.KickUserResponse
+	(*HeartbeatRequest)(nil),            // 25: proto.HeartbeatRequest
+	(*HeartbeatResponse)(nil),           // 26: proto.HeartbeatResponse
+	(*LeaveLiveClassRequest)(nil),       // 27: proto.LeaveLiveClassRequest
+	(*LeaveLiveClassResponse)(nil),      // 28: proto.LeaveLiveClassResponse
+	(*GetAttendanceRequest)(nil),        // 29: proto.GetAttendanceRequest
+	(*AttendanceSession)(nil),           // 30: proto.AttendanceSession
+	(*StudentAttendance)(nil),           // 31: proto.StudentAttendance
+	(*GetAttendanceResponse)(nil),       // 32: proto.GetAttendanceResponse
+	(*ExportAttendanceRequest)(nil),     // 33: proto.ExportAttendanceRequest
+	(*ExportAttendanceResponse)(nil),    // 34: proto.ExportAttendanceResponse
+	(*ListParticipantsRequest)(nil),     // 35: proto.ListParticipantsRequest
+	(*Participant)(nil),                 // 36: proto.Participant
+	(*ListParticipantsResponse)(nil),    // 37: proto.ListParticipantsResponse
+	(*ParticipantEvent)(nil),            // 38: proto.ParticipantEvent
+	nil,                                 // 39: proto.AnswerStatistics.AnswerCountsEntry
 }
 var file_live_proto_depIdxs = []int32{
-	8,  // 0: proto.GetMessagesResponse.messages:type_name -> proto.Message
-	8,  // 1: proto.SendMessageRequest.message:type_name -> proto.Message
-	18, // 2: proto.AnswerStatistics.answer_counts:type_name -> proto.AnswerStatistics.AnswerCountsEntry
-	0,  // 3: proto.LiveClassService.CreateLiveClass:input_type -> proto.CreateLiveClassRequest
-	2,  // 4: proto.LiveClassService.JoinLiveClass:input_type -> proto.JoinLiveClassRequest
-	6,  // 5: proto.LiveClassService.SendMessage:input_type -> proto.SendMessageRequest
-	9,  // 6: proto.LiveClassService.EndLiveClass:input_type -> proto.EndLiveClassRequest
-	11, // 7: proto.LiveClassService.PublishQuestion:input_type -> proto.PublishQuestionRequest
-	13, // 8: proto.LiveClassService.SubmitAnswer:input_type -> proto.SubmitAnswerRequest
-	3,  // 9: proto.LiveClassService.GetMessages:input_type -> proto.GetMessagesRequest
-	15, // 10: proto.LiveClassService.GetAnswerStatistics:input_type -> proto.GetAnswerStatisticsRequest
-	1,  // 11: proto.LiveClassService.CreateLiveClass:output_type -> proto.CreateLiveClassResponse
-	5,  // 12: proto.LiveClassService.JoinLiveClass:output_type -> proto.JoinLiveClassResponse
-	7,  // 13: proto.LiveClassService.SendMessage:output_type -> proto.SendMessageResponse
-	10, // 14: proto.LiveClassService.EndLiveClass:output_type -> proto.EndLiveClassResponse
-	12, // 15: proto.LiveClassService.PublishQuestion:output_type -> proto.PublishQuestionResponse
-	14, // 16: proto.LiveClassService.SubmitAnswer:output_type -> proto.SubmitAnswerResponse
-	4,  // 17: proto.LiveClassService.GetMessages:output_type -> proto.GetMessagesResponse
-	16, // 18: proto.LiveClassService.GetAnswerStatistics:output_type -> proto.AnswerStatistics
-	11, // [11:19] is the sub-list for method output_type
-	3,  // [3:11] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	4,  // 0: proto.ListLiveClassesResponse.classes:type_name -> proto.LiveClass
+	13, // 1: proto.GetMessagesResponse.messages:type_name -> proto.Message
+	13, // 2: proto.SendMessageRequest.message:type_name -> proto.Message
+	39, // 3: proto.AnswerStatistics.answer_counts:type_name -> proto.AnswerStatistics.AnswerCountsEntry
+	30, // 4: proto.StudentAttendance.sessions:type_name -> proto.AttendanceSession
+	31, // 5: proto.GetAttendanceResponse.students:type_name -> proto.StudentAttendance
+	36, // 6: proto.ListParticipantsResponse.participants:type_name -> proto.Participant
+	36, // 7: proto.ParticipantEvent.participants:type_name -> proto.Participant
+	0,  // 8: proto.LiveClassService.CreateLiveClass:input_type -> proto.CreateLiveClassRequest
+	7,  // 9: proto.LiveClassService.JoinLiveClass:input_type -> proto.JoinLiveClassRequest
+	11, // 10: proto.LiveClassService.SendMessage:input_type -> proto.SendMessageRequest
+	14, // 11: proto.LiveClassService.EndLiveClass:input_type -> proto.EndLiveClassRequest
+	16, // 12: proto.LiveClassService.PublishQuestion:input_type -> proto.PublishQuestionRequest
+	18, // 13: proto.LiveClassService.SubmitAnswer:input_type -> proto.SubmitAnswerRequest
+	8,  // 14: proto.LiveClassService.GetMessages:input_type -> proto.GetMessagesRequest
+	20, // 15: proto.LiveClassService.GetAnswerStatistics:input_type -> proto.GetAnswerStatisticsRequest
+	23, // 16: proto.LiveClassService.KickUser:input_type -> proto.KickUserRequest
+	3,  // 17: proto.LiveClassService.GetLiveClass:input_type -> proto.GetLiveClassRequest
+	5,  // 18: proto.LiveClassService.ListLiveClasses:input_type -> proto.ListLiveClassesRequest
+	2,  // 19: proto.LiveClassService.UpdateLiveClassState:input_type -> proto.UpdateLiveClassStateRequest
+	25, // 20: proto.LiveClassService.Heartbeat:input_type -> proto.HeartbeatRequest
+	27, // 21: proto.LiveClassService.LeaveLiveClass:input_type -> proto.LeaveLiveClassRequest
+	29, // 22: proto.LiveClassService.GetAttendance:input_type -> proto.GetAttendanceRequest
+	33, // 23: proto.LiveClassService.ExportAttendance:input_type -> proto.ExportAttendanceRequest
+	35, // 24: proto.LiveClassService.ListParticipants:input_type -> proto.ListParticipantsRequest
+	35, // 25: proto.LiveClassService.WatchParticipants:input_type -> proto.ListParticipantsRequest
+	1,  // 26: proto.LiveClassService.CreateLiveClass:output_type -> proto.CreateLiveClassResponse
+	10, // 27: proto.LiveClassService.JoinLiveClass:output_type -> proto.JoinLiveClassResponse
+	12, // 28: proto.LiveClassService.SendMessage:output_type -> proto.SendMessageResponse
+	15, // 29: proto.LiveClassService.EndLiveClass:output_type -> proto.EndLiveClassResponse
+	17, // 30: proto.LiveClassService.PublishQuestion:output_type -> proto.PublishQuestionResponse
+	19, // 31: proto.LiveClassService.SubmitAnswer:output_type -> proto.SubmitAnswerResponse
+	9,  // 32: proto.LiveClassService.GetMessages:output_type -> proto.GetMessagesResponse
+	21, // 33: proto.LiveClassService.GetAnswerStatistics:output_type -> proto.AnswerStatistics
+	24, // 34: proto.LiveClassService.KickUser:output_type -> proto.KickUserResponse
+	4,  // 35: proto.LiveClassService.GetLiveClass:output_type -> proto.LiveClass
+	6,  // 36: proto.LiveClassService.ListLiveClasses:output_type -> proto.ListLiveClassesResponse
+	4,  // 37: proto.LiveClassService.UpdateLiveClassState:output_type -> proto.LiveClass
+	26, // 38: proto.LiveClassService.Heartbeat:output_type -> proto.HeartbeatResponse
+	28, // 39: proto.LiveClassService.LeaveLiveClass:output_type -> proto.LeaveLiveClassResponse
+	32, // 40: proto.LiveClassService.GetAttendance:output_type -> proto.GetAttendanceResponse
+	34, // 41: proto.LiveClassService.ExportAttendance:output_type -> proto.ExportAttendanceResponse
+	37, // 42: proto.LiveClassService.ListParticipants:output_type -> proto.ListParticipantsResponse
+	38, // 43: proto.LiveClassService.WatchParticipants:output_type -> proto.ParticipantEvent
+	26, // [26:44] is the sub-list for method output_type
+	8,  // [8:26] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_live_proto_init() }
@@ -1166,7 +2590,7 @@ func file_live_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_live_proto_rawDesc), len(file_live_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   19,
+			NumMessages:   40,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
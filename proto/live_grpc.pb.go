@@ -19,14 +19,24 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LiveClassService_CreateLiveClass_FullMethodName     = "/proto.LiveClassService/CreateLiveClass"
-	LiveClassService_JoinLiveClass_FullMethodName       = "/proto.LiveClassService/JoinLiveClass"
-	LiveClassService_SendMessage_FullMethodName         = "/proto.LiveClassService/SendMessage"
-	LiveClassService_EndLiveClass_FullMethodName        = "/proto.LiveClassService/EndLiveClass"
-	LiveClassService_PublishQuestion_FullMethodName     = "/proto.LiveClassService/PublishQuestion"
-	LiveClassService_SubmitAnswer_FullMethodName        = "/proto.LiveClassService/SubmitAnswer"
-	LiveClassService_GetMessages_FullMethodName         = "/proto.LiveClassService/GetMessages"
-	LiveClassService_GetAnswerStatistics_FullMethodName = "/proto.LiveClassService/GetAnswerStatistics"
+	LiveClassService_CreateLiveClass_FullMethodName      = "/proto.LiveClassService/CreateLiveClass"
+	LiveClassService_JoinLiveClass_FullMethodName        = "/proto.LiveClassService/JoinLiveClass"
+	LiveClassService_SendMessage_FullMethodName          = "/proto.LiveClassService/SendMessage"
+	LiveClassService_EndLiveClass_FullMethodName         = "/proto.LiveClassService/EndLiveClass"
+	LiveClassService_PublishQuestion_FullMethodName      = "/proto.LiveClassService/PublishQuestion"
+	LiveClassService_SubmitAnswer_FullMethodName         = "/proto.LiveClassService/SubmitAnswer"
+	LiveClassService_GetMessages_FullMethodName          = "/proto.LiveClassService/GetMessages"
+	LiveClassService_GetAnswerStatistics_FullMethodName  = "/proto.LiveClassService/GetAnswerStatistics"
+	LiveClassService_KickUser_FullMethodName             = "/proto.LiveClassService/KickUser"
+	LiveClassService_GetLiveClass_FullMethodName         = "/proto.LiveClassService/GetLiveClass"
+	LiveClassService_ListLiveClasses_FullMethodName      = "/proto.LiveClassService/ListLiveClasses"
+	LiveClassService_UpdateLiveClassState_FullMethodName = "/proto.LiveClassService/UpdateLiveClassState"
+	LiveClassService_Heartbeat_FullMethodName            = "/proto.LiveClassService/Heartbeat"
+	LiveClassService_LeaveLiveClass_FullMethodName       = "/proto.LiveClassService/LeaveLiveClass"
+	LiveClassService_GetAttendance_FullMethodName        = "/proto.LiveClassService/GetAttendance"
+	LiveClassService_ExportAttendance_FullMethodName     = "/proto.LiveClassService/ExportAttendance"
+	LiveClassService_ListParticipants_FullMethodName     = "/proto.LiveClassService/ListParticipants"
+	LiveClassService_WatchParticipants_FullMethodName    = "/proto.LiveClassService/WatchParticipants"
 )
 
 // LiveClassServiceClient is the client API for LiveClassService service.
@@ -50,6 +60,26 @@ type LiveClassServiceClient interface {
 	GetMessages(ctx context.Context, in *GetMessagesRequest, opts ...grpc.CallOption) (*GetMessagesResponse, error)
 	// 获取答题结果统计（流式接口）
 	GetAnswerStatistics(ctx context.Context, in *GetAnswerStatisticsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnswerStatistics], error)
+	// 将用户移出直播课，被移出的用户不能再加入、发言或答题
+	KickUser(ctx context.Context, in *KickUserRequest, opts ...grpc.CallOption) (*KickUserResponse, error)
+	// 按 ID 查询直播课详情，包括已结束的直播课
+	GetLiveClass(ctx context.Context, in *GetLiveClassRequest, opts ...grpc.CallOption) (*LiveClass, error)
+	// 查询直播课列表，支持过滤、排序和游标翻页
+	ListLiveClasses(ctx context.Context, in *ListLiveClassesRequest, opts ...grpc.CallOption) (*ListLiveClassesResponse, error)
+	// 切换直播课状态：候场、直播中、暂停、结束
+	UpdateLiveClassState(ctx context.Context, in *UpdateLiveClassStateRequest, opts ...grpc.CallOption) (*LiveClass, error)
+	// 心跳，客户端在直播课中定期发送，超时未收到心跳视为离开
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	// 离开直播课
+	LeaveLiveClass(ctx context.Context, in *LeaveLiveClassRequest, opts ...grpc.CallOption) (*LeaveLiveClassResponse, error)
+	// 查询直播课的出勤记录，只有发起人和管理员可以查询
+	GetAttendance(ctx context.Context, in *GetAttendanceRequest, opts ...grpc.CallOption) (*GetAttendanceResponse, error)
+	// 导出已结束直播课的出勤记录（CSV）
+	ExportAttendance(ctx context.Context, in *ExportAttendanceRequest, opts ...grpc.CallOption) (*ExportAttendanceResponse, error)
+	// 查询当前在线的用户
+	ListParticipants(ctx context.Context, in *ListParticipantsRequest, opts ...grpc.CallOption) (*ListParticipantsResponse, error)
+	// 订阅在线用户变化（流式接口）：先发送完整列表，之后推送加入和离开
+	WatchParticipants(ctx context.Context, in *ListParticipantsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ParticipantEvent], error)
 }
 
 type liveClassServiceClient struct {
@@ -149,6 +179,115 @@ func (c *liveClassServiceClient) GetAnswerStatistics(ctx context.Context, in *Ge
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type LiveClassService_GetAnswerStatisticsClient = grpc.ServerStreamingClient[AnswerStatistics]
 
+func (c *liveClassServiceClient) KickUser(ctx context.Context, in *KickUserRequest, opts ...grpc.CallOption) (*KickUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KickUserResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_KickUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) GetLiveClass(ctx context.Context, in *GetLiveClassRequest, opts ...grpc.CallOption) (*LiveClass, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveClass)
+	err := c.cc.Invoke(ctx, LiveClassService_GetLiveClass_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) ListLiveClasses(ctx context.Context, in *ListLiveClassesRequest, opts ...grpc.CallOption) (*ListLiveClassesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLiveClassesResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_ListLiveClasses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) UpdateLiveClassState(ctx context.Context, in *UpdateLiveClassStateRequest, opts ...grpc.CallOption) (*LiveClass, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveClass)
+	err := c.cc.Invoke(ctx, LiveClassService_UpdateLiveClassState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_Heartbeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) LeaveLiveClass(ctx context.Context, in *LeaveLiveClassRequest, opts ...grpc.CallOption) (*LeaveLiveClassResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaveLiveClassResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_LeaveLiveClass_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) GetAttendance(ctx context.Context, in *GetAttendanceRequest, opts ...grpc.CallOption) (*GetAttendanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAttendanceResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_GetAttendance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) ExportAttendance(ctx context.Context, in *ExportAttendanceRequest, opts ...grpc.CallOption) (*ExportAttendanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportAttendanceResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_ExportAttendance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) ListParticipants(ctx context.Context, in *ListParticipantsRequest, opts ...grpc.CallOption) (*ListParticipantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListParticipantsResponse)
+	err := c.cc.Invoke(ctx, LiveClassService_ListParticipants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *liveClassServiceClient) WatchParticipants(ctx context.Context, in *ListParticipantsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ParticipantEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LiveClassService_ServiceDesc.Streams[1], LiveClassService_WatchParticipants_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListParticipantsRequest, ParticipantEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiveClassService_WatchParticipantsClient = grpc.ServerStreamingClient[ParticipantEvent]
+
 // LiveClassServiceServer is the server API for LiveClassService service.
 // All implementations must embed UnimplementedLiveClassServiceServer
 // for forward compatibility.
@@ -170,6 +309,26 @@ type LiveClassServiceServer interface {
 	GetMessages(context.Context, *GetMessagesRequest) (*GetMessagesResponse, error)
 	// 获取答题结果统计（流式接口）
 	GetAnswerStatistics(*GetAnswerStatisticsRequest, grpc.ServerStreamingServer[AnswerStatistics]) error
+	// 将用户移出直播课，被移出的用户不能再加入、发言或答题
+	KickUser(context.Context, *KickUserRequest) (*KickUserResponse, error)
+	// 按 ID 查询直播课详情，包括已结束的直播课
+	GetLiveClass(context.Context, *GetLiveClassRequest) (*LiveClass, error)
+	// 查询直播课列表，支持过滤、排序和游标翻页
+	ListLiveClasses(context.Context, *ListLiveClassesRequest) (*ListLiveClassesResponse, error)
+	// 切换直播课状态：候场、直播中、暂停、结束
+	UpdateLiveClassState(context.Context, *UpdateLiveClassStateRequest) (*LiveClass, error)
+	// 心跳，客户端在直播课中定期发送，超时未收到心跳视为离开
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	// 离开直播课
+	LeaveLiveClass(context.Context, *LeaveLiveClassRequest) (*LeaveLiveClassResponse, error)
+	// 查询直播课的出勤记录，只有发起人和管理员可以查询
+	GetAttendance(context.Context, *GetAttendanceRequest) (*GetAttendanceResponse, error)
+	// 导出已结束直播课的出勤记录（CSV）
+	ExportAttendance(context.Context, *ExportAttendanceRequest) (*ExportAttendanceResponse, error)
+	// 查询当前在线的用户
+	ListParticipants(context.Context, *ListParticipantsRequest) (*ListParticipantsResponse, error)
+	// 订阅在线用户变化（流式接口）：先发送完整列表，之后推送加入和离开
+	WatchParticipants(*ListParticipantsRequest, grpc.ServerStreamingServer[ParticipantEvent]) error
 	mustEmbedUnimplementedLiveClassServiceServer()
 }
 
@@ -204,6 +363,36 @@ func (UnimplementedLiveClassServiceServer) GetMessages(context.Context, *GetMess
 func (UnimplementedLiveClassServiceServer) GetAnswerStatistics(*GetAnswerStatisticsRequest, grpc.ServerStreamingServer[AnswerStatistics]) error {
 	return status.Errorf(codes.Unimplemented, "method GetAnswerStatistics not implemented")
 }
+func (UnimplementedLiveClassServiceServer) KickUser(context.Context, *KickUserRequest) (*KickUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickUser not implemented")
+}
+func (UnimplementedLiveClassServiceServer) GetLiveClass(context.Context, *GetLiveClassRequest) (*LiveClass, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLiveClass not implemented")
+}
+func (UnimplementedLiveClassServiceServer) ListLiveClasses(context.Context, *ListLiveClassesRequest) (*ListLiveClassesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLiveClasses not implemented")
+}
+func (UnimplementedLiveClassServiceServer) UpdateLiveClassState(context.Context, *UpdateLiveClassStateRequest) (*LiveClass, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateLiveClassState not implemented")
+}
+func (UnimplementedLiveClassServiceServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedLiveClassServiceServer) LeaveLiveClass(context.Context, *LeaveLiveClassRequest) (*LeaveLiveClassResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveLiveClass not implemented")
+}
+func (UnimplementedLiveClassServiceServer) GetAttendance(context.Context, *GetAttendanceRequest) (*GetAttendanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAttendance not implemented")
+}
+func (UnimplementedLiveClassServiceServer) ExportAttendance(context.Context, *ExportAttendanceRequest) (*ExportAttendanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportAttendance not implemented")
+}
+func (UnimplementedLiveClassServiceServer) ListParticipants(context.Context, *ListParticipantsRequest) (*ListParticipantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListParticipants not implemented")
+}
+func (UnimplementedLiveClassServiceServer) WatchParticipants(*ListParticipantsRequest, grpc.ServerStreamingServer[ParticipantEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchParticipants not implemented")
+}
 func (UnimplementedLiveClassServiceServer) mustEmbedUnimplementedLiveClassServiceServer() {}
 func (UnimplementedLiveClassServiceServer) testEmbeddedByValue()                          {}
 
@@ -362,6 +551,179 @@ func _LiveClassService_GetAnswerStatistics_Handler(srv interface{}, stream grpc.
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type LiveClassService_GetAnswerStatisticsServer = grpc.ServerStreamingServer[AnswerStatistics]
 
+func _LiveClassService_KickUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).KickUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_KickUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).KickUser(ctx, req.(*KickUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_GetLiveClass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLiveClassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).GetLiveClass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_GetLiveClass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).GetLiveClass(ctx, req.(*GetLiveClassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_ListLiveClasses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLiveClassesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).ListLiveClasses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_ListLiveClasses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).ListLiveClasses(ctx, req.(*ListLiveClassesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_UpdateLiveClassState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLiveClassStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).UpdateLiveClassState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_UpdateLiveClassState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).UpdateLiveClassState(ctx, req.(*UpdateLiveClassStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_LeaveLiveClass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveLiveClassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).LeaveLiveClass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_LeaveLiveClass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).LeaveLiveClass(ctx, req.(*LeaveLiveClassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_GetAttendance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAttendanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).GetAttendance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_GetAttendance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).GetAttendance(ctx, req.(*GetAttendanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_ExportAttendance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportAttendanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).ExportAttendance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_ExportAttendance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).ExportAttendance(ctx, req.(*ExportAttendanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_ListParticipants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListParticipantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LiveClassServiceServer).ListParticipants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LiveClassService_ListParticipants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LiveClassServiceServer).ListParticipants(ctx, req.(*ListParticipantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LiveClassService_WatchParticipants_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListParticipantsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LiveClassServiceServer).WatchParticipants(m, &grpc.GenericServerStream[ListParticipantsRequest, ParticipantEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LiveClassService_WatchParticipantsServer = grpc.ServerStreamingServer[ParticipantEvent]
+
 // LiveClassService_ServiceDesc is the grpc.ServiceDesc for LiveClassService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -397,6 +759,42 @@ var LiveClassService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMessages",
 			Handler:    _LiveClassService_GetMessages_Handler,
 		},
+		{
+			MethodName: "KickUser",
+			Handler:    _LiveClassService_KickUser_Handler,
+		},
+		{
+			MethodName: "GetLiveClass",
+			Handler:    _LiveClassService_GetLiveClass_Handler,
+		},
+		{
+			MethodName: "ListLiveClasses",
+			Handler:    _LiveClassService_ListLiveClasses_Handler,
+		},
+		{
+			MethodName: "UpdateLiveClassState",
+			Handler:    _LiveClassService_UpdateLiveClassState_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _LiveClassService_Heartbeat_Handler,
+		},
+		{
+			MethodName: "LeaveLiveClass",
+			Handler:    _LiveClassService_LeaveLiveClass_Handler,
+		},
+		{
+			MethodName: "GetAttendance",
+			Handler:    _LiveClassService_GetAttendance_Handler,
+		},
+		{
+			MethodName: "ExportAttendance",
+			Handler:    _LiveClassService_ExportAttendance_Handler,
+		},
+		{
+			MethodName: "ListParticipants",
+			Handler:    _LiveClassService_ListParticipants_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -404,6 +802,11 @@ var LiveClassService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _LiveClassService_GetAnswerStatistics_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchParticipants",
+			Handler:       _LiveClassService_WatchParticipants_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "live.proto",
 }
@@ -0,0 +1,203 @@
+package liveservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"LanshanClass1.3/global/database"
+	pb "LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// heartbeatTimeout 超过该时间未收到心跳视为离开直播课
+func heartbeatTimeout() time.Duration {
+	return database.Config.GetDuration("live.heartbeat_timeout")
+}
+
+// Heartbeat 记录用户仍在直播课中并续期在线状态，超时或主动离开后再次发送心跳会开始新的一段在线记录
+func (s *LiveClassServiceServer) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := liveClass(ctx, req.ClassId); err != nil {
+		return nil, err
+	}
+	if err := checkParticipant(ctx, principal, req.ClassId); err != nil {
+		return nil, err
+	}
+	if _, err := database.TouchAttendance(req.ClassId, principal.Username); err != nil {
+		log.Printf("TouchAttendance failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to record heartbeat")
+	}
+	touchPresence(ctx, req.ClassId, principal.Username)
+
+	interval := int32(heartbeatTimeout() / 3 / time.Second)
+	if interval < 1 {
+		interval = 1
+	}
+	return &pb.HeartbeatResponse{Status: "success", IntervalSeconds: interval}, nil
+}
+
+// LeaveLiveClass 记录用户离开直播课
+func (s *LiveClassServiceServer) LeaveLiveClass(ctx context.Context, req *pb.LeaveLiveClassRequest) (*pb.LeaveLiveClassResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id is required")
+	}
+	if err := database.LeaveAttendance(req.ClassId, principal.Username); err != nil {
+		log.Printf("LeaveAttendance failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to leave live class")
+	}
+	leavePresence(ctx, req.ClassId, principal.Username)
+	return &pb.LeaveLiveClassResponse{Status: "success"}, nil
+}
+
+// GetAttendance 查询直播课中每个学生的在线记录和累计时长，只有发起人和管理员可以查询
+func (s *LiveClassServiceServer) GetAttendance(ctx context.Context, req *pb.GetAttendanceRequest) (*pb.GetAttendanceResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id is required")
+	}
+	class, err := ownedClass(ctx, principal, req.ClassId, "")
+	if err != nil {
+		return nil, err
+	}
+	students, err := classAttendance(class, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetAttendanceResponse{ClassId: class.ID, ClassStatus: class.Status, Students: students}, nil
+}
+
+// ExportAttendance 将已结束直播课的出勤记录导出为 CSV，每段在线记录一行
+func (s *LiveClassServiceServer) ExportAttendance(ctx context.Context, req *pb.ExportAttendanceRequest) (*pb.ExportAttendanceResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id is required")
+	}
+	class, err := ownedClass(ctx, principal, req.ClassId, database.AuditAttendanceExport)
+	if err != nil {
+		return nil, err
+	}
+	if class.Status != database.ClassEnded && class.Status != database.ClassArchived {
+		return nil, status.Errorf(codes.FailedPrecondition, "直播课结束后才能导出出勤记录")
+	}
+	students, err := classAttendance(class, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	data, err := attendanceCSV(students)
+	if err != nil {
+		log.Printf("attendanceCSV failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to export attendance")
+	}
+
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditAttendanceExport, Target: class.ID, Outcome: database.AuditSuccess,
+	})
+	return &pb.ExportAttendanceResponse{
+		Filename:    "attendance-" + class.ID + ".csv",
+		ContentType: "text/csv; charset=utf-8",
+		Data:        data,
+	}, nil
+}
+
+// classAttendance 按学生汇总直播课的在线记录，发起人不计入
+func classAttendance(class *database.LiveClass, now time.Time) ([]*pb.StudentAttendance, error) {
+	records, err := database.ListAttendance(class.ID)
+	if err != nil {
+		log.Printf("ListAttendance failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load attendance")
+	}
+
+	var students []*pb.StudentAttendance
+	var usernames []string
+	for i := range records {
+		record := &records[i]
+		if record.Username == class.TeacherName {
+			continue
+		}
+		// 记录已按用户名排序，同一学生的记录相邻
+		if len(students) == 0 || students[len(students)-1].Username != record.Username {
+			students = append(students, &pb.StudentAttendance{Username: record.Username})
+			usernames = append(usernames, record.Username)
+		}
+		student := students[len(students)-1]
+		session := &pb.AttendanceSession{
+			JoinedAt:        record.JoinedAt.Unix(),
+			DurationSeconds: int64(record.Duration(now) / time.Second),
+		}
+		if record.LeftAt != nil {
+			session.LeftAt = record.LeftAt.Unix()
+		} else {
+			student.Online = true
+		}
+		student.Sessions = append(student.Sessions, session)
+		student.TotalSeconds += session.DurationSeconds
+	}
+
+	names := database.GetDisplayNames(usernames)
+	for _, student := range students {
+		student.DisplayName = names[student.Username]
+	}
+	return students, nil
+}
+
+// attendanceCSV 生成出勤记录 CSV，带 BOM 以便 Excel 正确识别中文
+func attendanceCSV(students []*pb.StudentAttendance) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\xef\xbb\xbf")
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"username", "display_name", "joined_at", "left_at", "duration_seconds", "total_seconds"}); err != nil {
+		return nil, err
+	}
+	for _, student := range students {
+		for _, session := range student.Sessions {
+			leftAt := ""
+			if session.LeftAt > 0 {
+				leftAt = time.Unix(session.LeftAt, 0).Format(time.RFC3339)
+			}
+			if err := w.Write([]string{
+				csvCell(student.Username),
+				csvCell(student.DisplayName),
+				time.Unix(session.JoinedAt, 0).Format(time.RFC3339),
+				leftAt,
+				strconv.FormatInt(session.DurationSeconds, 10),
+				strconv.FormatInt(student.TotalSeconds, 10),
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvCell 昵称由用户填写，以公式字符开头时加上单引号，避免在表格软件中被当作公式执行
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
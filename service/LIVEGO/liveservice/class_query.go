@@ -0,0 +1,196 @@
+package liveservice
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"LanshanClass1.3/global/database"
+	pb "LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 直播课列表每页条数
+const (
+	defaultClassPageSize = 20
+	maxClassPageSize     = 100
+)
+
+// classStatusFilters 列表接口的状态过滤条件对应的直播课状态
+var classStatusFilters = map[string][]string{
+	"live":      database.OpenClassStatuses,
+	"scheduled": {database.ClassScheduled},
+	"ended":     database.ClosedClassStatuses,
+	"lobby":     {database.ClassLobby},
+	"paused":    {database.ClassPaused},
+	"archived":  {database.ClassArchived},
+}
+
+// GetLiveClass 按 ID 查询直播课详情，推流地址只返回给发起人和管理员
+func (s *LiveClassServiceServer) GetLiveClass(ctx context.Context, req *pb.GetLiveClassRequest) (*pb.LiveClass, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id is required")
+	}
+
+	record, err := database.GetLiveClass(req.ClassId)
+	if errors.Is(err, database.ErrClassNotFound) {
+		return nil, status.Errorf(codes.NotFound, "live class not found")
+	}
+	if err != nil {
+		log.Printf("GetLiveClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live class")
+	}
+	classes, err := toLiveClasses(ctx, []database.LiveClass{*record})
+	if err != nil {
+		return nil, err
+	}
+
+	class := classes[0]
+	if record.TeacherName == principal.Username || principal.Role == database.RoleAdmin {
+		class.StreamUrl = record.StreamURL
+	}
+	return class, nil
+}
+
+// ListLiveClasses 查询直播课列表，按状态、发起人和关键字过滤，使用游标翻页
+func (s *LiveClassServiceServer) ListLiveClasses(ctx context.Context, req *pb.ListLiveClassesRequest) (*pb.ListLiveClassesResponse, error) {
+	filter := database.LiveClassFilter{
+		Teacher: req.Teacher,
+		Keyword: req.Keyword,
+		Sort:    req.Sort,
+		Limit:   int(req.PageSize),
+	}
+	if req.Status != "" {
+		statuses, ok := classStatusFilters[req.Status]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "status must be live, scheduled, ended, lobby, paused or archived")
+		}
+		filter.Statuses = statuses
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = database.ClassSortNewest
+	case database.ClassSortNewest, database.ClassSortOldest, database.ClassSortName:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "sort must be newest, oldest or name")
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultClassPageSize
+	}
+	if filter.Limit > maxClassPageSize {
+		filter.Limit = maxClassPageSize
+	}
+	if req.Cursor != "" {
+		cursor, err := decodeClassCursor(req.Cursor)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cursor")
+		}
+		filter.After = cursor
+	}
+
+	// 多查一条用于判断是否还有下一页
+	pageSize := filter.Limit
+	filter.Limit++
+	records, err := database.ListLiveClasses(filter)
+	if err != nil {
+		log.Printf("ListLiveClasses failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list live classes")
+	}
+
+	var nextCursor string
+	if len(records) > pageSize {
+		records = records[:pageSize]
+		nextCursor = encodeClassCursor(records[pageSize-1].CursorAfter())
+	}
+	classes, err := toLiveClasses(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListLiveClassesResponse{Classes: classes, NextCursor: nextCursor}, nil
+}
+
+// toLiveClasses 将直播课记录转换为 gRPC 消息，并批量填充发起人昵称、观看人数和开放中直播课的在线人数，不含推流地址
+func toLiveClasses(ctx context.Context, records []database.LiveClass) ([]*pb.LiveClass, error) {
+	ids := make([]string, 0, len(records))
+	openIDs := make([]string, 0, len(records))
+	teachers := make([]string, 0, len(records))
+	for i := range records {
+		ids = append(ids, records[i].ID)
+		teachers = append(teachers, records[i].TeacherName)
+		if database.IsOpenStatus(records[i].Status) {
+			openIDs = append(openIDs, records[i].ID)
+		}
+	}
+	viewers, err := database.CountViewers(ids)
+	if err != nil {
+		log.Printf("CountViewers failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live classes")
+	}
+	// 在线人数只用于展示，读取失败时返回 0
+	online, err := countOnline(ctx, openIDs)
+	if err != nil {
+		log.Printf("countOnline failed: %v", err)
+	}
+	names := database.GetDisplayNames(teachers)
+
+	classes := make([]*pb.LiveClass, 0, len(records))
+	for i := range records {
+		record := &records[i]
+		class := &pb.LiveClass{
+			ClassId:            record.ID,
+			ClassName:          record.Name,
+			TeacherName:        record.TeacherName,
+			TeacherDisplayName: names[record.TeacherName],
+			RoomName:           record.RoomName,
+			Status:             record.Status,
+			CreatedAt:          record.CreatedAt.Unix(),
+			ViewerCount:        viewers[record.ID],
+			OnlineCount:        online[record.ID],
+		}
+		if record.StartedAt != nil {
+			class.StartedAt = record.StartedAt.Unix()
+		}
+		if record.EndedAt != nil {
+			class.EndedAt = record.EndedAt.Unix()
+		}
+		if record.ScheduledStart != nil {
+			class.ScheduledStart = record.ScheduledStart.Unix()
+		}
+		if record.ScheduledEnd != nil {
+			class.ScheduledEnd = record.ScheduledEnd.Unix()
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// encodeClassCursor 将翻页位置编码为不透明的字符串
+func encodeClassCursor(cursor database.LiveClassCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeClassCursor 解析客户端传回的翻页位置
+func decodeClassCursor(s string) (*database.LiveClassCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor database.LiveClassCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("cursor without id")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,434 @@
+package liveservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"LanshanClass1.3/global/database"
+	pb "LanshanClass1.3/proto"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 开放中（候场、直播中、暂停）的直播课保存在 Redis 中，多个直播服务实例共享同一份状态；MySQL 保存完整记录，Redis 数据丢失时据此恢复
+const (
+	classKeyPrefix    = "live:class:"    // 直播课 ID -> 直播课信息（哈希）
+	activeClassesKey  = "live:classes"   // 开放中的直播课 ID 集合
+	roomKeyPrefix     = "live:room:"     // LiveGo 房间名 -> 占用该房间的直播课 ID
+	messagesSuffix    = ":messages"      // 消息（Stream）
+	questionsSuffix   = ":questions"     // 题目 ID -> 题目内容（哈希）
+	answersSuffix     = ":answers:"      // 后接题目 ID：答案 -> 提交次数（哈希）
+	kickedSuffix      = ":kicked"        // 被移出直播课的用户名集合
+	onlineSuffix      = ":online"        // 当前在线的用户名集合
+	presenceKeyPrefix = "live:presence:" // 后接直播课 ID 和用户名：在线状态，值为上线时间（毫秒），心跳超时后过期
+)
+
+// errClassNotLive 直播课不存在、尚未开放或已结束
+var errClassNotLive = errors.New("live class is not live")
+
+// classInfo 直播课基本信息
+type classInfo struct {
+	ID          string
+	Name        string
+	TeacherName string
+	RoomName    string
+	StreamURL   string
+	Status      string
+	CreatedAt   time.Time
+}
+
+// newClassInfo 根据 MySQL 中的记录生成直播课信息
+func newClassInfo(class *database.LiveClass) *classInfo {
+	return &classInfo{
+		ID:          class.ID,
+		Name:        class.Name,
+		TeacherName: class.TeacherName,
+		RoomName:    class.RoomName,
+		StreamURL:   class.StreamURL,
+		Status:      class.Status,
+		CreatedAt:   class.CreatedAt,
+	}
+}
+
+// chatMessage 直播间中的一条消息，额外记录发送者用户名，账号删除时据此替换为化名
+type chatMessage struct {
+	Username string
+	*pb.Message
+}
+
+func classKey(id string) string     { return classKeyPrefix + id }
+func roomKey(room string) string    { return roomKeyPrefix + room }
+func messagesKey(id string) string  { return classKeyPrefix + id + messagesSuffix }
+func questionsKey(id string) string { return classKeyPrefix + id + questionsSuffix }
+func kickedKey(id string) string    { return classKeyPrefix + id + kickedSuffix }
+func onlineKey(id string) string    { return classKeyPrefix + id + onlineSuffix }
+func presenceKey(id, username string) string {
+	return presenceKeyPrefix + id + ":" + username
+}
+func answersKey(id, questionID string) string {
+	return classKeyPrefix + id + answersSuffix + questionID
+}
+
+// writeClass 在管道中写入直播课信息，并根据 MySQL 中的记录写入消息、题目、答案统计和移出名单
+func writeClass(ctx context.Context, pipe redis.Pipeliner, info *classInfo, data *database.LiveClassData) {
+	pipe.HSet(ctx, classKey(info.ID), map[string]interface{}{
+		"name":         info.Name,
+		"teacher_name": info.TeacherName,
+		"room_name":    info.RoomName,
+		"stream_url":   info.StreamURL,
+		"status":       info.Status,
+		"created_at":   info.CreatedAt.Unix(),
+	})
+	pipe.SAdd(ctx, activeClassesKey, info.ID)
+	pipe.Set(ctx, roomKey(info.RoomName), info.ID, 0)
+	if data == nil {
+		return
+	}
+	pipe.Del(ctx, messagesKey(info.ID), questionsKey(info.ID), kickedKey(info.ID))
+
+	// 消息 ID 按发送时间生成，保证恢复后按时间顺序排列
+	var lastMS, seq int64
+	for _, msg := range data.Messages {
+		ms := msg.CreatedAt.UnixMilli()
+		if ms <= lastMS {
+			ms, seq = lastMS, seq+1
+		} else {
+			seq = 0
+		}
+		lastMS = ms
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: messagesKey(info.ID),
+			ID:     fmt.Sprintf("%d-%d", ms, seq),
+			Values: messageValues(&chatMessage{
+				Username: msg.Username,
+				Message: &pb.Message{
+					SenderName:     msg.SenderName,
+					MessageContent: msg.Content,
+					Timestamp:      msg.CreatedAt.Unix(),
+				},
+			}),
+		})
+	}
+	for _, q := range data.Questions {
+		pipe.HSet(ctx, questionsKey(info.ID), q.ID, q.Text)
+	}
+	for _, a := range data.Answers {
+		pipe.HSet(ctx, answersKey(info.ID, a.QuestionID), a.Answer, a.Count)
+	}
+	for _, username := range data.Kicked {
+		pipe.SAdd(ctx, kickedKey(info.ID), username)
+	}
+}
+
+// saveClass 保存新开放的直播课
+func saveClass(ctx context.Context, info *classInfo) error {
+	pipe := database.RedisClient.TxPipeline()
+	writeClass(ctx, pipe, info, nil)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save live class: %w", err)
+	}
+	return nil
+}
+
+// restoreClass 在 Redis 中没有该直播课时根据 MySQL 中的记录恢复，多个实例同时恢复时只有一个会写入
+func restoreClass(ctx context.Context, class *database.LiveClass) error {
+	info := newClassInfo(class)
+	err := database.RedisClient.Watch(ctx, func(tx *redis.Tx) error {
+		n, err := tx.Exists(ctx, classKey(class.ID)).Result()
+		if err != nil || n > 0 {
+			return err
+		}
+		data, err := database.LoadLiveClassData(class.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			writeClass(ctx, pipe, info, data)
+			return nil
+		})
+		return err
+	}, classKey(class.ID))
+	if err != nil && !errors.Is(err, redis.TxFailedErr) {
+		return fmt.Errorf("failed to restore live class %s: %w", class.ID, err)
+	}
+	return nil
+}
+
+// getClass 查询开放中的直播课，其他情况返回 errClassNotLive
+func getClass(ctx context.Context, id string) (*classInfo, error) {
+	fields, err := database.RedisClient.HGetAll(ctx, classKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load live class: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, errClassNotLive
+	}
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	return &classInfo{
+		ID:          id,
+		Name:        fields["name"],
+		TeacherName: fields["teacher_name"],
+		RoomName:    fields["room_name"],
+		StreamURL:   fields["stream_url"],
+		Status:      fields["status"],
+		CreatedAt:   time.Unix(createdAt, 0),
+	}, nil
+}
+
+// setStatusScript 只在直播课仍在 Redis 中时更新状态，避免与结束直播课并发时重新写入半个哈希
+var setStatusScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "status", ARGV[1])
+end
+return 0
+`)
+
+// setClassStatus 更新开放中的直播课的状态
+func setClassStatus(ctx context.Context, id, classStatus string) error {
+	if err := setStatusScript.Run(ctx, database.RedisClient, []string{classKey(id)}, classStatus).Err(); err != nil {
+		return fmt.Errorf("failed to set class status: %w", err)
+	}
+	return nil
+}
+
+// listLiveClassIDs 查询开放中的直播课 ID
+func listLiveClassIDs(ctx context.Context) ([]string, error) {
+	ids, err := database.RedisClient.SMembers(ctx, activeClassesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list live classes: %w", err)
+	}
+	return ids, nil
+}
+
+// releaseRoomScript 只在房间仍被该直播课占用时释放，避免误删其他直播课的占用
+var releaseRoomScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// claimRoom 为直播课占用 LiveGo 房间，房间已被开放中的直播课占用时返回 false
+func claimRoom(ctx context.Context, room, id string) (bool, error) {
+	ok, err := database.RedisClient.SetNX(ctx, roomKey(room), id, 0).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim room: %w", err)
+	}
+	return ok, nil
+}
+
+// releaseRoom 释放直播课占用的房间
+func releaseRoom(ctx context.Context, room, id string) error {
+	if err := releaseRoomScript.Run(ctx, database.RedisClient, []string{roomKey(room)}, id).Err(); err != nil {
+		return fmt.Errorf("failed to release room: %w", err)
+	}
+	return nil
+}
+
+// removeClass 删除已结束的直播课在 Redis 中的全部数据，并释放其占用的房间
+func removeClass(ctx context.Context, id string) error {
+	questionIDs, err := database.RedisClient.HKeys(ctx, questionsKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list questions: %w", err)
+	}
+	room, err := database.RedisClient.HGet(ctx, classKey(id), "room_name").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to load room: %w", err)
+	}
+	keys := []string{classKey(id), messagesKey(id), questionsKey(id), kickedKey(id), onlineKey(id)}
+	for _, questionID := range questionIDs {
+		keys = append(keys, answersKey(id, questionID))
+	}
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, activeClassesKey, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove live class: %w", err)
+	}
+	if room != "" {
+		return releaseRoom(ctx, room, id)
+	}
+	return nil
+}
+
+// isKicked 判断用户是否已被移出直播课
+func isKicked(ctx context.Context, id, username string) (bool, error) {
+	kicked, err := database.RedisClient.SIsMember(ctx, kickedKey(id), username).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check kicked users: %w", err)
+	}
+	return kicked, nil
+}
+
+// kickUser 将用户加入移出名单
+func kickUser(ctx context.Context, id, username string) error {
+	if err := database.RedisClient.SAdd(ctx, kickedKey(id), username).Err(); err != nil {
+		return fmt.Errorf("failed to kick user: %w", err)
+	}
+	return nil
+}
+
+// messageValues 将消息转换为 Stream 字段
+func messageValues(msg *chatMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"username":    msg.Username,
+		"sender_name": msg.SenderName,
+		"content":     msg.MessageContent,
+		"ts":          msg.Timestamp,
+	}
+}
+
+// parseMessage 将 Stream 中的一条记录转换为消息
+func parseMessage(entry redis.XMessage) *chatMessage {
+	str := func(key string) string {
+		v, _ := entry.Values[key].(string)
+		return v
+	}
+	ts, _ := strconv.ParseInt(str("ts"), 10, 64)
+	return &chatMessage{
+		Username: str("username"),
+		Message: &pb.Message{
+			SenderName:     str("sender_name"),
+			MessageContent: str("content"),
+			Timestamp:      ts,
+		},
+	}
+}
+
+// appendMessage 追加一条消息
+func appendMessage(ctx context.Context, id string, msg *chatMessage) error {
+	err := database.RedisClient.XAdd(ctx, &redis.XAddArgs{Stream: messagesKey(id), Values: messageValues(msg)}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to append message: %w", err)
+	}
+	return nil
+}
+
+// readMessages 读取时间戳晚于 after（Unix 秒）的消息
+// Stream ID 由 Redis 服务器的时钟生成，而消息时间戳来自直播服务实例，两者不一定一致，因此读取全部消息后按时间戳过滤
+func readMessages(ctx context.Context, id string, after int64) ([]*pb.Message, error) {
+	entries, err := database.RedisClient.XRange(ctx, messagesKey(id), "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	messages := make([]*pb.Message, 0, len(entries))
+	for _, entry := range entries {
+		if msg := parseMessage(entry); msg.Timestamp > after {
+			messages = append(messages, msg.Message)
+		}
+	}
+	return messages, nil
+}
+
+// addQuestion 保存题目
+func addQuestion(ctx context.Context, id string, question *pb.Question) error {
+	if err := database.RedisClient.HSet(ctx, questionsKey(id), question.QuestionId, question.QuestionText).Err(); err != nil {
+		return fmt.Errorf("failed to save question: %w", err)
+	}
+	return nil
+}
+
+// questionExists 判断题目是否存在
+func questionExists(ctx context.Context, id, questionID string) (bool, error) {
+	ok, err := database.RedisClient.HExists(ctx, questionsKey(id), questionID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check question: %w", err)
+	}
+	return ok, nil
+}
+
+// incrAnswer 原子地增加某个答案的提交次数
+func incrAnswer(ctx context.Context, id, questionID, answer string) error {
+	if err := database.RedisClient.HIncrBy(ctx, answersKey(id, questionID), answer, 1).Err(); err != nil {
+		return fmt.Errorf("failed to count answer: %w", err)
+	}
+	return nil
+}
+
+// loadAnswerCounts 查询某道题的答案统计
+func loadAnswerCounts(ctx context.Context, id, questionID string) (map[string]int32, error) {
+	fields, err := database.RedisClient.HGetAll(ctx, answersKey(id, questionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load answer counts: %w", err)
+	}
+	counts := make(map[string]int32, len(fields))
+	for answer, v := range fields {
+		n, _ := strconv.ParseInt(v, 10, 32)
+		counts[answer] = int32(n)
+	}
+	return counts, nil
+}
+
+// renameUser 将进行中的直播课里某个用户的用户名和展示名替换为化名
+// Stream 中的记录不能修改，按原 ID 重写整个 Stream；WATCH 保证重写期间新发送的消息不会丢失
+func renameUser(ctx context.Context, username, pseudonym string) error {
+	ids, err := listLiveClassIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		key := messagesKey(id)
+		rewrite := func(tx *redis.Tx) error {
+			entries, err := tx.XRange(ctx, key, "-", "+").Result()
+			if err != nil {
+				return err
+			}
+			changed := false
+			for _, entry := range entries {
+				if entry.Values["username"] == username {
+					changed = true
+					break
+				}
+			}
+			teacher, err := tx.HGet(ctx, classKey(id), "teacher_name").Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			kicked, err := tx.SIsMember(ctx, kickedKey(id), username).Result()
+			if err != nil {
+				return err
+			}
+			if !changed && teacher != username && !kicked {
+				return nil
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if changed {
+					pipe.Del(ctx, key)
+					for _, entry := range entries {
+						msg := parseMessage(entry)
+						if msg.Username == username {
+							msg.Username = pseudonym
+							msg.SenderName = pseudonym
+						}
+						pipe.XAdd(ctx, &redis.XAddArgs{Stream: key, ID: entry.ID, Values: messageValues(msg)})
+					}
+				}
+				if teacher == username {
+					pipe.HSet(ctx, classKey(id), "teacher_name", pseudonym)
+				}
+				if kicked {
+					pipe.SRem(ctx, kickedKey(id), username)
+					pipe.SAdd(ctx, kickedKey(id), pseudonym)
+				}
+				return nil
+			})
+			return err
+		}
+
+		for attempt := 0; ; attempt++ {
+			err := database.RedisClient.Watch(ctx, rewrite, key, classKey(id), kickedKey(id))
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, redis.TxFailedErr) || attempt >= 10 {
+				return fmt.Errorf("failed to rename user in class %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
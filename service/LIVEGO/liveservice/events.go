@@ -0,0 +1,130 @@
+package liveservice
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"LanshanClass1.3/global/database"
+)
+
+// liveEventsChannel 直播课事件的 Redis 频道，每个直播服务实例都订阅并分发给本实例上的流式请求
+const liveEventsChannel = "live:events"
+
+// 直播课事件类型
+const (
+	eventMessage  = "message"  // 新消息
+	eventQuestion = "question" // 发布题目
+	eventAnswer   = "answer"   // 提交答案
+	eventKick     = "kick"     // 移出用户
+	eventJoin     = "join"     // 用户上线
+	eventLeave    = "leave"    // 用户离开或心跳超时
+	eventState    = "state"    // 直播课状态变化（候场、直播中、暂停）
+	eventEnded    = "ended"    // 直播课结束
+	eventResync   = "resync"   // 只在本实例内使用：关注者处理不过来，之前的事件已被丢弃，需要重新读取最新状态
+)
+
+// liveEvent 直播课事件
+type liveEvent struct {
+	Type       string `json:"type"`
+	ClassID    string `json:"class_id"`
+	QuestionID string `json:"question_id,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Since      int64  `json:"since,omitempty"` // 用户上线时间（毫秒）
+}
+
+// publishEvent 发布直播课事件，失败只记录日志，流式请求在超时前仍会收到后续事件
+func publishEvent(ctx context.Context, ev liveEvent) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("invalid live event: %v", err)
+		return
+	}
+	if err := database.RedisClient.Publish(ctx, liveEventsChannel, payload).Err(); err != nil {
+		log.Printf("failed to publish live event: %v", err)
+	}
+}
+
+// eventHub 将订阅到的事件分发给本实例上关注对应直播课的请求
+type eventHub struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan liveEvent]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{watchers: make(map[string]map[chan liveEvent]struct{})}
+}
+
+// watch 关注某个直播课的事件，调用返回的函数取消关注
+func (h *eventHub) watch(classID string) (<-chan liveEvent, func()) {
+	ch := make(chan liveEvent, 16)
+	h.mu.Lock()
+	if h.watchers[classID] == nil {
+		h.watchers[classID] = make(map[chan liveEvent]struct{})
+	}
+	h.watchers[classID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.watchers[classID], ch)
+		if len(h.watchers[classID]) == 0 {
+			delete(h.watchers, classID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// dispatch 分发事件，关注者处理不过来时清空其积压的事件并改为发送一个 resync 事件，
+// 关注者收到后重新读取最新状态，而不是在丢失部分增量的情况下继续推送
+func (h *eventHub) dispatch(ev liveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.watchers[ev.ClassID] {
+		select {
+		case ch <- ev:
+			continue
+		default:
+		}
+		resync := liveEvent{Type: eventResync, ClassID: ev.ClassID}
+	drain:
+		for {
+			select {
+			case old := <-ch:
+				// 直播课已结束时不需要重新读取，保留结束事件
+				if old.Type == eventEnded {
+					resync = old
+				}
+			default:
+				break drain
+			}
+		}
+		ch <- resync
+		ch <- ev
+	}
+}
+
+// RunEventFanout 订阅直播课事件并分发给本实例上的流式请求，在 ctx 结束前持续运行
+func (s *LiveClassServiceServer) RunEventFanout(ctx context.Context) {
+	sub := database.RedisClient.Subscribe(ctx, liveEventsChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var ev liveEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				log.Printf("invalid live event: %v", err)
+				continue
+			}
+			s.hub.dispatch(ev)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package liveservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"LanshanClass1.3/global/database"
+	pb "LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// schedulerActor 调度任务切换直播课状态时记录在审计日志中的操作者
+const schedulerActor = "scheduler"
+
+// livegoClient 调用 LiveGo 控制接口的 HTTP 客户端，调度任务也会调用，必须设置超时，避免 LiveGo 无响应时阻塞整轮调度
+var livegoClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchStreamKey 调用 LiveGo 服务器获取房间的推流密钥
+func fetchStreamKey(ctx context.Context, room string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://localhost:8090/control/get?room="+url.QueryEscape(room), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build LiveGo request: %w", err)
+	}
+	livegoResp, err := livegoClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call LiveGo server: %w", err)
+	}
+	defer livegoResp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(livegoResp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode LiveGo response: %w", err)
+	}
+	streamKey, ok := result["data"].(string)
+	if !ok {
+		return "", fmt.Errorf("failed to extract stream key from response: %+v", result)
+	}
+	return streamKey, nil
+}
+
+// streamURL 拼接完整的推流地址
+func streamURL(streamKey string) string {
+	return "rtmp://localhost:8090/live/" + streamKey
+}
+
+// claimStream 为直播课占用 LiveGo 房间并获取推流密钥，失败时释放房间
+// 同一个 LiveGo 房间同时只能用于一个直播课，否则两个直播课会共用同一路推流
+func claimStream(ctx context.Context, classID, room string) (string, error) {
+	claimed, err := claimRoom(ctx, room, classID)
+	if err != nil {
+		log.Printf("claimRoom failed: %v", err)
+		return "", status.Errorf(codes.Internal, "failed to claim room")
+	}
+	if !claimed {
+		return "", status.Errorf(codes.AlreadyExists, "room %s is already in use by another live class", room)
+	}
+	streamKey, err := fetchStreamKey(ctx, room)
+	if err != nil {
+		log.Printf("fetchStreamKey failed: %v", err)
+		releaseRoomAsync(room, classID)
+		return "", status.Errorf(codes.Unavailable, "failed to get stream key from LiveGo")
+	}
+	return streamKey, nil
+}
+
+// releaseRoomAsync 在请求失败后释放房间，不受请求上下文取消的影响
+func releaseRoomAsync(room, classID string) {
+	if err := releaseRoom(context.Background(), room, classID); err != nil {
+		log.Printf("releaseRoom failed: %v", err)
+	}
+}
+
+// changeClassState 将直播课切换到 to 状态，并同步 Redis 中的状态、通知各实例
+// 从已预约切换到开放状态时占用房间并获取推流密钥；切换到已结束时从 Redis 中移除直播课
+func changeClassState(ctx context.Context, class *database.LiveClass, to string) error {
+	from := class.Status
+	if !database.CanTransition(from, to) {
+		return status.Errorf(codes.FailedPrecondition, "cannot change live class state from %s to %s", from, to)
+	}
+
+	var updates map[string]interface{}
+	opening := from == database.ClassScheduled && database.IsOpenStatus(to)
+	if opening {
+		streamKey, err := claimStream(ctx, class.ID, class.RoomName)
+		if err != nil {
+			return err
+		}
+		updates = map[string]interface{}{"stream_url": streamURL(streamKey)}
+	}
+
+	if err := database.TransitionLiveClass(class, to, updates); err != nil {
+		if opening {
+			releaseRoomAsync(class.RoomName, class.ID)
+		}
+		if errors.Is(err, database.ErrInvalidTransition) {
+			return status.Errorf(codes.FailedPrecondition, "live class state has changed, please retry")
+		}
+		log.Printf("TransitionLiveClass failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to change live class state")
+	}
+
+	// 直播课结束时所有在线用户视为离开
+	if to == database.ClassEnded && class.EndedAt != nil {
+		if err := database.CloseClassAttendance(class.ID, *class.EndedAt); err != nil {
+			log.Printf("CloseClassAttendance failed: %v", err)
+		}
+	}
+
+	// MySQL 已更新，Redis 写入失败时由 liveClass 或服务启动时根据 MySQL 恢复
+	switch {
+	case opening:
+		if err := saveClass(ctx, newClassInfo(class)); err != nil {
+			log.Printf("saveClass failed: %v", err)
+		}
+		publishEvent(ctx, liveEvent{Type: eventState, ClassID: class.ID, Status: to})
+	case database.IsOpenStatus(to):
+		if err := setClassStatus(ctx, class.ID, to); err != nil {
+			log.Printf("setClassStatus failed: %v", err)
+		}
+		publishEvent(ctx, liveEvent{Type: eventState, ClassID: class.ID, Status: to})
+	case to == database.ClassEnded && database.IsOpenStatus(from):
+		if err := removeClass(ctx, class.ID); err != nil {
+			log.Printf("removeClass failed: %v", err)
+		}
+		publishEvent(ctx, liveEvent{Type: eventEnded, ClassID: class.ID})
+	}
+	log.Printf("Live class %s: %s -> %s", class.ID, from, to)
+	return nil
+}
+
+// ownedClass 查询直播课并检查请求用户是否是发起人（管理员除外），action 不为空时记录拒绝的审计日志
+func ownedClass(ctx context.Context, principal *utils.Principal, classID, action string) (*database.LiveClass, error) {
+	class, err := database.GetLiveClass(classID)
+	if errors.Is(err, database.ErrClassNotFound) {
+		return nil, status.Errorf(codes.NotFound, "live class not found")
+	}
+	if err != nil {
+		log.Printf("GetLiveClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live class")
+	}
+	if class.TeacherName != principal.Username && principal.Role != database.RoleAdmin {
+		log.Printf("权限不足: 创建者=%s, 请求者=%s", class.TeacherName, principal.Username)
+		if action != "" {
+			utils.RecordAudit(ctx, utils.AuditEntry{
+				Action: action, Target: classID, Outcome: database.AuditDenied,
+				Detail: "not the class initiator",
+			})
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "only the class initiator can manage the live class")
+	}
+	return class, nil
+}
+
+// UpdateLiveClassState 切换直播课状态，只有发起人或管理员可以操作
+func (s *LiveClassServiceServer) UpdateLiveClassState(ctx context.Context, req *pb.UpdateLiveClassStateRequest) (*pb.LiveClass, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" || req.State == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id and state are required")
+	}
+
+	class, err := ownedClass(ctx, principal, req.ClassId, database.AuditClassState)
+	if err != nil {
+		return nil, err
+	}
+	from := class.Status
+	if err := changeClassState(ctx, class, req.State); err != nil {
+		return nil, err
+	}
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditClassState, Target: class.ID, Outcome: database.AuditSuccess,
+		Detail: "from=" + from + " to=" + class.Status,
+	})
+
+	classes, err := toLiveClasses(ctx, []database.LiveClass{*class})
+	if err != nil {
+		return nil, err
+	}
+	classes[0].StreamUrl = class.StreamURL
+	return classes[0], nil
+}
+
+// RunScheduler 定期开放到达预约时间的直播课、结束超过预约结束时间的直播课、归档结束已久的直播课，
+// 并将超时未发送心跳的用户记为离开，在 ctx 结束前持续运行
+// 多个实例同时运行时由 MySQL 中的条件更新保证每次切换只执行一次
+func (s *LiveClassServiceServer) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(database.Config.GetDuration("live.scheduler_interval"))
+	defer ticker.Stop()
+	for {
+		runSchedule(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSchedule 执行一轮调度
+func runSchedule(ctx context.Context, now time.Time) {
+	// 超时未发送心跳的用户视为已离开，离开时间为最后一次心跳
+	if n, err := database.CloseStaleAttendance(now.Add(-heartbeatTimeout())); err != nil {
+		log.Printf("CloseStaleAttendance failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Closed %d stale attendance records", n)
+	}
+
+	// 到达预约结束时间的直播课直接结束，包括从未开放的
+	scheduleTransitions(ctx, append([]string{database.ClassScheduled}, database.OpenClassStatuses...),
+		"scheduled_end", now, database.ClassEnded)
+	scheduleTransitions(ctx, []string{database.ClassScheduled},
+		"scheduled_start", now.Add(database.Config.GetDuration("live.lobby_lead")), database.ClassLobby)
+	scheduleTransitions(ctx, []string{database.ClassEnded},
+		"ended_at", now.Add(-database.Config.GetDuration("live.archive_after")), database.ClassArchived)
+}
+
+// scheduleTransitions 将 column 列的时间不晚于 before 的直播课切换到 to 状态
+func scheduleTransitions(ctx context.Context, statuses []string, column string, before time.Time, to string) {
+	classes, err := database.ListClassesDue(statuses, column, before)
+	if err != nil {
+		log.Printf("ListClassesDue failed: %v", err)
+		return
+	}
+	for i := range classes {
+		class := &classes[i]
+		from := class.Status
+		if err := changeClassState(ctx, class, to); err != nil {
+			// 其他实例已经处理过该直播课
+			if latest, lerr := database.GetLiveClass(class.ID); lerr == nil && latest.Status != from {
+				continue
+			}
+			log.Printf("Scheduler failed to move live class %s to %s: %v", class.ID, to, err)
+			if from == database.ClassScheduled && database.IsOpenStatus(to) && !canRetryOpen(class, err) {
+				abandonScheduledClass(ctx, class, err)
+			}
+			continue
+		}
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Actor: schedulerActor, Action: database.AuditClassState, Target: class.ID,
+			Outcome: database.AuditSuccess, Detail: "from=" + from + " to=" + to,
+		})
+	}
+}
+
+// canRetryOpen 判断调度任务开放预约的直播课失败后是否应在下一轮重试
+// 房间被占用时不会自行恢复；其他错误（如 LiveGo 暂时不可用）在候场期间重试，到达预约开始时间后放弃
+func canRetryOpen(class *database.LiveClass, err error) bool {
+	if status.Code(err) == codes.AlreadyExists {
+		return false
+	}
+	return class.ScheduledStart != nil && class.ScheduledStart.After(time.Now())
+}
+
+// abandonScheduledClass 无法开放预约的直播课时将其结束并记录审计日志，避免调度任务无限重试
+func abandonScheduledClass(ctx context.Context, class *database.LiveClass, cause error) {
+	from := class.Status
+	if err := changeClassState(ctx, class, database.ClassEnded); err != nil {
+		log.Printf("Scheduler failed to end live class %s: %v", class.ID, err)
+		return
+	}
+	log.Printf("Scheduler gave up opening live class %s: %v", class.ID, cause)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Actor: schedulerActor, Action: database.AuditClassState, Target: class.ID,
+		Outcome: database.AuditFailure,
+		Detail:  "from=" + from + " to=" + database.ClassEnded + " reason=" + status.Convert(cause).Message(),
+	})
+}
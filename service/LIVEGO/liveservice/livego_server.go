@@ -2,256 +2,437 @@ package liveservice
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"log"
-	"net/http"
 	"reflect"
-	"strings"
-	"sync"
 	"time"
+	"unicode/utf8"
 
+	"LanshanClass1.3/global/database"
 	pb "LanshanClass1.3/proto"
 	"LanshanClass1.3/utils"
 
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-// LiveClassServiceServer 定义服务
+// LiveClassServiceServer 定义服务，直播课状态保存在 Redis 中，多个实例可以同时运行
 type LiveClassServiceServer struct {
-	streams map[string]*LiveClass
-	mu      sync.Mutex
+	hub *eventHub // 本实例上等待直播课事件的流式请求
 	pb.UnimplementedLiveClassServiceServer
 }
 
-// LiveClass 定义直播间结构
-type LiveClass struct {
-	TeacherName string                      // 直播间发起人的用户名
-	StreamURL   string                      // 推流地址
-	Messages    []*pb.Message               // 存储的消息列表
-	mu          sync.Mutex                  // 保护消息列表的互斥锁
-	Questions   map[string]*pb.Question     // 题目列表
-	Answers     map[string]map[string]int32 // 答案统计
-}
-
 // NewLiveClassServiceServer 初始化服务
 func NewLiveClassServiceServer() *LiveClassServiceServer {
 	return &LiveClassServiceServer{
-		streams: make(map[string]*LiveClass),
+		hub: newEventHub(),
 	}
 }
 
-// authenticateToken 验证 JWT Token 并返回用户名
-func (s *LiveClassServiceServer) authenticateToken(ctx context.Context) (string, error) {
-	// 获取元数据
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		log.Println("Error: Missing metadata in request")
-		return "", status.Errorf(codes.Unauthenticated, "missing metadata")
+// LoadActiveClasses 让 Redis 与 MySQL 中开放中的直播课保持一致：Redis 数据丢失时从 MySQL 恢复，已结束的直播课从 Redis 中移除
+func (s *LiveClassServiceServer) LoadActiveClasses() error {
+	ctx := context.Background()
+	classes, err := database.ListOpenLiveClasses()
+	if err != nil {
+		return err
+	}
+	active := make(map[string]bool, len(classes))
+	for i := range classes {
+		active[classes[i].ID] = true
+		if err := restoreClass(ctx, &classes[i]); err != nil {
+			return err
+		}
 	}
 
-	// 详细记录元数据
-	log.Printf("Received metadata: %+v", md)
-
-	// 获取授权头
-	authHeaders := md.Get("authorization")
-	if len(authHeaders) == 0 {
-		log.Println("Error: No authorization headers found")
-		return "", status.Errorf(codes.Unauthenticated, "missing authorization header")
+	ids, err := listLiveClassIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !active[id] {
+			if err := removeClass(ctx, id); err != nil {
+				return err
+			}
+		}
 	}
+	log.Printf("Loaded %d open live classes", len(classes))
+	return nil
+}
 
-	authHeader := authHeaders[0]
-	log.Printf("Authorization header: %s", authHeader)
+// MethodPolicies 声明每个 RPC 允许调用的角色（管理员拥有全部权限），由认证拦截器统一执行
+var MethodPolicies = map[string]utils.MethodPolicy{
+	pb.LiveClassService_CreateLiveClass_FullMethodName:      {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_JoinLiveClass_FullMethodName:        {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_SendMessage_FullMethodName:          {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_EndLiveClass_FullMethodName:         {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_UpdateLiveClassState_FullMethodName: {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_PublishQuestion_FullMethodName:      {Roles: []string{database.RoleTeacher}, Scopes: []string{database.ScopeQuestionsWrite}},
+	pb.LiveClassService_SubmitAnswer_FullMethodName:         {Roles: []string{database.RoleStudent}},
+	pb.LiveClassService_GetMessages_FullMethodName:          {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_GetAnswerStatistics_FullMethodName:  {Roles: []string{database.RoleTeacher}, Scopes: []string{database.ScopeStatsRead}},
+	pb.LiveClassService_KickUser_FullMethodName:             {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_GetLiveClass_FullMethodName:         {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_ListLiveClasses_FullMethodName:      {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_Heartbeat_FullMethodName:            {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_LeaveLiveClass_FullMethodName:       {Roles: []string{database.RoleStudent, database.RoleTeacher}},
+	pb.LiveClassService_GetAttendance_FullMethodName:        {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_ListParticipants_FullMethodName:     {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_WatchParticipants_FullMethodName:    {Roles: []string{database.RoleTeacher}},
+	pb.LiveClassService_ExportAttendance_FullMethodName:     {Roles: []string{database.RoleTeacher}},
+}
 
-	// 解析授权头
-	parts := strings.Fields(authHeader) // 使用Fields而不是Split处理多个空格
-	if len(parts) < 2 || strings.ToLower(parts[0]) != "bearer" {
-		log.Printf("Invalid authorization header format: %s", authHeader)
-		return "", status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+// liveClass 查询开放中的直播课，尚未开放或已结束时返回 FailedPrecondition
+func liveClass(ctx context.Context, classID string) (*classInfo, error) {
+	info, err := getClass(ctx, classID)
+	if errors.Is(err, errClassNotLive) {
+		return notLiveClass(ctx, classID)
+	}
+	if err != nil {
+		log.Printf("getClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live class")
 	}
+	return info, nil
+}
 
-	// 获取token（处理多个空格情况）
-	token := strings.Join(parts[1:], " ")
+// notLiveClass 根据 MySQL 中的状态说明直播课为什么不在 Redis 中；直播课开放但 Redis 中缺失时从 MySQL 恢复
+func notLiveClass(ctx context.Context, classID string) (*classInfo, error) {
+	class, err := database.GetLiveClass(classID)
+	if errors.Is(err, database.ErrClassNotFound) {
+		return nil, status.Errorf(codes.NotFound, "live class not found")
+	}
+	if err != nil {
+		log.Printf("GetLiveClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live class")
+	}
+	switch {
+	case class.Status == database.ClassScheduled:
+		return nil, status.Errorf(codes.FailedPrecondition, "直播课尚未开始")
+	case !database.IsOpenStatus(class.Status):
+		return nil, status.Errorf(codes.FailedPrecondition, "直播课已结束")
+	}
+	if err := restoreClass(ctx, class); err != nil {
+		log.Printf("restoreClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load live class")
+	}
+	return newClassInfo(class), nil
+}
 
-	claims := &utils.Claims{}
-	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return utils.JwtSecret, nil
-	})
+// requireLive 发布题目和答题只能在直播中进行，候场和暂停时拒绝
+func requireLive(class *classInfo) error {
+	if class.Status != database.ClassLive {
+		return status.Errorf(codes.FailedPrecondition, "直播课当前状态为 %s，只能在直播中进行该操作", class.Status)
+	}
+	return nil
+}
 
+// checkNotKicked 被移出直播课的用户不能再加入、发言或答题
+func checkNotKicked(ctx context.Context, classID, username string) error {
+	kicked, err := isKicked(ctx, classID, username)
 	if err != nil {
-		log.Printf("Token validation failed: %v", err)
-		return "", status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		log.Printf("isKicked failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load live class")
 	}
-
-	log.Printf("User authenticated: %s", claims.Username)
-	return claims.Username, nil
+	if kicked {
+		return status.Errorf(codes.PermissionDenied, "你已被移出该直播课")
+	}
+	return nil
 }
 
-// CreateLiveClass 创建直播课
-func (s *LiveClassServiceServer) CreateLiveClass(ctx context.Context, req *pb.CreateLiveClassRequest) (*pb.CreateLiveClassResponse, error) {
-	// 从认证信息中获取教师用户名
-	teacherName, err := s.authenticateToken(ctx)
+// checkParticipant 检查用户可以参与直播课：没有被移出，且直播课仅限报名用户参与时已经报名
+// 加入、发言、读取消息、答题和心跳都经过该检查，不能绕过加入直接调用其他接口；发起人、其服务账号和管理员不受报名限制
+func checkParticipant(ctx context.Context, principal *utils.Principal, classID string) error {
+	if err := checkNotKicked(ctx, classID, principal.Username); err != nil {
+		return err
+	}
+	if principal.Role == database.RoleAdmin {
+		return nil
+	}
+	class, err := database.GetLiveClass(classID)
+	if errors.Is(err, database.ErrClassNotFound) {
+		return status.Errorf(codes.NotFound, "live class not found")
+	}
 	if err != nil {
-		log.Printf("Authentication failed in CreateLiveClass: %v", err)
-		return nil, err
+		log.Printf("GetLiveClass failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load live class")
+	}
+	if !class.InviteOnly || principal.ActsFor(class.TeacherName) {
+		return nil
+	}
+	enrolled, err := database.IsEnrolled(classID, principal.Username)
+	if err != nil {
+		log.Printf("IsEnrolled failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load live class")
 	}
+	if !enrolled {
+		return status.Errorf(codes.PermissionDenied, "该直播课只允许通过邀请码报名的用户参与")
+	}
+	return nil
+}
 
-	log.Printf("Creating live class for teacher: %s", teacherName)
+// archivedClass 查询已结束的直播课及其在 MySQL 中保存的数据，供教师回看
+func archivedClass(classID string) (*database.LiveClass, *database.LiveClassData, error) {
+	class, err := database.GetLiveClass(classID)
+	if errors.Is(err, database.ErrClassNotFound) || (err == nil && class.Status != database.ClassEnded && class.Status != database.ClassArchived) {
+		return nil, nil, status.Errorf(codes.NotFound, "live class not found")
+	}
+	if err == nil {
+		var data *database.LiveClassData
+		if data, err = database.LoadLiveClassData(classID); err == nil {
+			return class, data, nil
+		}
+	}
+	log.Printf("Loading archived class failed: %v", err)
+	return nil, nil, status.Errorf(codes.Internal, "failed to load live class")
+}
 
-	// 调用 LiveGo 服务器获取推流密钥
-	livegoURL := "http://localhost:8090/control/get?room=" + req.RoomName
-	livegoResp, err := http.Get(livegoURL)
-	if err != nil {
-		log.Printf("Failed to call LiveGo server: %v", err)
-		return nil, err
+// checkStatisticsAccess 检查请求用户是否是直播课发起人、其服务账号或管理员，只有他们可以查看答题统计
+func checkStatisticsAccess(principal *utils.Principal, teacherName string) error {
+	if !principal.ActsFor(teacherName) && principal.Role != database.RoleAdmin {
+		log.Printf("权限不足: 创建者=%s, 请求者=%s", teacherName, principal.Username)
+		return status.Errorf(codes.PermissionDenied, "only the class initiator can view answer statistics")
 	}
-	defer livegoResp.Body.Close()
+	return nil
+}
 
-	var result map[string]interface{}
-	err = json.NewDecoder(livegoResp.Body).Decode(&result)
+// CreateLiveClass 创建直播课，未指定开始时间或开始时间已过时立即开始直播，否则预约到开始时间
+func (s *LiveClassServiceServer) CreateLiveClass(ctx context.Context, req *pb.CreateLiveClassRequest) (*pb.CreateLiveClassResponse, error) {
+	// 从认证信息中获取教师用户名
+	principal, err := utils.RequirePrincipal(ctx)
 	if err != nil {
-		log.Printf("Failed to decode LiveGo response: %v", err)
 		return nil, err
 	}
-	log.Printf("LiveGo response: %+v", result)
+	teacherName := principal.Username
+	if req.ClassName == "" || req.RoomName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_name and room_name are required")
+	}
+	if utf8.RuneCountInString(req.ClassName) > maxClassNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "class_name must be at most %d characters", maxClassNameLength)
+	}
 
-	streamKey, ok := result["data"].(string)
-	if !ok {
-		log.Printf("Failed to extract stream key from response: %+v", result)
-		return nil, errors.New("failed to extract stream key from response")
+	now := time.Now()
+	var scheduledStart, scheduledEnd *time.Time
+	if req.ScheduledStart > 0 {
+		t := time.Unix(req.ScheduledStart, 0)
+		scheduledStart = &t
+	}
+	if req.ScheduledEnd > 0 {
+		t := time.Unix(req.ScheduledEnd, 0)
+		if !t.After(now) || (scheduledStart != nil && !t.After(*scheduledStart)) {
+			return nil, status.Errorf(codes.InvalidArgument, "scheduled_end must be after scheduled_start and in the future")
+		}
+		scheduledEnd = &t
+	}
+	immediate := scheduledStart == nil || !scheduledStart.After(now)
+
+	// 直播课 ID 由服务端生成，class_name 只用于展示
+	classID, err := newClassID()
+	if err != nil {
+		log.Printf("newClassID failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create live class")
 	}
 
-	// 拼接完整的推流地址
-	streamURL := "rtmp://localhost:8090/live/" + streamKey
+	log.Printf("Creating live class %s for teacher: %s", classID, teacherName)
 
-	// 初始化直播课 - 使用认证的用户名作为教师名
-	liveClass := &LiveClass{
-		TeacherName: teacherName,
-		StreamURL:   streamURL,
-		Messages:    make([]*pb.Message, 0),
-		Questions:   make(map[string]*pb.Question),
-		Answers:     make(map[string]map[string]int32),
+	class := &database.LiveClass{
+		ID:             classID,
+		Name:           req.ClassName,
+		TeacherName:    teacherName,
+		RoomName:       req.RoomName,
+		Status:         database.ClassScheduled,
+		ScheduledStart: scheduledStart,
+		ScheduledEnd:   scheduledEnd,
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	// 立即开始的直播课现在占用房间并获取推流密钥，预约的直播课在开放候场时再获取
+	var streamKey string
+	if immediate {
+		if streamKey, err = claimStream(ctx, classID, req.RoomName); err != nil {
+			return nil, err
+		}
+		class.Status = database.ClassLive
+		class.StreamURL = streamURL(streamKey)
+		class.StartedAt = &now
+	}
 
-	if s.streams == nil {
-		s.streams = make(map[string]*LiveClass)
+	// 先写入数据库
+	if err := database.CreateLiveClass(class); err != nil {
+		if immediate {
+			releaseRoomAsync(req.RoomName, classID)
+		}
+		if errors.Is(err, database.ErrRoomBooked) {
+			return nil, status.Errorf(codes.AlreadyExists, "room %s is already booked by another live class at that time", req.RoomName)
+		}
+		log.Printf("CreateLiveClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create live class")
 	}
 
-	s.streams[req.ClassName] = liveClass
+	// 写入 Redis 后其他实例即可看到该直播课
+	if immediate {
+		if err := saveClass(ctx, newClassInfo(class)); err != nil {
+			log.Printf("saveClass failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to create live class")
+		}
+	}
 
-	log.Printf("Live class created: %s by %s", req.ClassName, teacherName)
+	log.Printf("Live class created: %s (%s) by %s, state %s", classID, req.ClassName, teacherName, class.Status)
+	detail := "name=" + req.ClassName + " room=" + req.RoomName
+	if scheduledStart != nil {
+		detail += " scheduled_start=" + scheduledStart.Format(time.RFC3339)
+	}
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditClassCreate, Target: classID, Outcome: database.AuditSuccess, Detail: detail,
+	})
 
 	return &pb.CreateLiveClassResponse{
-		ClassId:   req.ClassName,
+		ClassId:   classID,
 		Status:    "success",
 		StreamKey: streamKey,
+		ClassName: req.ClassName,
+		State:     class.Status,
 	}, nil
 }
+
+// classIDChars 直播课 ID 字符集，小写字母和数字并去掉了容易混淆的字符
+const classIDChars = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// classIDLength 直播课 ID 长度
+const classIDLength = 12
+
+// maxClassNameLength 直播课名称长度限制
+const maxClassNameLength = 100
+
+// newClassID 生成随机的直播课 ID
+func newClassID() (string, error) {
+	b := make([]byte, classIDLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = classIDChars[int(b[i])%len(classIDChars)]
+	}
+	return string(b), nil
+}
 func (s *LiveClassServiceServer) JoinLiveClass(ctx context.Context, req *pb.JoinLiveClassRequest) (*pb.JoinLiveClassResponse, error) {
 	log.Printf("Received JoinLiveClass request: %+v", req)
 
-	// 认证用户
-	username, err := s.authenticateToken(ctx)
+	principal, err := utils.RequirePrincipal(ctx)
 	if err != nil {
-		log.Printf("Authentication failed: %v", err)
 		return nil, err
 	}
-
-	log.Printf("User %s authenticated", username)
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	username := principal.Username
+
+	// 配置要求验证邮箱时，未验证的用户不能加入直播课
+	if database.Config.GetBool("mail.require_verification") {
+		user, err := database.GetUser(username)
+		if err != nil {
+			log.Printf("GetUser failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to join live class")
+		}
+		if !user.EmailVerified {
+			return nil, status.Errorf(codes.PermissionDenied, "请先验证邮箱后再加入直播课")
+		}
+	}
 
 	// 检查是否存在该直播课
-	liveClass, ok := s.streams[req.ClassId]
-	if !ok {
+	class, err := liveClass(ctx, req.ClassId)
+	if err != nil {
 		log.Printf("Live class not found: %s", req.ClassId)
-		return nil, errors.New("live class not found")
+		return nil, err
+	}
+	if err := checkParticipant(ctx, principal, req.ClassId); err != nil {
+		return nil, err
 	}
 
-	log.Printf("Returning stream URL for class %s: %s", req.ClassId, liveClass.StreamURL)
+	// 出勤记录写入失败不影响加入直播课，下一次心跳时会重新记录
+	if err := database.RecordAttendance(req.ClassId, username); err != nil {
+		log.Printf("RecordAttendance failed: %v", err)
+	}
+	touchPresence(ctx, req.ClassId, username)
+
+	log.Printf("Returning stream URL for class %s: %s", req.ClassId, class.StreamURL)
 
 	return &pb.JoinLiveClassResponse{
 		Status:    "success",
-		StreamUrl: liveClass.StreamURL,
-		Message:   fmt.Sprintf("%s 加入了直播课", username),
+		StreamUrl: class.StreamURL,
+		Message:   fmt.Sprintf("%s 加入了直播课", database.GetDisplayName(username)),
 	}, nil
 }
 
 // SendMessage 发送消息
 func (s *LiveClassServiceServer) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.SendMessageResponse, error) {
-	username, err := s.authenticateToken(ctx)
+	principal, err := utils.RequirePrincipal(ctx)
 	if err != nil {
 		return nil, err
 	}
+	username := principal.Username
 
 	classID := req.ClassId
 
-	s.mu.Lock()
-	liveClass, ok := s.streams[classID]
-	s.mu.Unlock()
-	if !ok {
-		return nil, errors.New("live class not found")
+	if _, err := liveClass(ctx, classID); err != nil {
+		return nil, err
+	}
+	if err := checkParticipant(ctx, principal, classID); err != nil {
+		return nil, err
 	}
 
-	// 创建新消息
-	message := &pb.Message{
-		SenderName:     username,
-		MessageContent: req.MessageContent,
-		Timestamp:      time.Now().Unix(),
+	// 创建新消息，发送者显示为昵称
+	now := time.Now()
+	message := &chatMessage{
+		Username: username,
+		Message: &pb.Message{
+			SenderName:     database.GetDisplayName(username),
+			MessageContent: req.MessageContent,
+			Timestamp:      now.Unix(),
+		},
 	}
 
-	// 将消息添加到列表
-	liveClass.mu.Lock()
-	liveClass.Messages = append(liveClass.Messages, message)
-	liveClass.mu.Unlock()
+	// 先保存到数据库，再追加到 Redis 中的消息流
+	if err := database.SaveChatMessage(&database.ChatMessage{
+		ClassID: classID, Username: username, SenderName: message.SenderName,
+		Content: req.MessageContent, CreatedAt: now,
+	}); err != nil {
+		log.Printf("SaveChatMessage failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to send message")
+	}
+	if err := appendMessage(ctx, classID, message); err != nil {
+		log.Printf("appendMessage failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to send message")
+	}
+	publishEvent(ctx, liveEvent{Type: eventMessage, ClassID: classID, Username: username})
 
 	return &pb.SendMessageResponse{
 		Status: "success",
 	}, nil
 }
 
+// EndLiveClass 结束直播课，未开始的预约直播课也可以结束（即取消）
 func (s *LiveClassServiceServer) EndLiveClass(ctx context.Context, req *pb.EndLiveClassRequest) (*pb.EndLiveClassResponse, error) {
-	classID := req.ClassId
-	username := req.Username
-
-	log.Printf("结束直播课请求: 教室ID=%s, 用户名=%s", classID, username)
+	// 只信任 Token 中的用户，忽略请求体中的 username
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	classID := req.ClassId
+	log.Printf("结束直播课请求: 教室ID=%s, 用户名=%s", classID, principal.Username)
 
-	// 检查是否存在该直播课
-	liveClass, ok := s.streams[classID]
-	if !ok {
-		log.Printf("直播课不存在: %s", classID)
-		return nil, errors.New("live class not found")
+	// 检查请求用户是否是直播间的发起人（管理员除外）
+	class, err := ownedClass(ctx, principal, classID, database.AuditClassEnd)
+	if err != nil {
+		return nil, err
 	}
 
-	// 检查请求用户是否是直播间的发起人
-	if liveClass.TeacherName != username {
-		log.Printf("权限不足: 创建者=%s, 请求者=%s", liveClass.TeacherName, username)
-		return nil, status.Errorf(codes.PermissionDenied, "only the class initiator can end the live class")
+	// 消息、题目和答案保留在数据库中供教师回看
+	if err := changeClassState(ctx, class, database.ClassEnded); err != nil {
+		return nil, err
 	}
 
-	// 清理消息队列（如果有的话，现在改为切片，不需要关闭）
-	// 清理消息列表
-	liveClass.Messages = nil
-
-	// 清理题目和答案数据
-	liveClass.Questions = nil
-	liveClass.Answers = nil
-
-	// 从 streams 中移除直播课
-	delete(s.streams, classID)
-
-	log.Printf("直播课已结束并清理: %s", classID)
+	log.Printf("直播课已结束: %s", classID)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditClassEnd, Target: classID, Outcome: database.AuditSuccess,
+	})
 
 	return &pb.EndLiveClassResponse{
 		Status: "success",
@@ -260,71 +441,101 @@ func (s *LiveClassServiceServer) EndLiveClass(ctx context.Context, req *pb.EndLi
 
 // PublishQuestion 发布题目
 func (s *LiveClassServiceServer) PublishQuestion(ctx context.Context, req *pb.PublishQuestionRequest) (*pb.PublishQuestionResponse, error) {
-	// 认证用户（确保是教师）
-	username, err := s.authenticateToken(ctx)
+	principal, err := utils.RequirePrincipal(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	classID := req.ClassId
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// 检查是否存在该直播课
-	liveClass, ok := s.streams[classID]
-	if !ok {
-		return nil, errors.New("live class not found")
+	class, err := liveClass(ctx, classID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 检查请求用户是否是直播间的发起人
-	if liveClass.TeacherName != username {
+	// 检查请求用户是否是直播间的发起人或其管理的服务账号
+	if !principal.ActsFor(class.TeacherName) && principal.Role != database.RoleAdmin {
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Action: database.AuditQuestionPublish, Target: classID, Outcome: database.AuditDenied,
+			Detail: "not the class initiator",
+		})
 		return nil, status.Errorf(codes.PermissionDenied, "only the class initiator can publish questions")
 	}
+	if err := requireLive(class); err != nil {
+		return nil, err
+	}
 
 	// 生成题目ID（使用更精确的纳秒级时间戳）
 	questionID := time.Now().Format("20060102150405.999999999")
 
 	// 保存题目
-	liveClass.Questions[questionID] = &pb.Question{
+	if err := database.SaveLiveQuestion(&database.LiveQuestion{
+		ID: questionID, ClassID: classID, Text: req.Question,
+	}); err != nil {
+		log.Printf("SaveLiveQuestion failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to publish question")
+	}
+	if err := addQuestion(ctx, classID, &pb.Question{
 		QuestionId:   questionID,
 		QuestionText: req.Question,
+	}); err != nil {
+		log.Printf("addQuestion failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to publish question")
 	}
-
-	// 初始化答案统计
-	liveClass.Answers[questionID] = make(map[string]int32)
+	publishEvent(ctx, liveEvent{Type: eventQuestion, ClassID: classID, QuestionID: questionID})
 
 	log.Printf("题目已发布: ID=%s, 内容=%s", questionID, req.Question)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditQuestionPublish, Target: classID, Outcome: database.AuditSuccess,
+		Detail: "question_id=" + questionID,
+	})
 
 	return &pb.PublishQuestionResponse{
 		Status:     "success",
 		QuestionId: questionID, // 确保返回question_id
 	}, nil
 }
-func (s *LiveClassServiceServer) GetMessages(ctx context.Context, req *pb.GetMessagesRequest) (*pb.GetMessagesResponse, error) {
-	s.mu.Lock()
-	liveClass, ok := s.streams[req.ClassId]
-	s.mu.Unlock()
 
-	if !ok {
-		return nil, errors.New("live class not found")
+// GetMessages 获取直播课中晚于 last_timestamp 的消息，被移出的用户不能再读取
+func (s *LiveClassServiceServer) GetMessages(ctx context.Context, req *pb.GetMessagesRequest) (*pb.GetMessagesResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParticipant(ctx, principal, req.ClassId); err != nil {
+		return nil, err
 	}
 
-	var messages []*pb.Message
-
-	liveClass.mu.Lock()
-	for _, msg := range liveClass.Messages {
-		if msg.Timestamp > req.LastTimestamp {
-			// 复制消息对象以确保完整返回
-			messageCopy := &pb.Message{
-				SenderName:     msg.SenderName,
-				MessageContent: msg.MessageContent,
-				Timestamp:      msg.Timestamp,
+	messages, err := readMessages(ctx, req.ClassId, req.LastTimestamp)
+	if err != nil {
+		log.Printf("readMessages failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to get messages")
+	}
+	if len(messages) == 0 {
+		// 消息流为空时可能是已结束的直播课，从数据库读取归档的消息
+		if _, err := getClass(ctx, req.ClassId); errors.Is(err, errClassNotLive) {
+			_, data, err := archivedClass(req.ClassId)
+			if err != nil {
+				return nil, err
+			}
+			// 已结束的直播课不在 Redis 中，以 MySQL 中的移出名单为准
+			for _, kicked := range data.Kicked {
+				if kicked == principal.Username {
+					return nil, status.Errorf(codes.PermissionDenied, "你已被移出该直播课")
+				}
+			}
+			for _, msg := range data.Messages {
+				if msg.CreatedAt.Unix() > req.LastTimestamp {
+					messages = append(messages, &pb.Message{
+						SenderName:     msg.SenderName,
+						MessageContent: msg.Content,
+						Timestamp:      msg.CreatedAt.Unix(),
+					})
+				}
 			}
-			messages = append(messages, messageCopy)
 		}
 	}
-	liveClass.mu.Unlock()
 
 	log.Printf("Returning %d messages for class %s", len(messages), req.ClassId)
 
@@ -335,33 +546,115 @@ func (s *LiveClassServiceServer) GetMessages(ctx context.Context, req *pb.GetMes
 
 // SubmitAnswer 提交答案
 func (s *LiveClassServiceServer) SubmitAnswer(ctx context.Context, req *pb.SubmitAnswerRequest) (*pb.SubmitAnswerResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 	classID := req.ClassId
 	answer := req.Answer
 	questionID := req.QuestionId
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// 检查是否存在该直播课
-	liveClass, ok := s.streams[classID]
-	if !ok {
-		return nil, errors.New("live class not found")
+	class, err := liveClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireLive(class); err != nil {
+		return nil, err
+	}
+	if err := checkParticipant(ctx, principal, classID); err != nil {
+		return nil, err
 	}
 
 	// 检查题目是否存在
-	_, ok = liveClass.Questions[questionID]
+	ok, err := questionExists(ctx, classID, questionID)
+	if err != nil {
+		log.Printf("questionExists failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to submit answer")
+	}
 	if !ok {
-		return nil, errors.New("question not found")
+		return nil, status.Errorf(codes.NotFound, "question not found")
 	}
 
-	// 更新答案统计
-	liveClass.Answers[questionID][answer]++
+	// 保存答题记录后原子地更新答案统计，唯一索引保证每个学生每道题只计入一次
+	err = database.SaveQuizAnswer(&database.QuizAnswer{
+		ClassID: classID, QuestionID: questionID, Username: principal.Username, Answer: answer,
+	})
+	if errors.Is(err, database.ErrAnswerExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "你已经提交过该题的答案")
+	}
+	if err != nil {
+		log.Printf("SaveQuizAnswer failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to submit answer")
+	}
+	if err := incrAnswer(ctx, classID, questionID, answer); err != nil {
+		log.Printf("incrAnswer failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to submit answer")
+	}
+	publishEvent(ctx, liveEvent{Type: eventAnswer, ClassID: classID, QuestionID: questionID})
 
 	return &pb.SubmitAnswerResponse{
 		Status: "success",
 	}, nil
 }
 
+// KickUser 将用户移出直播课，只有直播间发起人或管理员可以操作
+func (s *LiveClassServiceServer) KickUser(ctx context.Context, req *pb.KickUserRequest) (*pb.KickUserResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ClassId == "" || req.Username == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id and username are required")
+	}
+
+	class, err := liveClass(ctx, req.ClassId)
+	if err != nil {
+		return nil, err
+	}
+	detail := "username=" + req.Username
+	if req.Reason != "" {
+		detail += " reason=" + req.Reason
+	}
+	if class.TeacherName != principal.Username && principal.Role != database.RoleAdmin {
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Action: database.AuditUserKick, Target: req.ClassId, Outcome: database.AuditDenied, Detail: detail,
+		})
+		return nil, status.Errorf(codes.PermissionDenied, "only the class initiator can kick users")
+	}
+	if req.Username == class.TeacherName {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot kick the class initiator")
+	}
+
+	if err := database.KickFromClass(req.ClassId, req.Username, principal.Username); err != nil {
+		log.Printf("KickFromClass failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to kick user")
+	}
+	if err := kickUser(ctx, req.ClassId, req.Username); err != nil {
+		log.Printf("kickUser failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to kick user")
+	}
+	if err := database.LeaveAttendance(req.ClassId, req.Username); err != nil {
+		log.Printf("LeaveAttendance failed: %v", err)
+	}
+	leavePresence(ctx, req.ClassId, req.Username)
+	publishEvent(ctx, liveEvent{Type: eventKick, ClassID: req.ClassId, Username: req.Username})
+
+	log.Printf("User %s kicked from %s by %s", req.Username, req.ClassId, principal.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditUserKick, Target: req.ClassId, Outcome: database.AuditSuccess, Detail: detail,
+	})
+	return &pb.KickUserResponse{Status: "success"}, nil
+}
+
+// HandleUserErased 账号删除后，将开放中的直播课里该用户的用户名和展示名替换为化名
+// 每个实例都会收到删除事件，重写是幂等的
+func (s *LiveClassServiceServer) HandleUserErased(ev utils.UserErased) {
+	if err := renameUser(context.Background(), ev.Username, ev.Pseudonym); err != nil {
+		log.Printf("renameUser failed: %v", err)
+	}
+}
+
 // 修改方法签名以匹配接口
 func (s *LiveClassServiceServer) GetAnswerStatistics(
 	req *pb.GetAnswerStatisticsRequest,
@@ -369,29 +662,47 @@ func (s *LiveClassServiceServer) GetAnswerStatistics(
 ) error {
 	// 从流中获取上下文
 	ctx := stream.Context()
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
 
 	classID := req.ClassId
 	questionID := req.QuestionId
 
 	log.Printf("获取答题统计: 教室=%s, 问题=%s", classID, questionID)
 
-	s.mu.Lock()
-	liveClass, ok := s.streams[classID]
-	s.mu.Unlock()
+	// 先关注事件再读取统计，避免错过两者之间提交的答案
+	events, cancelWatch := s.hub.watch(classID)
+	defer cancelWatch()
 
-	if !ok {
-		return status.Errorf(codes.NotFound, "直播课不存在")
+	class, err := getClass(ctx, classID)
+	if errors.Is(err, errClassNotLive) {
+		// 已结束的直播课只发送一次归档的统计
+		return sendArchivedStatistics(stream, principal, classID, questionID)
+	}
+	if err != nil {
+		log.Printf("getClass failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load live class")
+	}
+	if err := checkStatisticsAccess(principal, class.TeacherName); err != nil {
+		return err
 	}
 
 	// 检查题目是否存在
-	liveClass.mu.Lock()
-	_, ok = liveClass.Questions[questionID]
-	answerCounts := liveClass.Answers[questionID] // 获取当前统计的副本
-	liveClass.mu.Unlock()
-
+	ok, err := questionExists(ctx, classID, questionID)
+	if err != nil {
+		log.Printf("questionExists failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load question")
+	}
 	if !ok {
 		return status.Errorf(codes.NotFound, "题目不存在")
 	}
+	answerCounts, err := loadAnswerCounts(ctx, classID, questionID)
+	if err != nil {
+		log.Printf("loadAnswerCounts failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to load answer statistics")
+	}
 
 	// 创建超时上下文
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -405,20 +716,25 @@ func (s *LiveClassServiceServer) GetAnswerStatistics(
 		return err
 	}
 
-	// 定期更新
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
+	// 任意实例上提交答案后，通过 Redis 事件通知本实例推送最新统计
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("上下文结束:", ctx.Err())
 			return nil
-		case <-ticker.C:
+		case ev := <-events:
+			if ev.Type == eventEnded {
+				return nil
+			}
+			if ev.Type != eventResync && (ev.Type != eventAnswer || ev.QuestionID != questionID) {
+				continue
+			}
 			// 获取最新统计
-			liveClass.mu.Lock()
-			updatedCounts := liveClass.Answers[questionID]
-			liveClass.mu.Unlock()
+			updatedCounts, err := loadAnswerCounts(ctx, classID, questionID)
+			if err != nil {
+				log.Printf("loadAnswerCounts failed: %v", err)
+				continue
+			}
 
 			// 检查是否有变化
 			if !reflect.DeepEqual(answerCounts, updatedCounts) {
@@ -435,3 +751,31 @@ func (s *LiveClassServiceServer) GetAnswerStatistics(
 		}
 	}
 }
+
+// sendArchivedStatistics 发送已结束直播课的答题统计
+func sendArchivedStatistics(stream pb.LiveClassService_GetAnswerStatisticsServer, principal *utils.Principal, classID, questionID string) error {
+	class, data, err := archivedClass(classID)
+	if err != nil {
+		return err
+	}
+	if err := checkStatisticsAccess(principal, class.TeacherName); err != nil {
+		return err
+	}
+	found := false
+	for _, q := range data.Questions {
+		if q.ID == questionID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return status.Errorf(codes.NotFound, "题目不存在")
+	}
+	counts := make(map[string]int32)
+	for _, a := range data.Answers {
+		if a.QuestionID == questionID {
+			counts[a.Answer] = a.Count
+		}
+	}
+	return stream.Send(&pb.AnswerStatistics{QuestionId: questionID, AnswerCounts: counts})
+}
@@ -0,0 +1,301 @@
+package liveservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"LanshanClass1.3/global/database"
+	pb "LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 在线状态：每个在线用户一个带过期时间的键，加入、心跳时续期；每个直播课另有一个在线用户名集合用于列出在线用户
+// 键过期后由清理任务从集合中移除并通知各实例，因此超时离开最多延迟一个清理间隔
+
+// markOnlineScript 将用户加入在线集合并续期在线状态，已在线时保留原来的上线时间，返回 1 表示新上线
+var markOnlineScript = redis.NewScript(`
+local added = redis.call("SADD", KEYS[1], ARGV[1])
+if not redis.call("SET", KEYS[2], ARGV[2], "NX", "PX", ARGV[3]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+end
+return added
+`)
+
+// sweepPresenceScript 从在线集合中移除在线状态已过期的用户，返回被移除的用户名
+// 在脚本中检查和移除，避免与同时到达的心跳冲突
+var sweepPresenceScript = redis.NewScript(`
+local removed = {}
+for _, username in ipairs(redis.call("SMEMBERS", KEYS[1])) do
+	if redis.call("EXISTS", ARGV[1] .. username) == 0 then
+		redis.call("SREM", KEYS[1], username)
+		table.insert(removed, username)
+	end
+end
+return removed
+`)
+
+// onlineUser 在线用户及其上线时间
+type onlineUser struct {
+	Username string
+	Since    time.Time
+}
+
+// markOnline 记录用户在线，返回是否为新上线
+func markOnline(ctx context.Context, id, username string, now time.Time) (bool, error) {
+	keys := []string{onlineKey(id), presenceKey(id, username)}
+	added, err := markOnlineScript.Run(ctx, database.RedisClient, keys,
+		username, now.UnixMilli(), heartbeatTimeout().Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark online: %w", err)
+	}
+	return added == 1, nil
+}
+
+// markOffline 记录用户离开，返回用户离开前是否在线
+func markOffline(ctx context.Context, id, username string) (bool, error) {
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Del(ctx, presenceKey(id, username))
+	removed := pipe.SRem(ctx, onlineKey(id), username)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to mark offline: %w", err)
+	}
+	return removed.Val() == 1, nil
+}
+
+// sweepPresence 移除直播课中在线状态已过期的用户
+func sweepPresence(ctx context.Context, id string) ([]string, error) {
+	removed, err := sweepPresenceScript.Run(ctx, database.RedisClient, []string{onlineKey(id)},
+		presenceKeyPrefix+id+":").StringSlice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to sweep presence: %w", err)
+	}
+	return removed, nil
+}
+
+// listOnline 查询直播课当前在线的用户，按上线时间排序
+func listOnline(ctx context.Context, id string) ([]onlineUser, error) {
+	usernames, err := database.RedisClient.SMembers(ctx, onlineKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list online users: %w", err)
+	}
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(usernames))
+	for i, username := range usernames {
+		keys[i] = presenceKey(id, username)
+	}
+	values, err := database.RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load presence: %w", err)
+	}
+
+	users := make([]onlineUser, 0, len(usernames))
+	for i, v := range values {
+		// 已过期但尚未被清理的用户视为已离开
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		ms, _ := strconv.ParseInt(s, 10, 64)
+		users = append(users, onlineUser{Username: usernames[i], Since: time.UnixMilli(ms)})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Since.Before(users[j].Since) })
+	return users, nil
+}
+
+// countOnline 批量查询直播课的在线人数
+func countOnline(ctx context.Context, ids []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	pipe := database.RedisClient.Pipeline()
+	cmds := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.SCard(ctx, onlineKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count online users: %w", err)
+	}
+	for i, id := range ids {
+		counts[id] = cmds[i].Val()
+	}
+	return counts, nil
+}
+
+// touchPresence 加入或心跳时续期在线状态，新上线时通知各实例；在线状态只用于展示，失败不影响请求
+func touchPresence(ctx context.Context, classID, username string) {
+	now := time.Now()
+	joined, err := markOnline(ctx, classID, username, now)
+	if err != nil {
+		log.Printf("markOnline failed: %v", err)
+		return
+	}
+	if joined {
+		publishEvent(ctx, liveEvent{Type: eventJoin, ClassID: classID, Username: username, Since: now.UnixMilli()})
+	}
+}
+
+// leavePresence 离开或被移出时清除在线状态并通知各实例
+func leavePresence(ctx context.Context, classID, username string) {
+	left, err := markOffline(ctx, classID, username)
+	if err != nil {
+		log.Printf("markOffline failed: %v", err)
+		return
+	}
+	if left {
+		publishEvent(ctx, liveEvent{Type: eventLeave, ClassID: classID, Username: username})
+	}
+}
+
+// RunPresenceSweeper 定期清理心跳超时的在线用户并通知各实例，在 ctx 结束前持续运行
+// 多个实例同时清理时，每个用户只会被其中一个实例移除和通知
+func (s *LiveClassServiceServer) RunPresenceSweeper(ctx context.Context) {
+	ticker := time.NewTicker(database.Config.GetDuration("live.presence_sweep_interval"))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ids, err := listLiveClassIDs(ctx)
+		if err != nil {
+			log.Printf("listLiveClassIDs failed: %v", err)
+			continue
+		}
+		for _, id := range ids {
+			removed, err := sweepPresence(ctx, id)
+			if err != nil {
+				log.Printf("sweepPresence failed: %v", err)
+				continue
+			}
+			for _, username := range removed {
+				publishEvent(ctx, liveEvent{Type: eventLeave, ClassID: id, Username: username})
+			}
+		}
+	}
+}
+
+// participantsClass 查询开放中的直播课，并检查请求用户是否是发起人、其服务账号或管理员
+func participantsClass(ctx context.Context, classID string) (*classInfo, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if classID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "class_id is required")
+	}
+	class, err := liveClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.ActsFor(class.TeacherName) && principal.Role != database.RoleAdmin {
+		return nil, status.Errorf(codes.PermissionDenied, "only the class initiator can view participants")
+	}
+	return class, nil
+}
+
+// toParticipants 将在线用户转换为 gRPC 消息，并批量填充昵称
+func toParticipants(users []onlineUser) []*pb.Participant {
+	usernames := make([]string, len(users))
+	for i, u := range users {
+		usernames[i] = u.Username
+	}
+	names := database.GetDisplayNames(usernames)
+	participants := make([]*pb.Participant, 0, len(users))
+	for _, u := range users {
+		participants = append(participants, &pb.Participant{
+			Username:    u.Username,
+			DisplayName: names[u.Username],
+			OnlineSince: u.Since.Unix(),
+		})
+	}
+	return participants
+}
+
+// ListParticipants 查询直播课当前在线的用户，只有发起人和管理员可以查询
+func (s *LiveClassServiceServer) ListParticipants(ctx context.Context, req *pb.ListParticipantsRequest) (*pb.ListParticipantsResponse, error) {
+	if _, err := participantsClass(ctx, req.ClassId); err != nil {
+		return nil, err
+	}
+	users, err := listOnline(ctx, req.ClassId)
+	if err != nil {
+		log.Printf("listOnline failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list participants")
+	}
+	return &pb.ListParticipantsResponse{Participants: toParticipants(users)}, nil
+}
+
+// sendParticipantsSnapshot 发送直播课当前的全部在线用户
+func sendParticipantsSnapshot(ctx context.Context, stream pb.LiveClassService_WatchParticipantsServer, classID string) error {
+	users, err := listOnline(ctx, classID)
+	if err != nil {
+		log.Printf("listOnline failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to list participants")
+	}
+	return stream.Send(&pb.ParticipantEvent{
+		Type:         "snapshot",
+		Participants: toParticipants(users),
+		OnlineCount:  int64(len(users)),
+	})
+}
+
+// WatchParticipants 先发送当前在线用户，之后推送加入和离开，直播课结束或客户端断开时返回
+func (s *LiveClassServiceServer) WatchParticipants(req *pb.ListParticipantsRequest, stream pb.LiveClassService_WatchParticipantsServer) error {
+	ctx := stream.Context()
+
+	// 先关注事件再读取在线用户，避免错过两者之间的变化
+	events, cancelWatch := s.hub.watch(req.ClassId)
+	defer cancelWatch()
+
+	if _, err := participantsClass(ctx, req.ClassId); err != nil {
+		return err
+	}
+	if err := sendParticipantsSnapshot(ctx, stream, req.ClassId); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			if ev.Type == eventEnded {
+				return nil
+			}
+			// 丢失过事件时重新发送完整的在线用户，客户端收到 snapshot 时应替换本地列表
+			if ev.Type == eventResync {
+				if err := sendParticipantsSnapshot(ctx, stream, req.ClassId); err != nil {
+					return err
+				}
+				continue
+			}
+			if ev.Type != eventJoin && ev.Type != eventLeave {
+				continue
+			}
+			user := onlineUser{Username: ev.Username, Since: time.UnixMilli(ev.Since)}
+			counts, err := countOnline(ctx, []string{req.ClassId})
+			if err != nil {
+				log.Printf("countOnline failed: %v", err)
+			}
+			if err := stream.Send(&pb.ParticipantEvent{
+				Type:         ev.Type,
+				Participants: toParticipants([]onlineUser{user}),
+				OnlineCount:  counts[req.ClassId],
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
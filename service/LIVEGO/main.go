@@ -4,24 +4,41 @@ package main
 import (
 	"LanshanClass1.3/global/database"
 	"LanshanClass1.3/service/LIVEGO/liveservice"
+	"context"
 	"log"
 	"net"
 
 	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
 	"google.golang.org/grpc"
 )
 
 func main() {
 	database.Init()
+	utils.InitJWT()
 	// 定义 gRPC 服务监听的地址
 	lis, err := net.Listen("tcp", "localhost:50052")
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 	// 创建 gRPC 服务器
-	s := grpc.NewServer()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(utils.UnaryAuthInterceptor(liveservice.MethodPolicies)),
+		grpc.ChainStreamInterceptor(utils.StreamAuthInterceptor(liveservice.MethodPolicies)),
+	)
 	// 注册服务
-	proto.RegisterLiveClassServiceServer(s, &liveservice.LiveClassServiceServer{})
+	server := liveservice.NewLiveClassServiceServer()
+	// Redis 数据丢失时从 MySQL 恢复进行中的直播课
+	if err := server.LoadActiveClasses(); err != nil {
+		log.Fatalf("failed to load live classes: %v", err)
+	}
+	proto.RegisterLiveClassServiceServer(s, server)
+	// 订阅直播课事件，分发给本实例上的流式请求
+	go server.RunEventFanout(context.Background())
+	go server.RunScheduler(context.Background())
+	go server.RunPresenceSweeper(context.Background())
+	// 账号删除后替换进行中的直播课里的用户名
+	go utils.SubscribeUserErased(context.Background(), server.HandleUserErased)
 	log.Println("gRPC server started at :50052")
 	// 启动 gRPC 服务
 	if err := s.Serve(lis); err != nil {
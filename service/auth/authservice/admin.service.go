@@ -0,0 +1,276 @@
+// admin.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"math/big"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// 用户列表分页参数
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// temporaryPasswordChars 临时密码字符集，去掉了容易混淆的字符
+const temporaryPasswordChars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+
+// temporaryPasswordSymbols 密码规则要求符号时加入临时密码的字符
+const temporaryPasswordSymbols = "!@#$%&*?"
+
+// AdminService 实现了 proto.AdminServiceServer 接口，所有方法仅管理员可调用
+type AdminService struct {
+	proto.UnimplementedAdminServiceServer
+	limiter *loginLimiter
+}
+
+// NewAdminService 初始化服务
+func NewAdminService() *AdminService {
+	return &AdminService{
+		limiter: newLoginLimiter(),
+	}
+}
+
+// ListUsers 分页查询和搜索用户
+func (s *AdminService) ListUsers(ctx context.Context, req *proto.ListUsersRequest) (*proto.ListUsersResponse, error) {
+	page, pageSize := int(req.Page), int(req.PageSize)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	users, total, err := database.ListUsers(database.UserFilter{
+		Query:           req.Query,
+		Role:            req.Role,
+		IncludeDisabled: req.IncludeDisabled,
+		PendingOnly:     req.PendingOnly,
+		Page:            page,
+		PageSize:        pageSize,
+	})
+	if err != nil {
+		log.Printf("ListUsers failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list users")
+	}
+
+	resp := &proto.ListUsersResponse{
+		Total:    total,
+		Page:     int32(page),
+		PageSize: int32(pageSize),
+	}
+	for i := range users {
+		resp.Users = append(resp.Users, toAdminUser(&users[i]))
+	}
+	return resp, nil
+}
+
+// GetUser 查询单个用户
+func (s *AdminService) GetUser(ctx context.Context, req *proto.GetUserRequest) (*proto.AdminUser, error) {
+	user, err := database.GetUser(req.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if user.InviteID != nil {
+		if invite, err := database.GetInviteByID(*user.InviteID); err == nil {
+			user.Invite = invite
+		}
+	}
+	return toAdminUser(user), nil
+}
+
+// SetUserDisabled 禁用或重新启用用户，禁用后该用户已签发的 Token 立即失效
+func (s *AdminService) SetUserDisabled(ctx context.Context, req *proto.SetUserDisabledRequest) (*proto.AdminUser, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Disabled && req.Username == admin.Username {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot disable yourself")
+	}
+
+	user, err := database.SetUserDisabled(req.Username, req.Disabled)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := utils.SetUserDisabled(user.Username, req.Disabled); err != nil {
+		log.Printf("SetUserDisabled in Redis failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to update user status")
+	}
+	if req.Disabled {
+		if err := utils.RevokeUserTokens(user.Username); err != nil {
+			log.Printf("RevokeUserTokens failed: %v", err)
+		}
+	}
+
+	log.Printf("User %s disabled=%v by %s", user.Username, req.Disabled, admin.Username)
+	return toAdminUser(user), nil
+}
+
+// AssignRole 设置用户角色，已签发的 Token 失效以便新角色立即生效
+func (s *AdminService) AssignRole(ctx context.Context, req *proto.AssignRoleRequest) (*proto.AdminUser, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !database.ValidRole(req.Role) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role: %s", req.Role)
+	}
+
+	user, err := database.AssignRole(req.Username, req.Role)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := utils.RevokeUserTokens(user.Username); err != nil {
+		log.Printf("RevokeUserTokens failed: %v", err)
+	}
+
+	log.Printf("Role of %s set to %s by %s", user.Username, req.Role, admin.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditRoleChange, Target: user.Username, Outcome: database.AuditSuccess,
+		Detail: "role=" + req.Role,
+	})
+	return toAdminUser(user), nil
+}
+
+// ResetPassword 强制重置用户密码，未指定新密码时生成临时密码
+func (s *AdminService) ResetPassword(ctx context.Context, req *proto.ResetPasswordRequest) (*proto.ResetPasswordResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := database.GetUser(req.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := localPasswordError(user); err != nil {
+		return nil, err
+	}
+
+	password := req.NewPassword
+	resp := &proto.ResetPasswordResponse{Message: "密码已重置"}
+	if password == "" {
+		password, err = temporaryPassword(user.Username)
+		if err != nil {
+			log.Printf("temporaryPassword failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to reset password")
+		}
+		resp.TemporaryPassword = password
+	} else if err := checkNewPassword("new_password", password, user.Username); err != nil {
+		return nil, err
+	}
+
+	if err := database.SetPassword(req.Username, password); err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := utils.RevokeUserTokens(req.Username); err != nil {
+		log.Printf("RevokeUserTokens failed: %v", err)
+	}
+	// 重置密码的同时解除登录锁定
+	if err := s.limiter.Unlock(ctx, req.Username); err != nil {
+		log.Printf("Unlock failed: %v", err)
+	}
+
+	log.Printf("Password of %s reset by %s", req.Username, admin.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditPasswordChange, Target: req.Username, Outcome: database.AuditSuccess,
+		Detail: "reset by admin",
+	})
+	return resp, nil
+}
+
+// ApproveRegistration 审批等待审批的注册，驳回时删除该账号
+func (s *AdminService) ApproveRegistration(ctx context.Context, req *proto.ApproveRegistrationRequest) (*proto.ApproveRegistrationResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := database.ResolvePendingUser(req.Username, req.Approve)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, userLookupError(err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+
+	log.Printf("Registration of %s resolved by %s: approve=%v", user.Username, admin.Username, req.Approve)
+	message := "已驳回注册，账号已删除"
+	if req.Approve {
+		message = "已通过注册审批"
+	}
+	return &proto.ApproveRegistrationResponse{Username: user.Username, Message: message}, nil
+}
+
+// toAdminUser 将数据库用户转换为管理员视角的用户消息
+func toAdminUser(user *database.User) *proto.AdminUser {
+	u := &proto.AdminUser{
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		Role:          user.Role,
+		RequestedRole: user.RequestedRole,
+		Disabled:      user.Disabled,
+		CreatedAt:     user.CreatedAt.Unix(),
+		Pending:       user.Pending,
+		Source:        user.Source,
+	}
+	if user.Invite != nil {
+		u.InviteCode = user.Invite.Code
+	}
+	if user.LastLoginAt != nil {
+		u.LastLoginAt = user.LastLoginAt.Unix()
+	}
+	return u
+}
+
+// temporaryPassword 生成符合密码规则的临时密码
+func temporaryPassword(username string) (string, error) {
+	policy := utils.GetCredentialPolicy()
+	chars := temporaryPasswordChars
+	for _, class := range policy.RequiredClasses {
+		if class == utils.ClassSymbol {
+			chars += temporaryPasswordSymbols
+		}
+	}
+	length := max(12, policy.PasswordMinLength)
+	// 随机结果偶尔缺少某类字符，重新生成即可
+	for i := 0; i < 20; i++ {
+		password, err := randomString(chars, length)
+		if err != nil {
+			return "", err
+		}
+		if len(policy.ValidatePassword("new_password", password, username)) == 0 {
+			return password, nil
+		}
+	}
+	return "", errors.New("failed to generate a password that satisfies the policy")
+}
+
+// randomString 从字符集中随机生成指定长度的字符串，用于临时密码、邀请码和恢复码
+func randomString(chars string, length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(chars)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,203 @@
+// api_key.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey 为调用者管理的服务账号签发 API Key，服务账号不存在时自动创建
+// 服务账号继承调用者的角色，API Key 只能调用其权限范围内的接口
+func (s *AuthService) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest) (*proto.CreateAPIKeyResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account := strings.TrimSpace(req.ServiceAccount)
+	if account == "" || len(account) > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "service_account is required and must not exceed 100 characters")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required and must not exceed 100 characters")
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresIn < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "expires_in must not be negative")
+	}
+
+	password, err := randomToken()
+	if err != nil {
+		log.Printf("randomToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create api key")
+	}
+	user, err := database.EnsureServiceAccount(account, principal.Username, principal.Role, password)
+	if errors.Is(err, database.ErrNotServiceAccount) {
+		return nil, status.Errorf(codes.AlreadyExists, "用户名 %s 已被其他账号使用", account)
+	}
+	if err != nil {
+		log.Printf("EnsureServiceAccount failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create api key")
+	}
+
+	key, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		log.Printf("GenerateAPIKey failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create api key")
+	}
+	record := &database.APIKey{
+		Prefix:    prefix,
+		Hash:      hash,
+		Name:      name,
+		Username:  user.Username,
+		Scopes:    strings.Join(scopes, ","),
+		CreatedBy: principal.Username,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := database.CreateAPIKey(record); err != nil {
+		log.Printf("CreateAPIKey failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create api key")
+	}
+
+	log.Printf("API key %s for %s created by %s", prefix, user.Username, principal.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditAPIKeyCreate, Target: user.Username, Outcome: database.AuditSuccess,
+		Detail: fmt.Sprintf("id=%d prefix=%s scopes=%s", record.ID, prefix, record.Scopes),
+	})
+	return &proto.CreateAPIKeyResponse{ApiKey: toAPIKey(record), Key: key}, nil
+}
+
+// ListAPIKeys 查询调用者管理的服务账号的 API Key，管理员可查询全部
+func (s *AuthService) ListAPIKeys(ctx context.Context, req *proto.ListAPIKeysRequest) (*proto.ListAPIKeysResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := principal.Username
+	if req.All {
+		if principal.Role != database.RoleAdmin {
+			return nil, status.Errorf(codes.PermissionDenied, "only admins can list all api keys")
+		}
+		owner = ""
+	}
+
+	keys, err := database.ListAPIKeys(owner, req.IncludeRevoked)
+	if err != nil {
+		log.Printf("ListAPIKeys failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list api keys")
+	}
+	resp := &proto.ListAPIKeysResponse{}
+	for i := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, toAPIKey(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey 撤销 API Key，只有服务账号的管理者或管理员可以操作
+func (s *AuthService) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest) (*proto.RevokeAPIKeyResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Id == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "id is required")
+	}
+
+	key, err := database.GetAPIKey(uint(req.Id))
+	if err != nil {
+		return nil, apiKeyLookupError(err)
+	}
+	if principal.Role != database.RoleAdmin {
+		account, err := database.GetUser(key.Username)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, userLookupError(err)
+		}
+		// 不属于自己的 API Key 按不存在处理，避免泄露其他人的 API Key ID
+		if account == nil || account.Owner != principal.Username {
+			return nil, status.Errorf(codes.NotFound, "api key not found")
+		}
+	}
+
+	if err := database.RevokeAPIKey(key.ID); err != nil {
+		log.Printf("RevokeAPIKey failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to revoke api key")
+	}
+
+	log.Printf("API key %s of %s revoked by %s", key.Prefix, key.Username, principal.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditAPIKeyRevoke, Target: key.Username, Outcome: database.AuditSuccess,
+		Detail: fmt.Sprintf("id=%d prefix=%s", key.ID, key.Prefix),
+	})
+	return &proto.RevokeAPIKeyResponse{Message: "API Key 已撤销"}, nil
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !database.ValidScope(scope) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scope: %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one scope is required")
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// toAPIKey 将数据库中的 API Key 转换为 proto 消息
+func toAPIKey(key *database.APIKey) *proto.APIKey {
+	resp := &proto.APIKey{
+		Id:             uint64(key.ID),
+		Name:           key.Name,
+		ServiceAccount: key.Username,
+		Scopes:         utils.APIKeyScopes(key.Scopes),
+		Prefix:         key.Prefix,
+		CreatedAt:      key.CreatedAt.Unix(),
+		Revoked:        key.Revoked,
+		CreatedBy:      key.CreatedBy,
+	}
+	if key.ExpiresAt != nil {
+		resp.ExpiresAt = key.ExpiresAt.Unix()
+	}
+	if key.LastUsedAt != nil {
+		resp.LastUsedAt = key.LastUsedAt.Unix()
+	}
+	return resp
+}
+
+// apiKeyLookupError 将查询 API Key 的错误转换为 gRPC 错误
+func apiKeyLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "api key not found")
+	}
+	log.Printf("API key lookup failed: %v", err)
+	return status.Errorf(codes.Internal, "internal error")
+}
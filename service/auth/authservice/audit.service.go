@@ -0,0 +1,108 @@
+// audit.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListAuditEvents 按时间倒序分页查询审计事件
+func (s *AdminService) ListAuditEvents(ctx context.Context, req *proto.ListAuditEventsRequest) (*proto.ListAuditEventsResponse, error) {
+	filter, err := auditFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events, total, err := database.ListAuditEvents(filter)
+	if err != nil {
+		log.Printf("ListAuditEvents failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list audit events")
+	}
+
+	resp := &proto.ListAuditEventsResponse{
+		Total:    total,
+		Page:     int32(filter.Page),
+		PageSize: int32(filter.PageSize),
+	}
+	for i := range events {
+		resp.Events = append(resp.Events, toAuditEvent(&events[i]))
+	}
+	return resp, nil
+}
+
+// ExportAuditEvents 按时间顺序流式导出全部符合条件的审计事件，忽略分页参数
+func (s *AdminService) ExportAuditEvents(req *proto.ListAuditEventsRequest, stream grpc.ServerStreamingServer[proto.AuditEvent]) error {
+	filter, err := auditFilter(req)
+	if err != nil {
+		return err
+	}
+
+	err = database.EachAuditEvent(filter, func(event *database.AuditEvent) error {
+		return stream.Send(toAuditEvent(event))
+	})
+	if err != nil {
+		log.Printf("ExportAuditEvents failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to export audit events")
+	}
+	return nil
+}
+
+// auditFilter 校验请求并转换为数据库查询条件
+func auditFilter(req *proto.ListAuditEventsRequest) (database.AuditFilter, error) {
+	if req.Since < 0 || req.Until < 0 {
+		return database.AuditFilter{}, status.Errorf(codes.InvalidArgument, "since and until must be unix timestamps")
+	}
+	if req.Since > 0 && req.Until > 0 && req.Since >= req.Until {
+		return database.AuditFilter{}, status.Errorf(codes.InvalidArgument, "since must be earlier than until")
+	}
+
+	page, pageSize := int(req.Page), int(req.PageSize)
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	filter := database.AuditFilter{
+		Actor:    req.Actor,
+		Action:   req.Action,
+		Target:   req.Target,
+		Outcome:  req.Outcome,
+		IP:       req.Ip,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until > 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+	return filter, nil
+}
+
+// toAuditEvent 将数据库中的审计事件转换为 proto 消息
+func toAuditEvent(event *database.AuditEvent) *proto.AuditEvent {
+	return &proto.AuditEvent{
+		Id:        uint64(event.ID),
+		CreatedAt: event.CreatedAt.Unix(),
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Target:    event.Target,
+		Outcome:   event.Outcome,
+		Ip:        event.IP,
+		UserAgent: event.UserAgent,
+		Detail:    event.Detail,
+	}
+}
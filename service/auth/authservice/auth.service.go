@@ -6,42 +6,478 @@ import (
 	"LanshanClass1.3/proto"
 	"LanshanClass1.3/utils"
 	"context"
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"log"
+	"strings"
 )
 
 // AuthService 实现了 proto.AuthServiceServer 接口
 type AuthService struct {
 	proto.UnimplementedAuthServiceServer
+	limiter       *loginLimiter
+	mailer        utils.Mailer
+	authenticator utils.Authenticator
 }
 
-// Register 注册方法
+// MethodPolicies 声明 AuthService、AdminService 和 OIDCService 每个 RPC 的访问策略，由认证拦截器统一执行
+var MethodPolicies = map[string]utils.MethodPolicy{
+	proto.AuthService_Register_FullMethodName:              {Public: true},
+	proto.AuthService_Login_FullMethodName:                 {Public: true},
+	proto.AuthService_RefreshToken_FullMethodName:          {Public: true},
+	proto.AuthService_Logout_FullMethodName:                {},
+	proto.AuthService_ListRoleRequests_FullMethodName:      {Roles: []string{database.RoleAdmin}},
+	proto.AuthService_ApproveRole_FullMethodName:           {Roles: []string{database.RoleAdmin}},
+	proto.AuthService_UnlockAccount_FullMethodName:         {Roles: []string{database.RoleAdmin}},
+	proto.AuthService_GetProfile_FullMethodName:            {},
+	proto.AuthService_UpdateProfile_FullMethodName:         {},
+	proto.AuthService_ChangePassword_FullMethodName:        {},
+	proto.AuthService_DeleteAccount_FullMethodName:         {},
+	proto.AuthService_VerifySecondFactor_FullMethodName:    {Public: true},
+	proto.AuthService_EnrollTOTP_FullMethodName:            {},
+	proto.AuthService_ConfirmTOTP_FullMethodName:           {},
+	proto.AuthService_DisableTOTP_FullMethodName:           {},
+	proto.AuthService_ListSessions_FullMethodName:          {},
+	proto.AuthService_RevokeSession_FullMethodName:         {},
+	proto.AuthService_RequestPasswordReset_FullMethodName:  {Public: true},
+	proto.AuthService_ResetPassword_FullMethodName:         {Public: true},
+	proto.AuthService_VerifyEmail_FullMethodName:           {Public: true},
+	proto.AuthService_SendVerificationEmail_FullMethodName: {},
+	proto.AuthService_CreateAPIKey_FullMethodName:          {Roles: []string{database.RoleTeacher}},
+	proto.AuthService_ListAPIKeys_FullMethodName:           {Roles: []string{database.RoleTeacher}},
+	proto.AuthService_RevokeAPIKey_FullMethodName:          {Roles: []string{database.RoleTeacher}},
+	proto.AuthService_ExportMyData_FullMethodName:          {},
+
+	proto.AdminService_ListUsers_FullMethodName:           {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_GetUser_FullMethodName:             {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_SetUserDisabled_FullMethodName:     {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_AssignRole_FullMethodName:          {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ResetPassword_FullMethodName:       {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ApproveRegistration_FullMethodName: {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_CreateInvite_FullMethodName:        {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ListInvites_FullMethodName:         {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_RevokeInvite_FullMethodName:        {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ListAuditEvents_FullMethodName:     {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ExportAuditEvents_FullMethodName:   {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ListErasures_FullMethodName:        {Roles: []string{database.RoleAdmin}},
+	proto.AdminService_ReviewErasure_FullMethodName:       {Roles: []string{database.RoleAdmin}},
+
+	proto.OIDCService_RegisterClient_FullMethodName: {Roles: []string{database.RoleAdmin}},
+	proto.OIDCService_ListClients_FullMethodName:    {Roles: []string{database.RoleAdmin}},
+	proto.OIDCService_DeleteClient_FullMethodName:   {Roles: []string{database.RoleAdmin}},
+	proto.OIDCService_CheckAuthorize_FullMethodName: {Public: true},
+	proto.OIDCService_Authorize_FullMethodName:      {Public: true},
+	proto.OIDCService_Token_FullMethodName:          {Public: true},
+	proto.OIDCService_UserInfo_FullMethodName:       {Public: true},
+}
+
+// NewAuthService 初始化服务
+func NewAuthService() *AuthService {
+	return &AuthService{
+		limiter:       newLoginLimiter(),
+		mailer:        utils.NewMailer(),
+		authenticator: utils.NewAuthenticator(),
+	}
+}
+
+// Register 注册方法，注册模式由配置 registration.mode 决定
 func (s *AuthService) Register(ctx context.Context, req *proto.RegisterRequest) (*proto.RegisterResponse, error) {
-	err := database.CreateUser(req.Username, req.Password)
+	// 使用 LDAP 认证时账号由学校目录统一管理，本地注册会抢占目录中的用户名
+	if database.Config.GetString("auth.backend") == "ldap" {
+		return nil, status.Errorf(codes.FailedPrecondition, "请直接使用统一身份认证账号登录")
+	}
+	mode := database.RegistrationMode()
+	if mode == database.RegistrationInvite && req.InviteCode == "" {
+		return nil, status.Errorf(codes.PermissionDenied, "当前仅允许使用邀请码注册")
+	}
+
+	// 一次返回全部不符合规则的字段
+	policy := utils.GetCredentialPolicy()
+	violations := policy.ValidateUsername("username", req.Username)
+	violations = append(violations, policy.ValidatePassword("password", req.Password, req.Username)...)
+	if req.Role != "" && !database.ValidRole(req.Role) {
+		violations = append(violations, utils.FieldViolation{Field: "role", Description: "unknown role: " + req.Role})
+	}
+	if req.Email != "" && !validEmail(req.Email) {
+		violations = append(violations, utils.FieldViolation{Field: "email", Description: "invalid email address"})
+	}
+	if len(violations) > 0 {
+		return nil, invalidFieldsError(violations)
+	}
+	if req.Email != "" {
+		if err := checkEmailAvailable(req.Email, req.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := database.CreateUser(database.NewUser{
+		Username:      req.Username,
+		Password:      req.Password,
+		RequestedRole: req.Role,
+		Email:         req.Email,
+		InviteCode:    req.InviteCode,
+		Pending:       mode == database.RegistrationApproval,
+	})
+	if errors.Is(err, database.ErrInvalidInvite) {
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Actor: req.Username, Action: database.AuditRegister, Target: req.Username,
+			Outcome: database.AuditFailure, Detail: "invalid invite code " + req.InviteCode,
+		})
+		return nil, status.Errorf(codes.InvalidArgument, "邀请码无效、已过期或已用完")
+	}
 	if err != nil {
 		return nil, err
 	}
+	detail := "role=" + user.Role
+	if user.Invite != nil {
+		detail += " invite=" + user.Invite.Code
+	}
+	if user.Pending {
+		detail += " pending"
+	}
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Actor: user.Username, Action: database.AuditRegister, Target: user.Username,
+		Outcome: database.AuditSuccess, Detail: detail,
+	})
+	if user.Email != "" {
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("sendVerificationEmail failed: %v", err)
+		}
+	}
+
+	if user.Pending {
+		log.Printf("Registration pending approval: %s", user.Username)
+		return &proto.RegisterResponse{
+			Message:         "注册成功，请等待管理员审批",
+			PendingApproval: true,
+		}, nil
+	}
 
-	token := utils.GenerateToken(req.Username)
+	token, refreshToken, err := issueTokens(ctx, user.Username, user.Role, "")
+	if err != nil {
+		return nil, err
+	}
+	message := "注册成功"
+	if user.RequestedRole != "" {
+		message = "注册成功，申请的角色待管理员审批"
+	}
+	if user.Invite != nil && user.Invite.ClassID != "" {
+		message += "，已加入课程 " + user.Invite.ClassID
+	}
 	return &proto.RegisterResponse{
-		Token:   token,
-		Message: "注册成功",
+		Token:        token,
+		Message:      message,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
 	}, nil
 }
 
 // Login 登录方法
 func (s *AuthService) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
-	rightornot, err := database.VerifyPassword(req.Username, req.Password)
+	ip := utils.ClientIP(ctx)
+
+	// 用户名或 IP 处于锁定状态时直接拒绝，不再校验密码；未锁定时本次尝试先计入失败次数，登录成功后再移除
+	attempt, retryAfter, err := s.limiter.Begin(ctx, req.Username, ip)
 	if err != nil {
-		return nil, err
+		log.Printf("Login limiter check failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+	if retryAfter > 0 {
+		auditLogin(ctx, req.Username, database.AuditDenied, "locked")
+		return nil, lockedError(retryAfter)
+	}
+
+	// 由 auth.backend 配置的认证后端校验密码，LDAP 账号首次登录时创建本地用户
+	user, err := s.authenticator.Authenticate(ctx, req.Username, req.Password)
+	if errors.Is(err, utils.ErrNoDirectoryRole) {
+		auditLogin(ctx, req.Username, database.AuditDenied, "no directory role")
+		return nil, status.Errorf(codes.PermissionDenied, "账号不属于任何允许登录的组")
+	}
+	if err != nil && !errors.Is(err, utils.ErrInvalidCredentials) {
+		return nil, authenticatorError(err)
 	}
 
-	if !rightornot {
+	// 用户不存在与密码错误同样计入失败次数，避免泄露用户名是否存在
+	if err != nil {
+		retryAfter, err := s.limiter.RecordFailure(ctx, attempt)
+		if err != nil {
+			log.Printf("Login limiter record failed: %v", err)
+		}
+		if retryAfter > 0 {
+			log.Printf("Login locked: username=%s ip=%s retry_after=%s", req.Username, ip, retryAfter)
+			auditLogin(ctx, req.Username, database.AuditFailure, "invalid credentials, locked")
+			return nil, lockedError(retryAfter)
+		}
+		auditLogin(ctx, req.Username, database.AuditFailure, "invalid credentials")
 		return nil, status.Errorf(codes.InvalidArgument, "用户名或密码错误")
 	}
-	token := utils.GenerateToken(req.Username)
+	if err := s.limiter.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("Login limiter reset failed: %v", err)
+	}
+
+	if user.ServiceAccount {
+		auditLogin(ctx, user.Username, database.AuditDenied, "service account")
+		return nil, status.Errorf(codes.PermissionDenied, "服务账号只能使用 API Key 调用接口")
+	}
+	if user.Disabled {
+		auditLogin(ctx, user.Username, database.AuditDenied, "disabled")
+		return nil, status.Errorf(codes.PermissionDenied, "账号已被禁用")
+	}
+	if user.Pending {
+		auditLogin(ctx, user.Username, database.AuditDenied, "pending approval")
+		return nil, status.Errorf(codes.PermissionDenied, "账号等待管理员审批")
+	}
+	// 已启用两步验证或角色要求两步验证时，先返回第二步凭证，由 VerifySecondFactor 签发 Token
+	if user.TOTPEnabled || requiresSecondFactor(user.Role) {
+		return beginSecondFactor(ctx, user, req.Device)
+	}
+	token, refreshToken, err := completeLogin(ctx, user, req.Device)
+	if err != nil {
+		return nil, err
+	}
+	auditLogin(ctx, user.Username, database.AuditSuccess, "password")
 	return &proto.LoginResponse{
-		Token:   token,
-		Message: "登录成功",
+		Token:        token,
+		Message:      "登录成功",
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// authenticatorError 将认证后端的内部错误转换为 gRPC 错误
+func authenticatorError(err error) error {
+	log.Printf("Authenticate failed: %v", err)
+	if errors.Is(err, utils.ErrDirectoryUnavailable) {
+		return status.Errorf(codes.Unavailable, "统一身份认证服务暂时不可用")
+	}
+	return status.Errorf(codes.Internal, "failed to login")
+}
+
+// invalidFieldsError 将字段校验结果转换为 InvalidArgument 错误，逐个字段的原因放在 BadRequest 详情中
+func invalidFieldsError(violations []utils.FieldViolation) error {
+	descriptions := make([]string, 0, len(violations))
+	detail := &errdetails.BadRequest{}
+	for _, v := range violations {
+		descriptions = append(descriptions, v.Description)
+		detail.FieldViolations = append(detail.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	st := status.New(codes.InvalidArgument, strings.Join(descriptions, "；"))
+	detailed, err := st.WithDetails(detail)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// checkNewPassword 按密码规则检查新密码
+func checkNewPassword(field, password, username string) error {
+	if violations := utils.GetCredentialPolicy().ValidatePassword(field, password, username); len(violations) > 0 {
+		return invalidFieldsError(violations)
+	}
+	return nil
+}
+
+// confirmPassword 敏感操作前通过认证后端再次确认当前用户的密码
+func (s *AuthService) confirmPassword(ctx context.Context, username, password string) error {
+	user, err := s.authenticator.Authenticate(ctx, username, password)
+	if errors.Is(err, utils.ErrInvalidCredentials) || errors.Is(err, utils.ErrNoDirectoryRole) ||
+		(err == nil && user.Username != username) {
+		return status.Errorf(codes.PermissionDenied, "密码错误")
+	}
+	if err != nil {
+		return authenticatorError(err)
+	}
+	return nil
+}
+
+// localPasswordError 外部目录账号的密码不在本地保存，不能在本地修改或重置
+func localPasswordError(user *database.User) error {
+	if user.External() {
+		return status.Errorf(codes.FailedPrecondition, "该账号的密码由统一身份认证管理，请在学校目录中修改")
+	}
+	return nil
+}
+
+// RefreshToken 使用刷新 Token 换取新的访问 Token，刷新 Token 同时轮换
+func (s *AuthService) RefreshToken(ctx context.Context, req *proto.RefreshTokenRequest) (*proto.RefreshTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	session, refreshToken, err := utils.RotateRefreshToken(req.RefreshToken)
+	if errors.Is(err, utils.ErrInvalidRefreshToken) {
+		return nil, status.Errorf(codes.Unauthenticated, "刷新 Token 无效或已过期")
+	}
+	if err != nil {
+		log.Printf("RotateRefreshToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to refresh token")
+	}
+
+	// 每次刷新都从数据库读取最新角色
+	user, err := database.GetUser(session.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user no longer exists")
+	}
+	if user.Disabled {
+		return nil, status.Errorf(codes.PermissionDenied, "账号已被禁用")
+	}
+
+	token, err := utils.GenerateToken(user.Username, user.Role, session.ID)
+	if err != nil {
+		log.Printf("GenerateToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to refresh token")
+	}
+
+	return &proto.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// Logout 注销当前会话，会话的访问 Token 和刷新 Token 一并失效
+func (s *AuthService) Logout(ctx context.Context, req *proto.LogoutRequest) (*proto.LogoutResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := principal.Claims
+
+	if req.AllDevices {
+		if err := utils.RevokeUserTokens(claims.Username); err != nil {
+			log.Printf("RevokeUserTokens failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to logout")
+		}
+	} else {
+		if err := utils.RevokeToken(claims); err != nil {
+			log.Printf("RevokeToken failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to logout")
+		}
+		if claims.SessionID != "" {
+			if err := utils.RevokeSession(claims.Username, claims.SessionID); err != nil && !errors.Is(err, utils.ErrSessionNotFound) {
+				log.Printf("RevokeSession failed: %v", err)
+				return nil, status.Errorf(codes.Internal, "failed to logout")
+			}
+		}
+		if req.RefreshToken != "" {
+			if err := utils.RevokeRefreshToken(req.RefreshToken); err != nil {
+				log.Printf("RevokeRefreshToken failed: %v", err)
+				return nil, status.Errorf(codes.Internal, "failed to logout")
+			}
+		}
+	}
+
+	log.Printf("User logged out: %s (all_devices=%v)", claims.Username, req.AllDevices)
+	detail := ""
+	if req.AllDevices {
+		detail = "all devices"
+	}
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditLogout, Target: claims.Username, Outcome: database.AuditSuccess, Detail: detail,
+	})
+	return &proto.LogoutResponse{Message: "注销成功"}, nil
+}
+
+// ListRoleRequests 查询待审批的角色申请（仅管理员）
+func (s *AuthService) ListRoleRequests(ctx context.Context, req *proto.ListRoleRequestsRequest) (*proto.ListRoleRequestsResponse, error) {
+	users, err := database.ListRoleRequests()
+	if err != nil {
+		log.Printf("ListRoleRequests failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list role requests")
+	}
+
+	resp := &proto.ListRoleRequestsResponse{}
+	for _, user := range users {
+		resp.Requests = append(resp.Requests, &proto.RoleRequest{
+			Username:      user.Username,
+			CurrentRole:   user.Role,
+			RequestedRole: user.RequestedRole,
+		})
+	}
+	return resp, nil
+}
+
+// ApproveRole 审批或驳回用户的角色申请（仅管理员）
+func (s *AuthService) ApproveRole(ctx context.Context, req *proto.ApproveRoleRequest) (*proto.ApproveRoleResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := database.ResolveRoleRequest(req.Username, req.Approve)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+
+	log.Printf("Role request of %s resolved by %s: approve=%v, role=%s", user.Username, admin.Username, req.Approve, user.Role)
+	if req.Approve {
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Action: database.AuditRoleChange, Target: user.Username, Outcome: database.AuditSuccess,
+			Detail: "role request approved, role=" + user.Role,
+		})
+	}
+	message := "已驳回角色申请"
+	if req.Approve {
+		message = "已批准角色申请，重新登录或刷新 Token 后生效"
+	}
+	return &proto.ApproveRoleResponse{
+		Username: user.Username,
+		Role:     user.Role,
+		Message:  message,
 	}, nil
 }
+
+// UnlockAccount 解除用户的登录锁定（仅管理员）
+func (s *AuthService) UnlockAccount(ctx context.Context, req *proto.UnlockAccountRequest) (*proto.UnlockAccountResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Username == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "username is required")
+	}
+
+	if err := s.limiter.Unlock(ctx, req.Username); err != nil {
+		log.Printf("Unlock %s failed: %v", req.Username, err)
+		return nil, status.Errorf(codes.Internal, "failed to unlock account")
+	}
+
+	log.Printf("Account %s unlocked by %s", req.Username, admin.Username)
+	return &proto.UnlockAccountResponse{Message: "账号已解锁"}, nil
+}
+
+// issueTokens 创建登录会话并签发访问 Token 和刷新 Token，device 为空时根据 User-Agent 推断
+func issueTokens(ctx context.Context, username, role, device string) (string, string, error) {
+	ua := utils.UserAgent(ctx)
+	if device == "" {
+		device = describeDevice(ua)
+	}
+	session, err := utils.CreateSession(username, device, utils.ClientIP(ctx), ua)
+	if err != nil {
+		log.Printf("CreateSession failed: %v", err)
+		return "", "", status.Errorf(codes.Internal, "failed to issue token")
+	}
+	token, err := utils.GenerateToken(username, role, session.ID)
+	if err != nil {
+		log.Printf("GenerateToken failed: %v", err)
+		return "", "", status.Errorf(codes.Internal, "failed to issue token")
+	}
+	refreshToken, err := utils.GenerateRefreshToken(session)
+	if err != nil {
+		log.Printf("GenerateRefreshToken failed: %v", err)
+		return "", "", status.Errorf(codes.Internal, "failed to issue token")
+	}
+	return token, refreshToken, nil
+}
+
+// auditLogin 记录登录事件，登录失败时操作者为尝试登录的用户名
+func auditLogin(ctx context.Context, username, outcome, detail string) {
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Actor: username, Action: database.AuditLogin, Target: username, Outcome: outcome, Detail: detail,
+	})
+}
@@ -0,0 +1,119 @@
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/test/ldapstub"
+	"LanshanClass1.3/test/testenv"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ldapTeachers = "cn=teachers,ou=groups,dc=example,dc=org"
+	ldapStudents = "cn=students,ou=groups,dc=example,dc=org"
+)
+
+// setupLDAP 启动包含教师 alice 和学生 bob 的测试目录，并将认证后端切换为 LDAP
+func setupLDAP(t *testing.T) *AuthService {
+	testenv.Setup(t)
+	server := ldapstub.New(
+		ldapstub.Entry{DN: "uid=alice,ou=people,dc=example,dc=org", Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"alice"},
+			"cn":           {"Alice"},
+			"mail":         {"alice@example.org"},
+			"userPassword": {"alice123"},
+			"memberOf":     {ldapTeachers},
+		}},
+		ldapstub.Entry{DN: "uid=bob,ou=people,dc=example,dc=org", Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"bob"},
+			"cn":           {"Bob"},
+			"mail":         {"bob@example.org"},
+			"userPassword": {"bob123"},
+			"memberOf":     {ldapStudents},
+		}},
+	)
+	url, err := server.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start ldapstub: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	database.Config.Set("auth.backend", "ldap")
+	database.Config.Set("auth.ldap.url", url)
+	database.Config.Set("auth.ldap.base_dn", "ou=people,dc=example,dc=org")
+	database.Config.Set("auth.ldap.role_groups.teacher", []string{ldapTeachers})
+	database.Config.Set("auth.ldap.role_groups.student", []string{ldapStudents})
+	return NewAuthService()
+}
+
+func TestLoginWithLDAP(t *testing.T) {
+	s := setupLDAP(t)
+
+	resp, err := s.Login(context.Background(), &proto.LoginRequest{Username: "alice", Password: "alice123"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("response = %+v, want tokens", resp)
+	}
+
+	// 首次登录时按所属的组创建本地用户
+	user, err := database.GetUser("alice")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if !user.External() || user.Role != database.RoleTeacher || user.Email != "alice@example.org" {
+		t.Errorf("synced user = %+v", user)
+	}
+
+	// 再次登录使用同一个本地用户
+	if _, err := s.Login(context.Background(), &proto.LoginRequest{Username: "alice", Password: "alice123"}); err != nil {
+		t.Fatalf("second Login: %v", err)
+	}
+	if _, err := s.Login(context.Background(), &proto.LoginRequest{Username: "bob", Password: "bob123"}); err != nil {
+		t.Fatalf("Login bob: %v", err)
+	}
+	if bob, err := database.GetUser("bob"); err != nil || bob.Role != database.RoleStudent {
+		t.Errorf("bob = %+v, %v", bob, err)
+	}
+}
+
+func TestLoginWithLDAPRejectsWrongPassword(t *testing.T) {
+	s := setupLDAP(t)
+
+	for _, req := range []*proto.LoginRequest{
+		{Username: "alice", Password: "wrong"},
+		{Username: "alice", Password: ""},
+		{Username: "nobody", Password: "alice123"},
+	} {
+		_, err := s.Login(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Login(%s, %q): err = %v, want InvalidArgument", req.Username, req.Password, err)
+		}
+	}
+	if _, err := database.GetUser("nobody"); err == nil {
+		t.Error("failed login created a local user")
+	}
+}
+
+// 本地账号（如初始管理员）继续使用本地密码，目录中的同名账号不能接管
+func TestLoginWithLDAPLocalAccount(t *testing.T) {
+	s := setupLDAP(t)
+	if _, err := database.CreateUser(database.NewUser{Username: "alice", Password: "local-password1"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if _, err := s.Login(context.Background(), &proto.LoginRequest{Username: "alice", Password: "local-password1"}); err != nil {
+		t.Fatalf("local password: %v", err)
+	}
+	_, err := s.Login(context.Background(), &proto.LoginRequest{Username: "alice", Password: "alice123"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("directory password for a local account: err = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,217 @@
+// email.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// RequestPasswordReset 向邮箱发送重置密码邮件，为避免泄露邮箱是否注册，始终返回相同的结果
+func (s *AuthService) RequestPasswordReset(ctx context.Context, req *proto.RequestPasswordResetRequest) (*proto.RequestPasswordResetResponse, error) {
+	if !validEmail(req.Email) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid email address")
+	}
+	resp := &proto.RequestPasswordResetResponse{Message: "如果该邮箱已注册，重置密码邮件已发送"}
+
+	user, err := database.GetUserByEmail(req.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, nil
+	}
+	if err != nil {
+		log.Printf("GetUserByEmail failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to request password reset")
+	}
+	if user.Disabled || user.External() {
+		return resp, nil
+	}
+
+	token, err := utils.GenerateActionToken(utils.PurposeResetPassword, user.Username, user.Email,
+		database.Config.GetDuration("mail.reset_ttl"))
+	if err != nil {
+		log.Printf("GenerateActionToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to request password reset")
+	}
+	link := database.Config.GetString("mail.base_url") + "/password/reset?token=" + url.QueryEscape(token)
+	s.sendMail(utils.Mail{
+		To:      user.Email,
+		Subject: "重置 LanshanClass 密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置你的账号 %s 密码的请求，请在 %s 内打开以下链接设置新密码：\n\n%s\n\n"+
+			"也可以将下面的 Token 提交到 POST /password/reset：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件。\n",
+			user.Name(), user.Username, database.Config.GetDuration("mail.reset_ttl"), link, token),
+	})
+
+	log.Printf("Password reset requested: %s", user.Username)
+	return resp, nil
+}
+
+// ResetPassword 使用重置密码邮件中的 Token 设置新密码，该用户的全部登录随之失效
+func (s *AuthService) ResetPassword(ctx context.Context, req *proto.ResetPasswordWithTokenRequest) (*proto.ResetPasswordWithTokenResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+	if req.NewPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "new_password is required")
+	}
+
+	// 新密码通过校验后才将链接标记为已使用，新密码不符合要求时用户可以用同一个链接重试
+	claims, err := utils.VerifyActionToken(utils.PurposeResetPassword, req.Token)
+	if errors.Is(err, utils.ErrInvalidActionToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "链接无效、已过期或已被使用")
+	}
+	if err != nil {
+		log.Printf("VerifyActionToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to reset password")
+	}
+
+	// 发出邮件后更换了邮箱的，旧邮箱中的链接作废
+	user, err := database.GetUser(claims.Subject)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if user.Email != claims.Email {
+		return nil, status.Errorf(codes.InvalidArgument, "链接无效、已过期或已被使用")
+	}
+	if err := localPasswordError(user); err != nil {
+		return nil, err
+	}
+	if err := checkNewPassword("new_password", req.NewPassword, user.Username); err != nil {
+		return nil, err
+	}
+	err = utils.MarkActionTokenUsed(claims)
+	if errors.Is(err, utils.ErrInvalidActionToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "链接无效、已过期或已被使用")
+	}
+	if err != nil {
+		log.Printf("MarkActionTokenUsed failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to reset password")
+	}
+
+	if err := database.SetPassword(user.Username, req.NewPassword); err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := utils.RevokeUserTokens(user.Username); err != nil {
+		log.Printf("RevokeUserTokens failed: %v", err)
+	}
+	if err := s.limiter.Unlock(ctx, user.Username); err != nil {
+		log.Printf("Unlock failed: %v", err)
+	}
+
+	log.Printf("Password reset by email: %s", user.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Actor: user.Username, Action: database.AuditPasswordChange, Target: user.Username,
+		Outcome: database.AuditSuccess, Detail: "reset by email",
+	})
+	return &proto.ResetPasswordWithTokenResponse{Message: "密码已重置，请使用新密码登录"}, nil
+}
+
+// VerifyEmail 使用验证邮件中的 Token 验证邮箱
+func (s *AuthService) VerifyEmail(ctx context.Context, req *proto.VerifyEmailRequest) (*proto.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	claims, err := utils.ConsumeActionToken(utils.PurposeVerifyEmail, req.Token)
+	if errors.Is(err, utils.ErrInvalidActionToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "链接无效、已过期或已被使用")
+	}
+	if err != nil {
+		log.Printf("ConsumeActionToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to verify email")
+	}
+
+	err = database.MarkEmailVerified(claims.Subject, claims.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.FailedPrecondition, "邮箱已更换，请重新发送验证邮件")
+	}
+	if err != nil {
+		log.Printf("MarkEmailVerified failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to verify email")
+	}
+
+	log.Printf("Email verified: %s", claims.Subject)
+	return &proto.VerifyEmailResponse{Message: "邮箱验证成功"}, nil
+}
+
+// SendVerificationEmail 重新发送验证邮件
+func (s *AuthService) SendVerificationEmail(ctx context.Context, req *proto.SendVerificationEmailRequest) (*proto.SendVerificationEmailResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := database.GetUser(principal.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if user.Email == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "请先设置邮箱")
+	}
+	if user.EmailVerified {
+		return nil, status.Errorf(codes.FailedPrecondition, "邮箱已验证")
+	}
+
+	if err := s.sendVerificationEmail(user); err != nil {
+		return nil, err
+	}
+	return &proto.SendVerificationEmailResponse{Message: "验证邮件已发送"}, nil
+}
+
+// sendVerificationEmail 签发邮箱验证 Token 并发送验证邮件
+func (s *AuthService) sendVerificationEmail(user *database.User) error {
+	ttl := database.Config.GetDuration("mail.verification_ttl")
+	token, err := utils.GenerateActionToken(utils.PurposeVerifyEmail, user.Username, user.Email, ttl)
+	if err != nil {
+		log.Printf("GenerateActionToken failed: %v", err)
+		return status.Errorf(codes.Internal, "failed to send verification email")
+	}
+	link := database.Config.GetString("mail.base_url") + "/email/verify?token=" + url.QueryEscape(token)
+	s.sendMail(utils.Mail{
+		To:      user.Email,
+		Subject: "验证你的 LanshanClass 邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %s 内打开以下链接验证你的邮箱：\n\n%s\n\n如果这不是你本人的操作，请忽略本邮件。\n",
+			user.Name(), ttl, link),
+	})
+	return nil
+}
+
+// sendMail 在后台发送邮件，发送耗时不影响接口响应，也避免通过响应时间推断邮箱是否注册
+func (s *AuthService) sendMail(m utils.Mail) {
+	go func() {
+		if err := s.mailer.Send(context.Background(), m); err != nil {
+			log.Printf("Send mail failed: %v", err)
+		}
+	}()
+}
+
+// validEmail 判断是否为合法的纯邮箱地址（不含显示名）
+func validEmail(email string) bool {
+	if email == "" || strings.ContainsAny(email, "\r\n") {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// checkEmailAvailable 检查邮箱是否已被其他用户使用
+func checkEmailAvailable(email, username string) error {
+	inUse, err := database.EmailInUse(email, username)
+	if err != nil {
+		log.Printf("EmailInUse failed: %v", err)
+		return status.Errorf(codes.Internal, "internal error")
+	}
+	if inUse {
+		return status.Errorf(codes.AlreadyExists, "该邮箱已被使用")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// invite.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// inviteCodeChars 邀请码字符集，只含大写字母和数字并去掉了容易混淆的字符，方便口头或板书传达
+const inviteCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// inviteCodeLength 邀请码长度
+const inviteCodeLength = 10
+
+// CreateInvite 签发邀请码
+func (s *AdminService) CreateInvite(ctx context.Context, req *proto.CreateInviteRequest) (*proto.Invite, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = database.RoleStudent
+	}
+	if !database.ValidRole(role) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role: %s", role)
+	}
+	if req.MaxUses < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "max_uses must not be negative")
+	}
+	if req.ExpiresIn < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "expires_in must not be negative")
+	}
+	if req.ClassId != "" {
+		class, err := database.GetLiveClass(req.ClassId)
+		if errors.Is(err, database.ErrClassNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "live class not found: %s", req.ClassId)
+		}
+		if err != nil {
+			log.Printf("GetLiveClass failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to create invite")
+		}
+		if class.Status == database.ClassEnded || class.Status == database.ClassArchived {
+			return nil, status.Errorf(codes.FailedPrecondition, "直播课已结束")
+		}
+	}
+
+	code, err := randomString(inviteCodeChars, inviteCodeLength)
+	if err != nil {
+		log.Printf("randomString failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create invite")
+	}
+	invite := &database.Invite{
+		Code:      code,
+		Role:      role,
+		ClassID:   req.ClassId,
+		MaxUses:   int(req.MaxUses),
+		CreatedBy: admin.Username,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+	if err := database.CreateInvite(invite); err != nil {
+		log.Printf("CreateInvite failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to create invite")
+	}
+
+	log.Printf("Invite %s created by %s: role=%s class=%s max_uses=%d", invite.Code, admin.Username, invite.Role, invite.ClassID, invite.MaxUses)
+	return toInvite(invite), nil
+}
+
+// ListInvites 查询邀请码
+func (s *AdminService) ListInvites(ctx context.Context, req *proto.ListInvitesRequest) (*proto.ListInvitesResponse, error) {
+	invites, err := database.ListInvites(req.IncludeInactive)
+	if err != nil {
+		log.Printf("ListInvites failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list invites")
+	}
+
+	resp := &proto.ListInvitesResponse{}
+	for i := range invites {
+		resp.Invites = append(resp.Invites, toInvite(&invites[i]))
+	}
+	return resp, nil
+}
+
+// RevokeInvite 撤销邀请码，已使用该邀请码注册的账号不受影响
+func (s *AdminService) RevokeInvite(ctx context.Context, req *proto.RevokeInviteRequest) (*proto.Invite, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := database.RevokeInvite(req.Code)
+	if err != nil {
+		return nil, inviteLookupError(err)
+	}
+
+	log.Printf("Invite %s revoked by %s", invite.Code, admin.Username)
+	return toInvite(invite), nil
+}
+
+// toInvite 将数据库邀请码转换为消息
+func toInvite(invite *database.Invite) *proto.Invite {
+	i := &proto.Invite{
+		Code:      invite.Code,
+		Role:      invite.Role,
+		ClassId:   invite.ClassID,
+		MaxUses:   int32(invite.MaxUses),
+		Uses:      int32(invite.Uses),
+		Revoked:   invite.Revoked,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt.Unix(),
+	}
+	if invite.ExpiresAt != nil {
+		i.ExpiresAt = invite.ExpiresAt.Unix()
+	}
+	return i
+}
+
+// inviteLookupError 将邀请码查询错误转换为 gRPC 错误
+func inviteLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "invite not found")
+	}
+	log.Printf("Invite lookup failed: %v", err)
+	return status.Errorf(codes.Internal, "internal error")
+}
@@ -0,0 +1,203 @@
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Redis 键前缀
+const (
+	loginFailKeyPrefix      = "login:fail:"      // 滑动窗口内的失败记录（有序集合，score 为时间戳）
+	loginLockKeyPrefix      = "login:lock:"      // 锁定标记，过期即解锁
+	loginLockCountKeyPrefix = "login:lockcount:" // 连续锁定次数，用于逐级延长锁定时间
+)
+
+// lockCountTTL 锁定次数的保留时间，超过该时间未再次被锁定则从第一级重新计算
+const lockCountTTL = 24 * time.Hour
+
+// loginLimiter 基于 Redis 的登录失败计数与锁定
+type loginLimiter struct {
+	window         time.Duration // 失败次数统计窗口
+	maxUserFailure int64         // 单个用户名在窗口内允许的失败次数
+	maxIPFailure   int64         // 单个 IP 在窗口内允许的失败次数
+	lockoutBase    time.Duration // 首次锁定时长，之后每次翻倍
+	lockoutMax     time.Duration // 锁定时长上限
+}
+
+// newLoginLimiter 从配置创建登录限流器
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		window:         database.Config.GetDuration("login_limit.window"),
+		maxUserFailure: database.Config.GetInt64("login_limit.max_failures_per_user"),
+		maxIPFailure:   database.Config.GetInt64("login_limit.max_failures_per_ip"),
+		lockoutBase:    database.Config.GetDuration("login_limit.lockout_base"),
+		lockoutMax:     database.Config.GetDuration("login_limit.lockout_max"),
+	}
+}
+
+// subjects 返回需要限流的维度，IP 为空时只按用户名限流
+func subjects(username, ip string) []string {
+	keys := []string{"user:" + username}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+// limit 返回维度在窗口内允许的失败次数
+func (l *loginLimiter) limit(subject string) int64 {
+	if strings.HasPrefix(subject, "ip:") {
+		return l.maxIPFailure
+	}
+	return l.maxUserFailure
+}
+
+// beginAttemptScript 检查锁定并占用一次尝试，检查和计数在同一个脚本中完成，并发请求不会同时通过检查
+// KEYS 为每个维度的锁定键和失败记录键，ARGV 为当前时间、窗口起点、本次尝试的成员、窗口毫秒数和每个维度的上限
+// 返回剩余锁定毫秒数；返回 -1 表示窗口内的失败和进行中的尝试已达上限；返回 0 表示已占用
+var beginAttemptScript = redis.NewScript(`
+local wait = 0
+for i = 1, #KEYS, 2 do
+	local ttl = redis.call("PTTL", KEYS[i])
+	if ttl > wait then
+		wait = ttl
+	end
+end
+if wait > 0 then
+	return wait
+end
+for i = 1, #KEYS, 2 do
+	redis.call("ZREMRANGEBYSCORE", KEYS[i + 1], "-inf", ARGV[2])
+	if redis.call("ZCARD", KEYS[i + 1]) >= tonumber(ARGV[4 + (i + 1) / 2]) then
+		return -1
+	end
+end
+for i = 1, #KEYS, 2 do
+	redis.call("ZADD", KEYS[i + 1], ARGV[1], ARGV[3])
+	redis.call("PEXPIRE", KEYS[i + 1], ARGV[4])
+end
+return 0
+`)
+
+// recordFailureScript 尝试失败后，窗口内的失败次数达到上限的维度按连续锁定次数逐级延长锁定时间
+// KEYS 为每个维度的锁定键、失败记录键和锁定次数键，ARGV 为窗口起点、首次锁定毫秒数、锁定上限毫秒数、锁定次数保留毫秒数和每个维度的上限
+// 返回最长的锁定毫秒数，未锁定时返回 0
+var recordFailureScript = redis.NewScript(`
+local result = 0
+for i = 1, #KEYS, 3 do
+	redis.call("ZREMRANGEBYSCORE", KEYS[i + 1], "-inf", ARGV[1])
+	if redis.call("ZCARD", KEYS[i + 1]) >= tonumber(ARGV[4 + (i + 2) / 3]) then
+		local level = redis.call("INCR", KEYS[i + 2])
+		redis.call("PEXPIRE", KEYS[i + 2], ARGV[4])
+		local lockout = tonumber(ARGV[2]) * 2 ^ (level - 1)
+		if lockout > tonumber(ARGV[3]) or lockout <= 0 then
+			lockout = tonumber(ARGV[3])
+		end
+		lockout = math.floor(lockout)
+		redis.call("SET", KEYS[i], level, "PX", lockout)
+		redis.call("DEL", KEYS[i + 1])
+		if lockout > result then
+			result = lockout
+		end
+	end
+end
+return result
+`)
+
+// busyRetryAfter 窗口内的失败和进行中的尝试已达上限、但尚未锁定时建议的重试间隔
+const busyRetryAfter = time.Second
+
+// loginAttempt 一次已占用计数的登录尝试，结束时调用 RecordFailure 或 RecordSuccess
+type loginAttempt struct {
+	username string
+	ip       string
+	member   string // 本次尝试在失败记录中的成员
+}
+
+// Begin 检查用户名和 IP 是否处于锁定状态，未锁定时先将本次尝试计入失败次数，返回剩余锁定时间
+// 尝试在校验密码之前计数，并发的密码尝试最多只有上限次数能通过；只有调用 RecordSuccess 的尝试才会从失败次数中移除
+func (l *loginLimiter) Begin(ctx context.Context, username, ip string) (*loginAttempt, time.Duration, error) {
+	now := time.Now()
+	attempt := &loginAttempt{
+		username: username,
+		ip:       ip,
+		member:   strconv.FormatInt(now.UnixNano(), 10) + ":" + strconv.FormatUint(rand.Uint64(), 36),
+	}
+	var keys []string
+	args := []interface{}{now.UnixNano(), now.Add(-l.window).UnixNano(), attempt.member, l.window.Milliseconds()}
+	for _, subject := range subjects(username, ip) {
+		keys = append(keys, loginLockKeyPrefix+subject, loginFailKeyPrefix+subject)
+		args = append(args, l.limit(subject))
+	}
+	wait, err := beginAttemptScript.Run(ctx, database.RedisClient, keys, args...).Int64()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to check login limit: %w", err)
+	}
+	switch {
+	case wait < 0:
+		return nil, busyRetryAfter, nil
+	case wait > 0:
+		return nil, time.Duration(wait) * time.Millisecond, nil
+	}
+	return attempt, 0, nil
+}
+
+// RecordFailure 记录尝试失败，达到阈值时锁定并返回锁定时长
+func (l *loginLimiter) RecordFailure(ctx context.Context, attempt *loginAttempt) (time.Duration, error) {
+	var keys []string
+	args := []interface{}{time.Now().Add(-l.window).UnixNano(), l.lockoutBase.Milliseconds(),
+		l.lockoutMax.Milliseconds(), lockCountTTL.Milliseconds()}
+	for _, subject := range subjects(attempt.username, attempt.ip) {
+		keys = append(keys, loginLockKeyPrefix+subject, loginFailKeyPrefix+subject, loginLockCountKeyPrefix+subject)
+		args = append(args, l.limit(subject))
+	}
+	lockout, err := recordFailureScript.Run(ctx, database.RedisClient, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return time.Duration(lockout) * time.Millisecond, nil
+}
+
+// RecordSuccess 登录成功后清除该用户名的失败记录和锁定级别，并将本次尝试从 IP 的失败次数中移除
+func (l *loginLimiter) RecordSuccess(ctx context.Context, attempt *loginAttempt) error {
+	subject := "user:" + attempt.username
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Del(ctx, loginFailKeyPrefix+subject, loginLockCountKeyPrefix+subject)
+	if attempt.ip != "" {
+		pipe.ZRem(ctx, loginFailKeyPrefix+"ip:"+attempt.ip, attempt.member)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Unlock 解除用户名的锁定（管理员操作）
+func (l *loginLimiter) Unlock(ctx context.Context, username string) error {
+	subject := "user:" + username
+	return database.RedisClient.Del(ctx,
+		loginLockKeyPrefix+subject, loginFailKeyPrefix+subject, loginLockCountKeyPrefix+subject).Err()
+}
+
+// lockedError 构造带重试时间的 ResourceExhausted 错误
+func lockedError(retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	st := status.Newf(codes.ResourceExhausted, "登录失败次数过多，请 %d 秒后重试", seconds)
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,132 @@
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/test/testenv"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setupLimiter 每个用户名最多失败 3 次，首次锁定 1 分钟
+func setupLimiter(t *testing.T) (*testenv.Env, *loginLimiter) {
+	env := testenv.Setup(t)
+	database.Config.Set("login_limit.max_failures_per_user", 3)
+	database.Config.Set("login_limit.max_failures_per_ip", 10)
+	database.Config.Set("login_limit.lockout_base", "1m")
+	database.Config.Set("login_limit.lockout_max", "10m")
+	return env, newLoginLimiter()
+}
+
+// fail 完成一次失败的尝试，返回锁定时长
+func fail(t *testing.T, l *loginLimiter, username, ip string) time.Duration {
+	t.Helper()
+	ctx := context.Background()
+	attempt, retryAfter, err := l.Begin(ctx, username, ip)
+	if err != nil || retryAfter > 0 {
+		t.Fatalf("Begin: retryAfter = %v, err = %v", retryAfter, err)
+	}
+	lockout, err := l.RecordFailure(ctx, attempt)
+	if err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	return lockout
+}
+
+func TestLoginLimiterLockout(t *testing.T) {
+	env, l := setupLimiter(t)
+	ctx := context.Background()
+
+	for i := 1; i < 3; i++ {
+		if lockout := fail(t, l, "alice", "10.0.0.1"); lockout != 0 {
+			t.Fatalf("failure %d locked for %v", i, lockout)
+		}
+	}
+	if lockout := fail(t, l, "alice", "10.0.0.1"); lockout != time.Minute {
+		t.Fatalf("third failure: lockout = %v, want 1m", lockout)
+	}
+
+	// 锁定期间从其他 IP 登录同样被拒绝
+	attempt, retryAfter, err := l.Begin(ctx, "alice", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if attempt != nil || retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("locked account: attempt = %v, retryAfter = %v", attempt, retryAfter)
+	}
+	// 其他用户不受影响
+	if attempt, retryAfter, _ := l.Begin(ctx, "bob", "10.0.0.1"); attempt == nil || retryAfter != 0 {
+		t.Fatalf("other user: attempt = %v, retryAfter = %v", attempt, retryAfter)
+	}
+
+	// 解锁后再次达到上限，锁定时间翻倍
+	env.Redis.FastForward(time.Minute)
+	fail(t, l, "alice", "10.0.0.1")
+	fail(t, l, "alice", "10.0.0.1")
+	if lockout := fail(t, l, "alice", "10.0.0.1"); lockout != 2*time.Minute {
+		t.Fatalf("second lockout = %v, want 2m", lockout)
+	}
+
+	if err := l.Unlock(ctx, "alice"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if attempt, retryAfter, _ := l.Begin(ctx, "alice", "10.0.0.1"); attempt == nil || retryAfter != 0 {
+		t.Fatalf("after unlock: attempt = %v, retryAfter = %v", attempt, retryAfter)
+	}
+}
+
+func TestLoginLimiterSuccessResets(t *testing.T) {
+	_, l := setupLimiter(t)
+	ctx := context.Background()
+
+	fail(t, l, "alice", "10.0.0.1")
+	fail(t, l, "alice", "10.0.0.1")
+	attempt, _, err := l.Begin(ctx, "alice", "10.0.0.1")
+	if err != nil || attempt == nil {
+		t.Fatalf("Begin: attempt = %v, err = %v", attempt, err)
+	}
+	if err := l.RecordSuccess(ctx, attempt); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+
+	for i := 1; i < 3; i++ {
+		if lockout := fail(t, l, "alice", "10.0.0.1"); lockout != 0 {
+			t.Fatalf("failure %d after success locked for %v", i, lockout)
+		}
+	}
+}
+
+// 并发的尝试在校验密码之前计数，同时通过检查的尝试不会超过上限
+func TestLoginLimiterConcurrentAttempts(t *testing.T) {
+	_, l := setupLimiter(t)
+	ctx := context.Background()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, retryAfter, err := l.Begin(ctx, "alice", "")
+			if err != nil {
+				t.Errorf("Begin: %v", err)
+				return
+			}
+			if attempt != nil {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			} else if retryAfter != busyRetryAfter {
+				t.Errorf("rejected attempt: retryAfter = %v, want %v", retryAfter, busyRetryAfter)
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted != 3 {
+		t.Fatalf("admitted %d concurrent attempts, want 3", admitted)
+	}
+}
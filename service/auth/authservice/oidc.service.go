@@ -0,0 +1,426 @@
+// oidc.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// oidcCodeKeyPrefix 授权码 -> {client_id, redirect_uri, username, scope, nonce, code_challenge, auth_time}
+const oidcCodeKeyPrefix = "oidc:code:"
+
+// oauthErrorDomain 标记错误详情中的 OAuth 2.0 错误码，网关据此生成标准错误响应
+const oauthErrorDomain = "oauth2"
+
+// OIDCService 实现了 proto.OIDCServiceServer 接口，让其他应用使用 LanshanClass 账号单点登录
+type OIDCService struct {
+	proto.UnimplementedOIDCServiceServer
+	limiter       *loginLimiter
+	authenticator utils.Authenticator
+}
+
+// NewOIDCService 初始化服务，与密码登录共用失败次数限制
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		limiter:       newLoginLimiter(),
+		authenticator: utils.NewAuthenticator(),
+	}
+}
+
+// RegisterClient 注册客户端，机密客户端的 client_secret 只在此时返回一次
+func (s *OIDCService) RegisterClient(ctx context.Context, req *proto.RegisterClientRequest) (*proto.RegisterClientResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required and must not exceed 100 characters")
+	}
+	if len(req.RedirectUris) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one redirect_uri is required")
+	}
+	for _, uri := range req.RedirectUris {
+		if err := validRedirectURI(uri); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid redirect_uri %q: %v", uri, err)
+		}
+	}
+
+	clientID, err := randomString(inviteCodeChars, 20)
+	if err != nil {
+		log.Printf("randomString failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to register client")
+	}
+	client := &database.OIDCClient{
+		ClientID:     strings.ToLower(clientID),
+		Name:         name,
+		RedirectURIs: strings.Join(req.RedirectUris, "\n"),
+		Public:       req.Public,
+		CreatedBy:    admin.Username,
+	}
+	var secret string
+	if !req.Public {
+		if secret, err = randomToken(); err != nil {
+			log.Printf("randomToken failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to register client")
+		}
+		client.SecretHash = hashClientSecret(secret)
+	}
+	if err := database.CreateOIDCClient(client); err != nil {
+		log.Printf("CreateOIDCClient failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to register client")
+	}
+
+	log.Printf("OIDC client %s (%s) registered by %s", client.ClientID, client.Name, admin.Username)
+	return &proto.RegisterClientResponse{Client: toOIDCClient(client), ClientSecret: secret}, nil
+}
+
+// ListClients 查询全部客户端
+func (s *OIDCService) ListClients(ctx context.Context, req *proto.ListClientsRequest) (*proto.ListClientsResponse, error) {
+	clients, err := database.ListOIDCClients()
+	if err != nil {
+		log.Printf("ListOIDCClients failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list clients")
+	}
+	resp := &proto.ListClientsResponse{}
+	for i := range clients {
+		resp.Clients = append(resp.Clients, toOIDCClient(&clients[i]))
+	}
+	return resp, nil
+}
+
+// DeleteClient 删除客户端，已签发的 Token 在过期前仍然有效
+func (s *OIDCService) DeleteClient(ctx context.Context, req *proto.DeleteClientRequest) (*proto.DeleteClientResponse, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found, err := database.DeleteOIDCClient(req.ClientId)
+	if err != nil {
+		log.Printf("DeleteOIDCClient failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to delete client")
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "client not found")
+	}
+	log.Printf("OIDC client %s deleted by %s", req.ClientId, admin.Username)
+	return &proto.DeleteClientResponse{Message: "客户端已删除"}, nil
+}
+
+// CheckAuthorize 校验授权请求，网关在展示登录页面之前调用
+func (s *OIDCService) CheckAuthorize(ctx context.Context, req *proto.AuthorizeParams) (*proto.CheckAuthorizeResponse, error) {
+	client, err := checkAuthorizeParams(req)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.CheckAuthorizeResponse{ClientName: client.Name}, nil
+}
+
+// Authorize 校验用户名和密码（以及两步验证码），通过后签发授权码
+func (s *OIDCService) Authorize(ctx context.Context, req *proto.AuthorizeRequest) (*proto.AuthorizeResponse, error) {
+	params := req.Params
+	if params == nil {
+		params = &proto.AuthorizeParams{}
+	}
+	client, err := checkAuthorizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	ip := utils.ClientIP(ctx)
+	detail := "oidc client=" + client.ClientID
+
+	attempt, retryAfter, err := s.limiter.Begin(ctx, req.Username, ip)
+	if err != nil {
+		log.Printf("Login limiter check failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+	if retryAfter > 0 {
+		auditLogin(ctx, req.Username, database.AuditDenied, detail+", locked")
+		return nil, lockedError(retryAfter)
+	}
+
+	user, err := s.authenticator.Authenticate(ctx, req.Username, req.Password)
+	if errors.Is(err, utils.ErrNoDirectoryRole) {
+		auditLogin(ctx, req.Username, database.AuditDenied, detail+", no directory role")
+		return nil, status.Errorf(codes.PermissionDenied, "账号不属于任何允许登录的组")
+	}
+	if errors.Is(err, utils.ErrInvalidCredentials) {
+		return nil, s.loginFailure(ctx, attempt, detail+", invalid credentials", "用户名或密码错误")
+	}
+	if err != nil {
+		return nil, authenticatorError(err)
+	}
+	switch {
+	case user.ServiceAccount:
+		auditLogin(ctx, user.Username, database.AuditDenied, detail+", service account")
+		return nil, status.Errorf(codes.PermissionDenied, "服务账号不能登录")
+	case user.Disabled:
+		auditLogin(ctx, user.Username, database.AuditDenied, detail+", disabled")
+		return nil, status.Errorf(codes.PermissionDenied, "账号已被禁用")
+	case user.Pending:
+		auditLogin(ctx, user.Username, database.AuditDenied, detail+", pending approval")
+		return nil, status.Errorf(codes.PermissionDenied, "账号等待管理员审批")
+	}
+
+	// 单点登录页面不支持绑定两步验证，角色要求两步验证的用户需先在 LanshanClass 中完成绑定
+	if user.TOTPEnabled {
+		ok, err := checkTOTP(ctx, user.Username, user.TOTPSecret, req.TotpCode)
+		if err != nil {
+			log.Printf("checkTOTP failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to login")
+		}
+		if !ok {
+			return nil, s.loginFailure(ctx, attempt, detail+", invalid second factor", "两步验证码错误")
+		}
+	} else if requiresSecondFactor(user.Role) {
+		return nil, status.Errorf(codes.FailedPrecondition, "请先登录 LanshanClass 绑定两步验证")
+	}
+
+	if err := s.limiter.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("Login limiter reset failed: %v", err)
+	}
+	if err := database.RecordLogin(user.Username); err != nil {
+		log.Printf("RecordLogin failed: %v", err)
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		log.Printf("randomToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+	key := oidcCodeKeyPrefix + code
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"client_id":      client.ClientID,
+		"redirect_uri":   params.RedirectUri,
+		"username":       user.Username,
+		"scope":          normalizeOIDCScope(params.Scope),
+		"nonce":          params.Nonce,
+		"code_challenge": params.CodeChallenge,
+		"auth_time":      time.Now().Unix(),
+	})
+	pipe.Expire(ctx, key, database.Config.GetDuration("oidc.code_ttl"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Store OIDC code failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+
+	auditLogin(ctx, user.Username, database.AuditSuccess, detail)
+	return &proto.AuthorizeResponse{Code: code}, nil
+}
+
+// Token 使用授权码换取 Access Token 和 ID Token
+func (s *OIDCService) Token(ctx context.Context, req *proto.TokenRequest) (*proto.TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, oauthError(codes.InvalidArgument, "unsupported_grant_type", "only authorization_code is supported")
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauthError(codes.InvalidArgument, "invalid_request", "code and code_verifier are required")
+	}
+
+	client, err := database.GetOIDCClient(req.ClientId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("GetOIDCClient failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to issue token")
+		}
+		return nil, oauthError(codes.Unauthenticated, "invalid_client", "client authentication failed")
+	}
+	if !client.Public {
+		hash := hashClientSecret(req.ClientSecret)
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 {
+			return nil, oauthError(codes.Unauthenticated, "invalid_client", "client authentication failed")
+		}
+	}
+
+	// 授权码只能使用一次，并发请求中只有删除成功的一方继续
+	key := oidcCodeKeyPrefix + req.Code
+	pipe := database.RedisClient.TxPipeline()
+	get := pipe.HGetAll(ctx, key)
+	del := pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Load OIDC code failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to issue token")
+	}
+	grant := get.Val()
+	if del.Val() == 0 || len(grant) == 0 {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "authorization code is invalid or expired")
+	}
+	if grant["client_id"] != client.ClientID || grant["redirect_uri"] != req.RedirectUri {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "authorization code was issued to another client or redirect_uri")
+	}
+	if !utils.VerifyPKCE(req.CodeVerifier, grant["code_challenge"]) {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	user, err := database.GetUser(grant["username"])
+	if err != nil || user.Disabled {
+		return nil, oauthError(codes.InvalidArgument, "invalid_grant", "user is no longer available")
+	}
+
+	scope := grant["scope"]
+	authTime, _ := strconv.ParseInt(grant["auth_time"], 10, 64)
+	accessToken, err := utils.GenerateOIDCAccessToken(user.Username, client.ClientID, scope)
+	if err != nil {
+		log.Printf("GenerateOIDCAccessToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to issue token")
+	}
+	idToken, err := utils.GenerateIDToken(user, client.ClientID, scope, grant["nonce"], time.Unix(authTime, 0))
+	if err != nil {
+		log.Printf("GenerateIDToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to issue token")
+	}
+
+	return &proto.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(database.Config.GetDuration("oidc.access_token_ttl").Seconds()),
+		IdToken:     idToken,
+		Scope:       scope,
+	}, nil
+}
+
+// UserInfo 根据 Access Token 返回用户信息，返回的声明取决于授权时的 scope
+func (s *OIDCService) UserInfo(ctx context.Context, req *proto.UserInfoRequest) (*proto.UserInfoResponse, error) {
+	claims, err := utils.ParseOIDCAccessToken(req.AccessToken)
+	if err != nil {
+		return nil, oauthError(codes.Unauthenticated, "invalid_token", "access token is invalid or expired")
+	}
+	user, err := database.GetUser(claims.Subject)
+	if err != nil || user.Disabled {
+		return nil, oauthError(codes.Unauthenticated, "invalid_token", "user is no longer available")
+	}
+
+	resp := &proto.UserInfoResponse{Sub: user.Username}
+	if utils.HasScope(claims.Scope, utils.ScopeProfile) {
+		resp.PreferredUsername = user.Username
+		resp.Name = user.Name()
+		resp.Picture = user.AvatarURL
+		resp.Role = user.Role
+	}
+	if utils.HasScope(claims.Scope, utils.ScopeEmail) {
+		resp.Email = user.Email
+		resp.EmailVerified = user.EmailVerified
+	}
+	return resp, nil
+}
+
+// loginFailure 记录一次登录失败，达到上限时返回锁定错误
+func (s *OIDCService) loginFailure(ctx context.Context, attempt *loginAttempt, detail, message string) error {
+	retryAfter, err := s.limiter.RecordFailure(ctx, attempt)
+	if err != nil {
+		log.Printf("Login limiter record failed: %v", err)
+	}
+	if retryAfter > 0 {
+		log.Printf("Login locked: username=%s ip=%s retry_after=%s", attempt.username, attempt.ip, retryAfter)
+		auditLogin(ctx, attempt.username, database.AuditFailure, detail+", locked")
+		return lockedError(retryAfter)
+	}
+	auditLogin(ctx, attempt.username, database.AuditFailure, detail)
+	return status.Errorf(codes.InvalidArgument, "%s", message)
+}
+
+// checkAuthorizeParams 校验授权请求参数
+// client_id 或 redirect_uri 无效时错误码为 invalid_client / invalid_redirect_uri，网关不能重定向回客户端
+func checkAuthorizeParams(p *proto.AuthorizeParams) (*database.OIDCClient, error) {
+	client, err := database.GetOIDCClient(p.ClientId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("GetOIDCClient failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "internal error")
+		}
+		return nil, oauthError(codes.InvalidArgument, "invalid_client", "unknown client_id")
+	}
+	if !client.AllowsRedirectURI(p.RedirectUri) {
+		return nil, oauthError(codes.InvalidArgument, "invalid_redirect_uri", "redirect_uri is not registered for this client")
+	}
+	if p.ResponseType != "code" {
+		return nil, oauthError(codes.InvalidArgument, "unsupported_response_type", "only response_type=code is supported")
+	}
+	if !utils.HasScope(p.Scope, utils.ScopeOpenID) {
+		return nil, oauthError(codes.InvalidArgument, "invalid_scope", "scope must include openid")
+	}
+	if p.CodeChallenge == "" || p.CodeChallengeMethod != "S256" {
+		return nil, oauthError(codes.InvalidArgument, "invalid_request", "PKCE with code_challenge_method=S256 is required")
+	}
+	return client, nil
+}
+
+// normalizeOIDCScope 去掉不支持的 scope
+func normalizeOIDCScope(scope string) string {
+	var result []string
+	for _, s := range []string{utils.ScopeOpenID, utils.ScopeProfile, utils.ScopeEmail} {
+		if utils.HasScope(scope, s) {
+			result = append(result, s)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// validRedirectURI 回调地址必须是不带片段的绝对地址，除本机地址外必须使用 HTTPS
+func validRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" || u.Fragment != "" || strings.Contains(uri, "\n") {
+		return errors.New("must be an absolute URL without fragment")
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" || net.ParseIP(host).IsLoopback() {
+			return nil
+		}
+	}
+	return fmt.Errorf("scheme %q is not allowed, use https", u.Scheme)
+}
+
+// hashClientSecret 计算 client_secret 的哈希
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// oauthError 返回带有 OAuth 2.0 错误码的 gRPC 错误
+func oauthError(code codes.Code, reason, message string) error {
+	st := status.New(code, message)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: oauthErrorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// toOIDCClient 将数据库中的客户端转换为 proto 消息
+func toOIDCClient(c *database.OIDCClient) *proto.OIDCClient {
+	return &proto.OIDCClient{
+		ClientId:     c.ClientID,
+		Name:         c.Name,
+		RedirectUris: c.RedirectURIList(),
+		Public:       c.Public,
+		CreatedBy:    c.CreatedBy,
+		CreatedAt:    c.CreatedAt.Unix(),
+	}
+}
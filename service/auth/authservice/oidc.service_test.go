@@ -0,0 +1,130 @@
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/test/testenv"
+	"LanshanClass1.3/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testClientID    = "test-client"
+	testRedirectURI = "http://localhost:9999/callback"
+)
+
+// setupOIDC 创建用户 alice 和一个公开客户端
+func setupOIDC(t *testing.T) *OIDCService {
+	testenv.Setup(t)
+	if _, err := database.CreateUser(database.NewUser{Username: "alice", Password: "alice-password1"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	client := &database.OIDCClient{
+		ClientID:     testClientID,
+		Name:         "Test",
+		RedirectURIs: testRedirectURI,
+		Public:       true,
+		CreatedBy:    "admin",
+	}
+	if err := database.CreateOIDCClient(client); err != nil {
+		t.Fatalf("CreateOIDCClient: %v", err)
+	}
+	return NewOIDCService()
+}
+
+// pkceChallenge 按 S256 方法计算 code_challenge
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorize 以 alice 的身份登录并返回授权码
+func authorize(t *testing.T, s *OIDCService, verifier string) string {
+	t.Helper()
+	resp, err := s.Authorize(context.Background(), &proto.AuthorizeRequest{
+		Params: &proto.AuthorizeParams{
+			ClientId:            testClientID,
+			RedirectUri:         testRedirectURI,
+			ResponseType:        "code",
+			Scope:               "openid profile",
+			Nonce:               "n-0S6_WzA2Mj",
+			CodeChallenge:       pkceChallenge(verifier),
+			CodeChallengeMethod: "S256",
+		},
+		Username: "alice",
+		Password: "alice-password1",
+	})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return resp.Code
+}
+
+func tokenRequest(code, verifier string) *proto.TokenRequest {
+	return &proto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectUri:  testRedirectURI,
+		ClientId:     testClientID,
+		CodeVerifier: verifier,
+	}
+}
+
+func TestOIDCTokenExchange(t *testing.T) {
+	s := setupOIDC(t)
+	verifier := strings.Repeat("v", 43)
+	code := authorize(t, s, verifier)
+
+	resp, err := s.Token(context.Background(), tokenRequest(code, verifier))
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if resp.IdToken == "" || resp.Scope != "openid profile" {
+		t.Errorf("response = %+v", resp)
+	}
+	claims, err := utils.ParseOIDCAccessToken(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseOIDCAccessToken: %v", err)
+	}
+	if claims.Subject != "alice" || claims.Audience != testClientID {
+		t.Errorf("access token claims = %+v", claims)
+	}
+
+	// 授权码只能使用一次
+	_, err = s.Token(context.Background(), tokenRequest(code, verifier))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("reusing the code: err = %v, want InvalidArgument", err)
+	}
+}
+
+func TestOIDCTokenRejectsWrongVerifier(t *testing.T) {
+	s := setupOIDC(t)
+	code := authorize(t, s, strings.Repeat("v", 43))
+
+	_, err := s.Token(context.Background(), tokenRequest(code, strings.Repeat("w", 43)))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("wrong verifier: err = %v, want InvalidArgument", err)
+	}
+	// 校验失败的授权码同样作废，不能再用正确的 code_verifier 重试
+	_, err = s.Token(context.Background(), tokenRequest(code, strings.Repeat("v", 43)))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("retry after a failed exchange: err = %v, want InvalidArgument", err)
+	}
+}
+
+func TestOIDCTokenRequiresVerifier(t *testing.T) {
+	s := setupOIDC(t)
+	code := authorize(t, s, strings.Repeat("v", 43))
+
+	_, err := s.Token(context.Background(), tokenRequest(code, ""))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("missing verifier: err = %v, want InvalidArgument", err)
+	}
+}
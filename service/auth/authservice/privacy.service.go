@@ -0,0 +1,366 @@
+// privacy.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exportProfile 导出的个人资料
+type exportProfile struct {
+	Username      string     `json:"username"`
+	DisplayName   string     `json:"display_name"`
+	AvatarURL     string     `json:"avatar_url"`
+	Bio           string     `json:"bio"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	Source        string     `json:"source"`
+	TOTPEnabled   bool       `json:"totp_enabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+}
+
+type exportSession struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type exportEnrollment struct {
+	ClassID   string    `json:"class_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportMessage struct {
+	ClassID    string    `json:"class_id"`
+	SenderName string    `json:"sender_name"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportAnswer struct {
+	ClassID    string    `json:"class_id"`
+	QuestionID string    `json:"question_id"`
+	Answer     string    `json:"answer"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportAttendance struct {
+	ClassID  string     `json:"class_id"`
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at,omitempty"`
+}
+
+// personalExport 个人数据导出的全部内容，JSON 格式时作为一个文档，ZIP 格式时每个字段一个文件
+type personalExport struct {
+	ExportedAt  time.Time          `json:"exported_at"`
+	Profile     exportProfile      `json:"profile"`
+	Sessions    []exportSession    `json:"sessions"`
+	Enrollments []exportEnrollment `json:"enrollments"`
+	Messages    []exportMessage    `json:"messages"`
+	Answers     []exportAnswer     `json:"answers"`
+	Attendance  []exportAttendance `json:"attendance"`
+}
+
+// ExportMyData 导出当前用户的个人资料、登录会话、课程、聊天消息、答案和出勤记录
+func (s *AuthService) ExportMyData(ctx context.Context, req *proto.ExportMyDataRequest) (*proto.ExportMyDataResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	format := req.Format
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		return nil, status.Errorf(codes.InvalidArgument, "format must be json or zip")
+	}
+
+	export, err := collectPersonalData(principal.Username)
+	if err != nil {
+		log.Printf("collectPersonalData failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to export data")
+	}
+
+	filename := fmt.Sprintf("lanshanclass-%s-%s", principal.Username, export.ExportedAt.Format("20060102150405"))
+	resp := &proto.ExportMyDataResponse{}
+	if format == "zip" {
+		resp.Data, err = zipExport(export)
+		resp.Filename = filename + ".zip"
+		resp.ContentType = "application/zip"
+	} else {
+		resp.Data, err = json.MarshalIndent(export, "", "  ")
+		resp.Filename = filename + ".json"
+		resp.ContentType = "application/json"
+	}
+	if err != nil {
+		log.Printf("Encode export failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to export data")
+	}
+
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditDataExport, Target: principal.Username, Outcome: database.AuditSuccess,
+		Detail: "format=" + format,
+	})
+	return resp, nil
+}
+
+// collectPersonalData 汇总用户在数据库和 Redis 中的数据
+func collectPersonalData(username string) (*personalExport, error) {
+	user, err := database.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := utils.ListSessions(username)
+	if err != nil {
+		return nil, err
+	}
+	data, err := database.GetPersonalData(username)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &personalExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: exportProfile{
+			Username:      user.Username,
+			DisplayName:   user.DisplayName,
+			AvatarURL:     user.AvatarURL,
+			Bio:           user.Bio,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			Role:          user.Role,
+			Source:        user.Source,
+			TOTPEnabled:   user.TOTPEnabled,
+			CreatedAt:     user.CreatedAt,
+			LastLoginAt:   user.LastLoginAt,
+		},
+		Sessions:    make([]exportSession, 0, len(sessions)),
+		Enrollments: make([]exportEnrollment, 0, len(data.Enrollments)),
+		Messages:    make([]exportMessage, 0, len(data.Messages)),
+		Answers:     make([]exportAnswer, 0, len(data.Answers)),
+		Attendance:  make([]exportAttendance, 0, len(data.Attendance)),
+	}
+	for _, s := range sessions {
+		export.Sessions = append(export.Sessions, exportSession{
+			ID: s.ID, Device: s.Device, IP: s.IP, UserAgent: s.UserAgent, CreatedAt: s.CreatedAt, LastSeenAt: s.LastSeenAt,
+		})
+	}
+	for _, e := range data.Enrollments {
+		export.Enrollments = append(export.Enrollments, exportEnrollment{ClassID: e.ClassID, CreatedAt: e.CreatedAt})
+	}
+	for _, m := range data.Messages {
+		export.Messages = append(export.Messages, exportMessage{
+			ClassID: m.ClassID, SenderName: m.SenderName, Content: m.Content, CreatedAt: m.CreatedAt,
+		})
+	}
+	for _, a := range data.Answers {
+		export.Answers = append(export.Answers, exportAnswer{
+			ClassID: a.ClassID, QuestionID: a.QuestionID, Answer: a.Answer, CreatedAt: a.CreatedAt,
+		})
+	}
+	for _, a := range data.Attendance {
+		export.Attendance = append(export.Attendance, exportAttendance{ClassID: a.ClassID, JoinedAt: a.JoinedAt, LeftAt: a.LeftAt})
+	}
+	return export, nil
+}
+
+// zipExport 将每类数据写成 ZIP 中的一个 JSON 文件
+func zipExport(export *personalExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"profile.json", struct {
+			ExportedAt time.Time     `json:"exported_at"`
+			Profile    exportProfile `json:"profile"`
+		}{export.ExportedAt, export.Profile}},
+		{"sessions.json", export.Sessions},
+		{"enrollments.json", export.Enrollments},
+		{"messages.json", export.Messages},
+		{"answers.json", export.Answers},
+		{"attendance.json", export.Attendance},
+	}
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ListErasures 查询删除账号申请
+func (s *AdminService) ListErasures(ctx context.Context, req *proto.ListErasuresRequest) (*proto.ListErasuresResponse, error) {
+	reqs, err := database.ListErasureRequests(req.Status)
+	if err != nil {
+		log.Printf("ListErasureRequests failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list erasure requests")
+	}
+	resp := &proto.ListErasuresResponse{Erasures: make([]*proto.Erasure, 0, len(reqs))}
+	for i := range reqs {
+		resp.Erasures = append(resp.Erasures, toErasure(&reqs[i]))
+	}
+	return resp, nil
+}
+
+// ReviewErasure 审批删除账号申请，批准后唤醒后台任务执行
+func (s *AdminService) ReviewErasure(ctx context.Context, req *proto.ReviewErasureRequest) (*proto.Erasure, error) {
+	admin, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	erasure, err := database.ReviewErasureRequest(uint(req.Id), admin.Username, req.Approve)
+	if errors.Is(err, database.ErrErasureNotFound) {
+		return nil, status.Errorf(codes.NotFound, "删除申请不存在")
+	}
+	if errors.Is(err, database.ErrErasureNotPending) {
+		return nil, status.Errorf(codes.FailedPrecondition, "该申请已审批")
+	}
+	if err != nil {
+		log.Printf("ReviewErasureRequest failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to review erasure request")
+	}
+
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditErasureReview, Target: erasure.Username, Outcome: database.AuditSuccess,
+		Detail: fmt.Sprintf("request=%d status=%s", erasure.ID, erasure.Status),
+	})
+	if erasure.Status == database.ErasureApproved {
+		wakeErasureWorker()
+	}
+	return toErasure(erasure), nil
+}
+
+// erasureWake 唤醒删除任务，缓冲为 1，多次唤醒合并为一次
+var erasureWake = make(chan struct{}, 1)
+
+func wakeErasureWorker() {
+	select {
+	case erasureWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunErasureWorker 执行已批准的删除申请，直到 ctx 结束
+// 除审批时唤醒外还会定期检查，其他实例批准或重启前未执行的申请也会被处理
+func RunErasureWorker(ctx context.Context) {
+	interval := database.Config.GetDuration("erasure.poll_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			req, err := database.ClaimApprovedErasure()
+			if err != nil {
+				log.Printf("ClaimApprovedErasure failed: %v", err)
+				break
+			}
+			if req == nil {
+				break
+			}
+			runErasure(ctx, req)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-erasureWake:
+		}
+	}
+}
+
+// runErasure 删除账号并以化名替换保留数据中的用户名，随后注销其全部登录并通知直播服务
+func runErasure(ctx context.Context, req *database.ErasureRequest) {
+	username := req.Username
+	pseudonym, err := newPseudonym()
+	if err == nil {
+		err = database.EraseUser(username, pseudonym)
+	}
+	if err != nil {
+		log.Printf("Erasure %d failed: %v", req.ID, err)
+		if err := database.FinishErasure(req, "", err); err != nil {
+			log.Printf("FinishErasure failed: %v", err)
+		}
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Actor: req.ReviewedBy, Action: database.AuditErasure, Target: username, Outcome: database.AuditFailure,
+			Detail: fmt.Sprintf("request=%d", req.ID),
+		})
+		return
+	}
+
+	if err := utils.RevokeUserTokens(username); err != nil {
+		log.Printf("RevokeUserTokens failed: %v", err)
+	}
+	if err := utils.PublishUserErased(ctx, utils.UserErased{Username: username, Pseudonym: pseudonym}); err != nil {
+		log.Printf("PublishUserErased failed: %v", err)
+	}
+	if err := database.FinishErasure(req, pseudonym, nil); err != nil {
+		log.Printf("FinishErasure failed: %v", err)
+	}
+	log.Printf("Erasure %d completed as %s", req.ID, pseudonym)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Actor: req.ReviewedBy, Action: database.AuditErasure, Target: pseudonym, Outcome: database.AuditSuccess,
+		Detail: fmt.Sprintf("request=%d", req.ID),
+	})
+}
+
+// newPseudonym 生成化名，同一用户的全部保留数据使用同一个化名，统计时仍能区分不同用户
+func newPseudonym() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "deleted_" + hex.EncodeToString(b), nil
+}
+
+// toErasure 将删除申请转换为消息
+func toErasure(req *database.ErasureRequest) *proto.Erasure {
+	e := &proto.Erasure{
+		Id:          uint64(req.ID),
+		Username:    req.Username,
+		Reason:      req.Reason,
+		Status:      req.Status,
+		RequestedAt: req.RequestedAt.Unix(),
+		ReviewedBy:  req.ReviewedBy,
+		Error:       req.Error,
+	}
+	if req.ReviewedAt != nil {
+		e.ReviewedAt = req.ReviewedAt.Unix()
+	}
+	if req.CompletedAt != nil {
+		e.CompletedAt = req.CompletedAt.Unix()
+	}
+	return e
+}
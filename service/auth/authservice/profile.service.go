@@ -0,0 +1,203 @@
+// profile.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// 个人资料字段长度限制
+const (
+	maxDisplayNameLength = 50
+	maxAvatarURLLength   = 500
+	maxBioLength         = 500
+)
+
+// GetProfile 查询当前用户的个人资料
+func (s *AuthService) GetProfile(ctx context.Context, req *proto.GetProfileRequest) (*proto.UserProfile, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := database.GetUser(principal.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	return toProfile(user), nil
+}
+
+// UpdateProfile 更新当前用户的个人资料
+func (s *AuthService) UpdateProfile(ctx context.Context, req *proto.UpdateProfileRequest) (*proto.UserProfile, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DisplayName != nil && utf8.RuneCountInString(*req.DisplayName) > maxDisplayNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "display_name must be at most %d characters", maxDisplayNameLength)
+	}
+	if req.AvatarUrl != nil && *req.AvatarUrl != "" {
+		if len(*req.AvatarUrl) > maxAvatarURLLength {
+			return nil, status.Errorf(codes.InvalidArgument, "avatar_url must be at most %d characters", maxAvatarURLLength)
+		}
+		u, err := url.Parse(*req.AvatarUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "avatar_url must be an http(s) URL")
+		}
+	}
+	if req.Bio != nil && utf8.RuneCountInString(*req.Bio) > maxBioLength {
+		return nil, status.Errorf(codes.InvalidArgument, "bio must be at most %d characters", maxBioLength)
+	}
+	if req.Email != nil && *req.Email != "" {
+		if !validEmail(*req.Email) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid email address")
+		}
+		if err := checkEmailAvailable(*req.Email, principal.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := database.UpdateProfile(principal.Username, database.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarUrl,
+		Bio:         req.Bio,
+		Email:       req.Email,
+	})
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	// 设置或更换了邮箱时发送验证邮件
+	if req.Email != nil && user.Email != "" && !user.EmailVerified {
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("sendVerificationEmail failed: %v", err)
+		}
+	}
+	return toProfile(user), nil
+}
+
+// ChangePassword 修改密码，注销该用户的全部登录并为当前设备签发新 Token
+func (s *AuthService) ChangePassword(ctx context.Context, req *proto.ChangePasswordRequest) (*proto.ChangePasswordResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.NewPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "new_password is required")
+	}
+
+	user, err := database.GetUser(principal.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if err := localPasswordError(user); err != nil {
+		return nil, err
+	}
+	if err := checkNewPassword("new_password", req.NewPassword, user.Username); err != nil {
+		return nil, err
+	}
+
+	ok, err := database.VerifyPassword(principal.Username, req.OldPassword)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if !ok {
+		utils.RecordAudit(ctx, utils.AuditEntry{
+			Action: database.AuditPasswordChange, Target: principal.Username,
+			Outcome: database.AuditFailure, Detail: "wrong old password",
+		})
+		return nil, status.Errorf(codes.PermissionDenied, "原密码错误")
+	}
+
+	if err := database.SetPassword(principal.Username, req.NewPassword); err != nil {
+		log.Printf("SetPassword failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to change password")
+	}
+	// 当前设备重新登录时沿用原会话的设备名称
+	var device string
+	if session, err := utils.GetSession(principal.Claims.SessionID); err == nil {
+		device = session.Device
+	}
+	if err := utils.RevokeUserTokens(principal.Username); err != nil {
+		log.Printf("RevokeUserTokens failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to revoke existing sessions")
+	}
+
+	token, refreshToken, err := issueTokens(ctx, principal.Username, principal.Role, device)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Password changed: %s", principal.Username)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditPasswordChange, Target: principal.Username, Outcome: database.AuditSuccess,
+	})
+	return &proto.ChangePasswordResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+		Message:      "密码已修改，其他设备需要重新登录",
+	}, nil
+}
+
+// DeleteAccount 申请注销当前用户的账号，管理员批准后由后台任务删除账号和个人数据
+func (s *AuthService) DeleteAccount(ctx context.Context, req *proto.DeleteAccountRequest) (*proto.DeleteAccountResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.confirmPassword(ctx, principal.Username, req.Password); err != nil {
+		return nil, err
+	}
+
+	erasure, err := database.CreateErasureRequest(principal.Username, req.Reason)
+	if err != nil {
+		log.Printf("CreateErasureRequest failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to delete account")
+	}
+
+	log.Printf("Account erasure requested: %s (request %d)", principal.Username, erasure.ID)
+	utils.RecordAudit(ctx, utils.AuditEntry{
+		Action: database.AuditErasureRequest, Target: principal.Username, Outcome: database.AuditSuccess,
+		Detail: fmt.Sprintf("request=%d", erasure.ID),
+	})
+	return &proto.DeleteAccountResponse{
+		Message:   "注销申请已提交，管理员批准后将删除账号及个人数据",
+		RequestId: uint64(erasure.ID),
+		Status:    erasure.Status,
+	}, nil
+}
+
+// toProfile 将数据库用户转换为资料消息
+func toProfile(user *database.User) *proto.UserProfile {
+	return &proto.UserProfile{
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		AvatarUrl:     user.AvatarURL,
+		Bio:           user.Bio,
+		Email:         user.Email,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt.Unix(),
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+// userLookupError 将用户查询错误转换为 gRPC 错误
+func userLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "user not found")
+	}
+	log.Printf("User lookup failed: %v", err)
+	return status.Errorf(codes.Internal, "internal error")
+}
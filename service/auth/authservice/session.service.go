@@ -0,0 +1,115 @@
+// session.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListSessions 查询当前用户的全部登录会话
+func (s *AuthService) ListSessions(ctx context.Context, req *proto.ListSessionsRequest) (*proto.ListSessionsResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := utils.ListSessions(principal.Username)
+	if err != nil {
+		log.Printf("ListSessions failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to list sessions")
+	}
+
+	resp := &proto.ListSessionsResponse{}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &proto.Session{
+			SessionId:  session.ID,
+			Device:     session.Device,
+			Ip:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+			Current:    session.ID == principal.Claims.SessionID,
+		})
+	}
+	return resp, nil
+}
+
+// RevokeSession 注销当前用户的某个会话，用于远程登出丢失的设备
+func (s *AuthService) RevokeSession(ctx context.Context, req *proto.RevokeSessionRequest) (*proto.RevokeSessionResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.SessionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "session_id is required")
+	}
+
+	err = utils.RevokeSession(principal.Username, req.SessionId)
+	if errors.Is(err, utils.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.NotFound, "session not found")
+	}
+	if err != nil {
+		log.Printf("RevokeSession failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to revoke session")
+	}
+
+	log.Printf("Session %s of %s revoked", req.SessionId, principal.Username)
+	return &proto.RevokeSessionResponse{Message: "已退出该设备"}, nil
+}
+
+// describeDevice 根据 User-Agent 粗略推断设备，例如 "Chrome on Android"
+func describeDevice(ua string) string {
+	if strings.TrimSpace(ua) == "" {
+		return "未知设备"
+	}
+
+	var browser string
+	switch {
+	case strings.Contains(ua, "MicroMessenger"):
+		browser = "WeChat"
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(ua, "grpc-go/"):
+		return "gRPC client"
+	}
+
+	var os string
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		os = "iPhone"
+	case strings.Contains(ua, "iPad"):
+		os = "iPad"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	// 无法识别时保留 User-Agent 的第一段，如 "curl/8.5.0"
+	return strings.Fields(ua)[0]
+}
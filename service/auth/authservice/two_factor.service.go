@@ -0,0 +1,367 @@
+// two_factor.service.go
+package authservice
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Redis 键前缀
+const (
+	twoFactorChallengeKeyPrefix = "auth:2fa_challenge:" // 登录第二步凭证 -> {username, device, enroll_secret, attempts}
+	totpPendingKeyPrefix        = "auth:totp_pending:"  // 用户名 -> 尚未确认绑定的密钥
+	totpUsedKeyPrefix           = "auth:totp_used:"     // 已使用过的验证码时间窗口，防止同一验证码被重放
+)
+
+const (
+	recoveryCodeCount = 10               // 每次绑定生成的恢复码数量
+	totpPendingTTL    = 10 * time.Minute // 主动绑定时，从生成密钥到确认的有效期
+	totpUsedTTL       = 2 * time.Minute  // 覆盖验证码允许的全部时间偏差
+)
+
+// recoveryCodeChars 恢复码字符集，去掉了容易混淆的字符
+const recoveryCodeChars = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// requiresSecondFactor 判断角色是否被配置为必须启用两步验证
+func requiresSecondFactor(role string) bool {
+	for _, r := range database.Config.GetStringSlice("two_factor.required_roles") {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// beginSecondFactor 密码校验通过后创建登录第二步凭证
+// 角色要求两步验证但用户尚未绑定时，同时生成待绑定的密钥，第二步校验通过即完成绑定
+func beginSecondFactor(ctx context.Context, user *database.User, device string) (*proto.LoginResponse, error) {
+	challenge := map[string]interface{}{"username": user.Username, "device": device}
+	resp := &proto.LoginResponse{
+		Message:              "请输入两步验证码",
+		SecondFactorRequired: true,
+	}
+	if !user.TOTPEnabled {
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			log.Printf("GenerateTOTPSecret failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "failed to login")
+		}
+		challenge["enroll_secret"] = secret
+		resp.Message = "当前角色要求启用两步验证，请使用验证器 App 扫描二维码后输入验证码"
+		resp.TotpEnrollmentRequired = true
+		resp.TotpSecret = secret
+		resp.TotpProvisioningUri = provisioningURI(user.Username, secret)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		log.Printf("randomToken failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+	key := twoFactorChallengeKeyPrefix + token
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, challenge)
+	pipe.Expire(ctx, key, database.Config.GetDuration("two_factor.challenge_ttl"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Store 2FA challenge failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login")
+	}
+
+	resp.ChallengeToken = token
+	return resp, nil
+}
+
+// VerifySecondFactor 登录第二步，校验验证码或恢复码后签发 Token
+func (s *AuthService) VerifySecondFactor(ctx context.Context, req *proto.VerifySecondFactorRequest) (*proto.VerifySecondFactorResponse, error) {
+	if req.ChallengeToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge_token is required")
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code or recovery_code is required")
+	}
+
+	key := twoFactorChallengeKeyPrefix + req.ChallengeToken
+	challenge, err := database.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Printf("Load 2FA challenge failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to verify")
+	}
+	username := challenge["username"]
+	if username == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "两步验证已过期，请重新登录")
+	}
+
+	// 第二步的错误同样计入登录失败次数，防止通过反复登录来穷举验证码
+	ip := utils.ClientIP(ctx)
+	attempt, retryAfter, err := s.limiter.Begin(ctx, username, ip)
+	if err != nil {
+		log.Printf("Login limiter check failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to verify")
+	}
+	if retryAfter > 0 {
+		auditLogin(ctx, username, database.AuditDenied, "locked")
+		return nil, lockedError(retryAfter)
+	}
+
+	user, err := database.GetUser(username)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user no longer exists")
+	}
+	if user.Disabled {
+		return nil, status.Errorf(codes.PermissionDenied, "账号已被禁用")
+	}
+
+	var ok bool
+	enrollSecret := challenge["enroll_secret"]
+	switch {
+	case enrollSecret != "":
+		ok, err = checkTOTP(ctx, username, enrollSecret, req.Code)
+	case req.RecoveryCode != "":
+		ok, err = database.UseRecoveryCode(user.ID, hashRecoveryCode(req.RecoveryCode))
+	default:
+		ok, err = checkTOTP(ctx, username, user.TOTPSecret, req.Code)
+	}
+	if err != nil {
+		log.Printf("Second factor check failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to verify")
+	}
+	if !ok {
+		attempts, err := database.RedisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+		if err == nil && attempts >= database.Config.GetInt64("two_factor.max_attempts") {
+			database.RedisClient.Del(ctx, key)
+		}
+		retryAfter, err := s.limiter.RecordFailure(ctx, attempt)
+		if err != nil {
+			log.Printf("Login limiter record failed: %v", err)
+		}
+		if retryAfter > 0 {
+			log.Printf("Login locked: username=%s ip=%s retry_after=%s", username, ip, retryAfter)
+			auditLogin(ctx, username, database.AuditFailure, "invalid second factor, locked")
+			return nil, lockedError(retryAfter)
+		}
+		auditLogin(ctx, username, database.AuditFailure, "invalid second factor")
+		return nil, status.Errorf(codes.InvalidArgument, "验证码错误")
+	}
+
+	// 凭证只能使用一次，并发请求中只有删除成功的一方继续
+	n, err := database.RedisClient.Del(ctx, key).Result()
+	if err != nil || n == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "两步验证已过期，请重新登录")
+	}
+	if err := s.limiter.RecordSuccess(ctx, attempt); err != nil {
+		log.Printf("Login limiter reset failed: %v", err)
+	}
+
+	resp := &proto.VerifySecondFactorResponse{Message: "登录成功"}
+	if enrollSecret != "" {
+		resp.RecoveryCodes, err = enableTOTP(username, enrollSecret)
+		if err != nil {
+			return nil, err
+		}
+		resp.Message = "两步验证已启用，请妥善保存恢复码"
+	}
+
+	resp.Token, resp.RefreshToken, err = completeLogin(ctx, user, challenge["device"])
+	if err != nil {
+		return nil, err
+	}
+	method := "password+totp"
+	if enrollSecret == "" && req.RecoveryCode != "" {
+		method = "password+recovery_code"
+	}
+	auditLogin(ctx, username, database.AuditSuccess, method)
+	resp.ExpiresIn = int64(utils.AccessTokenTTL().Seconds())
+	return resp, nil
+}
+
+// EnrollTOTP 为当前用户生成待绑定的密钥，需调用 ConfirmTOTP 确认后才生效
+func (s *AuthService) EnrollTOTP(ctx context.Context, req *proto.EnrollTOTPRequest) (*proto.EnrollTOTPResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := database.GetUser(principal.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if user.TOTPEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "已启用两步验证")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("GenerateTOTPSecret failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to enroll")
+	}
+	if err := database.RedisClient.Set(ctx, totpPendingKeyPrefix+user.Username, secret, totpPendingTTL).Err(); err != nil {
+		log.Printf("Store pending TOTP secret failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to enroll")
+	}
+
+	return &proto.EnrollTOTPResponse{
+		Secret:          secret,
+		ProvisioningUri: provisioningURI(user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP 校验验证器 App 生成的验证码，通过后启用两步验证并返回恢复码
+func (s *AuthService) ConfirmTOTP(ctx context.Context, req *proto.ConfirmTOTPRequest) (*proto.ConfirmTOTPResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := database.RedisClient.Get(ctx, totpPendingKeyPrefix+principal.Username).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, status.Errorf(codes.FailedPrecondition, "请先获取两步验证密钥")
+	}
+	if err != nil {
+		log.Printf("Load pending TOTP secret failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to confirm")
+	}
+
+	ok, err := checkTOTP(ctx, principal.Username, secret, req.Code)
+	if err != nil {
+		log.Printf("checkTOTP failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to confirm")
+	}
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "验证码错误")
+	}
+
+	recoveryCodes, err := enableTOTP(principal.Username, secret)
+	if err != nil {
+		return nil, err
+	}
+	database.RedisClient.Del(ctx, totpPendingKeyPrefix+principal.Username)
+
+	log.Printf("TOTP enabled: %s", principal.Username)
+	return &proto.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+		Message:       "两步验证已启用，请妥善保存恢复码",
+	}, nil
+}
+
+// DisableTOTP 关闭两步验证，需要同时提供密码和当前验证码；角色要求两步验证时不允许关闭
+func (s *AuthService) DisableTOTP(ctx context.Context, req *proto.DisableTOTPRequest) (*proto.DisableTOTPResponse, error) {
+	principal, err := utils.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if requiresSecondFactor(principal.Role) {
+		return nil, status.Errorf(codes.FailedPrecondition, "当前角色必须启用两步验证")
+	}
+
+	if err := s.confirmPassword(ctx, principal.Username, req.Password); err != nil {
+		return nil, err
+	}
+
+	user, err := database.GetUser(principal.Username)
+	if err != nil {
+		return nil, userLookupError(err)
+	}
+	if !user.TOTPEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "未启用两步验证")
+	}
+	ok, err := checkTOTP(ctx, user.Username, user.TOTPSecret, req.Code)
+	if err != nil {
+		log.Printf("checkTOTP failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to disable")
+	}
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "验证码错误")
+	}
+
+	if err := database.DisableTOTP(user.Username); err != nil {
+		log.Printf("DisableTOTP failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to disable")
+	}
+	log.Printf("TOTP disabled: %s", user.Username)
+	return &proto.DisableTOTPResponse{Message: "两步验证已关闭"}, nil
+}
+
+// completeLogin 记录登录时间并签发 Token，密码登录和两步验证登录共用
+func completeLogin(ctx context.Context, user *database.User, device string) (string, string, error) {
+	if err := database.RecordLogin(user.Username); err != nil {
+		log.Printf("RecordLogin failed: %v", err)
+	}
+	return issueTokens(ctx, user.Username, user.Role, device)
+}
+
+// checkTOTP 校验验证码，每个时间窗口的验证码只能使用一次
+func checkTOTP(ctx context.Context, username, secret, code string) (bool, error) {
+	if secret == "" {
+		return false, nil
+	}
+	counter, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	key := fmt.Sprintf("%s%s:%d", totpUsedKeyPrefix, username, counter)
+	return database.RedisClient.SetNX(ctx, key, 1, totpUsedTTL).Result()
+}
+
+// enableTOTP 生成恢复码并启用两步验证，返回恢复码明文
+func enableTOTP(username, secret string) ([]string, error) {
+	plain, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("newRecoveryCodes failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication")
+	}
+	if err := database.EnableTOTP(username, secret, hashes); err != nil {
+		log.Printf("EnableTOTP failed: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication")
+	}
+	return plain, nil
+}
+
+// newRecoveryCodes 生成一组 xxxxx-xxxxx 格式的恢复码及其哈希
+func newRecoveryCodes() ([]string, []string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b, err := randomString(recoveryCodeChars, 10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := b[:5] + "-" + b[5:]
+		plain = append(plain, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return plain, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，忽略大小写、空格和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// provisioningURI 生成验证器 App 使用的 otpauth:// 地址
+func provisioningURI(username, secret string) string {
+	return utils.TOTPProvisioningURI(database.Config.GetString("two_factor.issuer"), username, secret)
+}
+
+// randomToken 生成 32 字节随机凭证
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -4,24 +4,37 @@ package main
 import (
 	"LanshanClass1.3/global/database"
 	"LanshanClass1.3/service/auth/authservice"
+	"context"
 	"log"
 	"net"
 
 	"LanshanClass1.3/proto"
+	"LanshanClass1.3/utils"
 	"google.golang.org/grpc"
 )
 
 func main() {
 	database.Init()
+	utils.InitJWT()
+	utils.InitCredentialPolicy()
+	// 后台执行管理员已批准的删除账号申请
+	go authservice.RunErasureWorker(context.Background())
 	// 定义 gRPC 服务监听的地址
 	lis, err := net.Listen("tcp", "localhost:50051")
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 	// 创建 gRPC 服务器
-	s := grpc.NewServer()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(utils.UnaryAuthInterceptor(authservice.MethodPolicies)),
+		grpc.ChainStreamInterceptor(utils.StreamAuthInterceptor(authservice.MethodPolicies)),
+	)
 	// 注册 AuthService 服务
-	proto.RegisterAuthServiceServer(s, &authservice.AuthService{})
+	proto.RegisterAuthServiceServer(s, authservice.NewAuthService())
+	// 注册 AdminService 服务
+	proto.RegisterAdminServiceServer(s, authservice.NewAdminService())
+	// 注册 OIDCService 服务
+	proto.RegisterOIDCServiceServer(s, authservice.NewOIDCService())
 	log.Println("gRPC server started at :50051")
 	// 启动 gRPC 服务
 	if err := s.Serve(lis); err != nil {
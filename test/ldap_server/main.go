@@ -0,0 +1,61 @@
+// ldap_server 启动一个带演示数据的 LDAP 测试目录，用于在本地体验 LDAP 登录：
+//
+//  1. go run ./test/ldap_server
+//  2. 将 auth.backend 设为 ldap，auth.ldap.url 设为输出的地址，其余保持默认示例配置
+//  3. 使用 alice / alice123（教师）或 bob / bob123（学生）登录
+package main
+
+import (
+	"LanshanClass1.3/test/ldapstub"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:3389", "监听地址")
+	flag.Parse()
+
+	const (
+		teachers = "cn=teachers,ou=groups,dc=example,dc=org"
+		students = "cn=students,ou=groups,dc=example,dc=org"
+	)
+	server := ldapstub.New(
+		ldapstub.Entry{DN: "uid=alice,ou=people,dc=example,dc=org", Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"alice"},
+			"cn":           {"Alice 老师"},
+			"mail":         {"alice@example.org"},
+			"userPassword": {"alice123"},
+			"memberOf":     {teachers},
+		}},
+		ldapstub.Entry{DN: "uid=bob,ou=people,dc=example,dc=org", Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"bob"},
+			"cn":           {"Bob"},
+			"mail":         {"bob@example.org"},
+			"userPassword": {"bob123"},
+			"memberOf":     {students},
+		}},
+		ldapstub.Entry{DN: teachers, Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"member":      {"uid=alice,ou=people,dc=example,dc=org"},
+		}},
+		ldapstub.Entry{DN: students, Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"member":      {"uid=bob,ou=people,dc=example,dc=org"},
+		}},
+	)
+	url, err := server.Start(*listen)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	fmt.Printf("LDAP test directory listening at %s\n", url)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+	server.Close()
+}
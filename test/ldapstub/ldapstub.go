@@ -0,0 +1,305 @@
+// Package ldapstub 是用于本地测试的最小 LDAP 服务器，可以在测试进程内启动，代替学校的 LDAP 目录
+//
+// 只支持简单绑定、查询和解绑；userPassword 属性以明文保存，查询结果中不返回
+// 过滤器支持 and、or、not、等值匹配和存在性判断
+package ldapstub
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Entry 目录条目
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// get 按属性名（不区分大小写）读取属性值
+func (e Entry) get(name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Server LDAP 测试服务器
+type Server struct {
+	mu       sync.RWMutex
+	entries  []Entry
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// New 使用给定的条目创建服务器
+func New(entries ...Entry) *Server {
+	return &Server{entries: entries}
+}
+
+// Add 添加条目，服务器运行期间也可以调用
+func (s *Server) Add(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// Start 在 addr 上开始监听（如 127.0.0.1:0），返回可用于 auth.ldap.url 的地址
+func (s *Server) Start(addr string) (string, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	s.listener = l
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	return "ldap://" + l.Addr().String(), nil
+}
+
+// Close 停止监听并等待已有连接结束
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return errors.New("server not started")
+	}
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// serve 处理一个连接上的全部请求
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := intValue(packet.Children[0])
+		op := packet.Children[1]
+
+		var responses []*ber.Packet
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			responses = append(responses, result(ldap.ApplicationBindResponse, s.bind(op), ""))
+		case ldap.ApplicationSearchRequest:
+			responses = s.search(op)
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationExtendedRequest:
+			// 不支持 StartTLS 等扩展操作
+			responses = append(responses, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultUnwillingToPerform, "extended operations are not supported"))
+		default:
+			return
+		}
+		for _, r := range responses {
+			if _, err := conn.Write(envelope(id, r).Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// bind 处理简单绑定；与真实目录一致，DN 不为空而密码为空时视为未认证绑定并返回成功
+func (s *Server) bind(op *ber.Packet) uint16 {
+	if len(op.Children) < 3 || op.Children[2].ClassType != ber.ClassContext || op.Children[2].Tag != 0 {
+		return ldap.LDAPResultAuthMethodNotSupported
+	}
+	name := stringValue(op.Children[1])
+	password := op.Children[2].Data.String()
+	if password == "" {
+		return ldap.LDAPResultSuccess
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.entries {
+		if sameDN(e.DN, name) {
+			for _, p := range e.get("userPassword") {
+				if p == password {
+					return ldap.LDAPResultSuccess
+				}
+			}
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+// search 处理查询，返回匹配的条目和结束响应
+func (s *Server) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError, "malformed search request")}
+	}
+	base, err := ldap.ParseDN(stringValue(op.Children[0]))
+	if err != nil {
+		return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultInvalidDNSyntax, err.Error())}
+	}
+	scope := intValue(op.Children[1])
+	sizeLimit := int(intValue(op.Children[3]))
+	filter := op.Children[6]
+	var attributes []string
+	for _, a := range op.Children[7].Children {
+		attributes = append(attributes, stringValue(a))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var responses []*ber.Packet
+	for _, e := range s.entries {
+		dn, err := ldap.ParseDN(e.DN)
+		if err != nil || !inScope(base, dn, scope) || !matches(e, filter) {
+			continue
+		}
+		if sizeLimit > 0 && len(responses) == sizeLimit {
+			return append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded, ""))
+		}
+		responses = append(responses, searchEntry(e, attributes))
+	}
+	return append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, ""))
+}
+
+// inScope 判断条目是否在查询范围内
+func inScope(base, dn *ldap.DN, scope int64) bool {
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return base.EqualFold(dn)
+	case ldap.ScopeSingleLevel:
+		return len(dn.RDNs) == len(base.RDNs)+1 && base.AncestorOfFold(dn)
+	default:
+		return base.EqualFold(dn) || base.AncestorOfFold(dn)
+	}
+}
+
+// matches 计算过滤器，不支持的过滤器类型视为不匹配
+func matches(e Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, f := range filter.Children {
+			if !matches(e, f) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, f := range filter.Children {
+			if matches(e, f) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(e, filter.Children[0])
+	case ldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := stringValue(filter.Children[1])
+		for _, v := range e.get(stringValue(filter.Children[0])) {
+			if strings.EqualFold(v, want) || sameDN(v, want) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		name := filter.Data.String()
+		return strings.EqualFold(name, "objectClass") || len(e.get(name)) > 0
+	default:
+		return false
+	}
+}
+
+// searchEntry 生成查询结果条目，attributes 为空或包含 * 时返回全部属性，1.1 表示不返回属性
+func searchEntry(e Entry, attributes []string) *ber.Packet {
+	all := len(attributes) == 0
+	for _, a := range attributes {
+		if a == "*" {
+			all = true
+		}
+	}
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range e.Attributes {
+		if strings.EqualFold(name, "userPassword") || (!all && !contains(attributes, name)) {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+	return op
+}
+
+// result 生成 LDAPResult 形式的响应
+func result(tag ber.Tag, code uint16, message string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, ldap.ApplicationMap[uint8(tag)])
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "diagnosticMessage"))
+	return op
+}
+
+// envelope 将响应包装为 LDAPMessage
+func envelope(id int64, op *ber.Packet) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	p.AppendChild(op)
+	return p
+}
+
+// intValue 读取整数或枚举值
+func intValue(p *ber.Packet) int64 {
+	if v, ok := p.Value.(int64); ok {
+		return v
+	}
+	v, _ := ber.ParseInt64(p.Data.Bytes())
+	return v
+}
+
+// stringValue 读取字符串值
+func stringValue(p *ber.Packet) string {
+	if v, ok := p.Value.(string); ok {
+		return v
+	}
+	return p.Data.String()
+}
+
+// sameDN 判断两个 DN 是否相同，忽略大小写
+func sameDN(a, b string) bool {
+	da, errA := ldap.ParseDN(a)
+	db, errB := ldap.ParseDN(b)
+	return errA == nil && errB == nil && len(da.RDNs) > 0 && da.EqualFold(db)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+// oidc_client 是单点登录的本地测试客户端，完整走一遍授权码 + PKCE 流程：
+//
+//  1. 管理员注册客户端，回调地址填 http://localhost:9999/callback
+//  2. go run ./test/oidc_client -client-id <client_id> -client-secret <client_secret>
+//  3. 在浏览器中打开输出的地址并登录，客户端会换取 Token、校验 ID Token 并查询 userinfo
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// discovery 发现文档中用到的字段
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse Token 端点的响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// idTokenClaims 需要额外校验的 ID Token 声明
+type idTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+func main() {
+	issuer := flag.String("issuer", "http://localhost:8080", "OIDC 签发者地址")
+	clientID := flag.String("client-id", "", "client_id")
+	clientSecret := flag.String("client-secret", "", "client_secret，公开客户端留空")
+	listen := flag.String("listen", "localhost:9999", "本地回调服务监听地址")
+	scope := flag.String("scope", "openid profile email", "申请的 scope")
+	flag.Parse()
+	if *clientID == "" {
+		log.Fatal("-client-id is required")
+	}
+
+	var conf discovery
+	if err := getJSON(strings.TrimRight(*issuer, "/")+"/.well-known/openid-configuration", &conf); err != nil {
+		log.Fatalf("fetch discovery document: %v", err)
+	}
+	if conf.Issuer != strings.TrimRight(*issuer, "/") {
+		log.Fatalf("issuer mismatch: %q", conf.Issuer)
+	}
+
+	redirectURI := "http://" + *listen + "/callback"
+	state, nonce, verifier := randomString(), randomString(), randomString()+randomString()
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	authURL := conf.AuthorizationEndpoint + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {*clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {*scope},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	done := make(chan error, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		err := handleCallback(r, conf, *clientID, *clientSecret, redirectURI, state, nonce, verifier)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "登录成功，结果见终端输出，可以关闭此页面")
+		}
+		done <- err
+	})
+	server := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			done <- err
+		}
+	}()
+
+	fmt.Printf("请在浏览器中打开以下地址登录：\n\n%s\n\n", authURL)
+	err := <-done
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	if err != nil {
+		log.Fatalf("FAIL: %v", err)
+	}
+	fmt.Println("PASS")
+}
+
+// handleCallback 校验回调参数，换取 Token，校验 ID Token 并查询 userinfo
+func handleCallback(r *http.Request, conf discovery, clientID, clientSecret, redirectURI, state, nonce, verifier string) error {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		return fmt.Errorf("authorization failed: %s: %s", e, q.Get("error_description"))
+	}
+	if q.Get("state") != state {
+		return errors.New("state mismatch")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {q.Get("code")},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	req, _ := http.NewRequest(http.MethodPost, conf.TokenEndpoint, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	} else {
+		form.Set("client_id", clientID)
+		req.Body = io.NopCloser(strings.NewReader(form.Encode()))
+		req.ContentLength = int64(len(form.Encode()))
+	}
+	var token tokenResponse
+	if err := doJSON(req, &token); err != nil {
+		return fmt.Errorf("token request: %w", err)
+	}
+	if token.Error != "" {
+		return fmt.Errorf("token request: %s: %s", token.Error, token.ErrorDescription)
+	}
+	fmt.Printf("token response: expires_in=%d scope=%q\n", token.ExpiresIn, token.Scope)
+
+	// 授权码只能使用一次
+	replay, _ := http.NewRequest(http.MethodPost, conf.TokenEndpoint, strings.NewReader(form.Encode()))
+	replay.Header = req.Header.Clone()
+	var replayed tokenResponse
+	if err := doJSON(replay, &replayed); err != nil {
+		return fmt.Errorf("replay request: %w", err)
+	}
+	if replayed.Error != "invalid_grant" {
+		return fmt.Errorf("authorization code could be reused: %+v", replayed)
+	}
+
+	claims, err := verifyIDToken(conf, token.IDToken, clientID)
+	if err != nil {
+		return fmt.Errorf("verify id_token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return errors.New("nonce mismatch")
+	}
+	fmt.Printf("id_token: sub=%s preferred_username=%s exp=%s\n", claims.Subject, claims.PreferredUsername, claims.ExpiresAt.Time)
+
+	userinfoReq, _ := http.NewRequest(http.MethodGet, conf.UserinfoEndpoint, nil)
+	userinfoReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	var userinfo map[string]interface{}
+	if err := doJSON(userinfoReq, &userinfo); err != nil {
+		return fmt.Errorf("userinfo request: %w", err)
+	}
+	if userinfo["sub"] != claims.Subject {
+		return fmt.Errorf("userinfo sub mismatch: %v", userinfo)
+	}
+	pretty, _ := json.MarshalIndent(userinfo, "", "  ")
+	fmt.Printf("userinfo: %s\n", pretty)
+	return nil
+}
+
+// verifyIDToken 使用 JWKS 中的公钥校验 ID Token 的签名、签发者、受众和有效期
+func verifyIDToken(conf discovery, idToken, clientID string) (*idTokenClaims, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(conf.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		for _, k := range set.Keys {
+			if k.Kid != kid {
+				continue
+			}
+			switch k.Kty {
+			case "RSA":
+				n, err := base64.RawURLEncoding.DecodeString(k.N)
+				if err != nil {
+					return nil, err
+				}
+				e, err := base64.RawURLEncoding.DecodeString(k.E)
+				if err != nil {
+					return nil, err
+				}
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			case "OKP":
+				x, err := base64.RawURLEncoding.DecodeString(k.X)
+				if err != nil {
+					return nil, err
+				}
+				return ed25519.PublicKey(x), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}, jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
+	if err != nil {
+		return nil, err
+	}
+	if claims.Issuer != conf.Issuer {
+		return nil, fmt.Errorf("iss mismatch: %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return nil, fmt.Errorf("aud mismatch: %v", claims.Audience)
+	}
+	return claims, nil
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应
+func getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(req, v)
+}
+
+// doJSON 发送请求并解析 JSON 响应，错误响应同样解析以便读取 error 字段
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// randomString 生成 32 字节随机字符串
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,81 @@
+// Package testenv 为测试准备进程内的运行环境：SQLite 数据库代替 MySQL，miniredis 代替 Redis，临时生成 JWT 签名密钥
+//
+// 全局的 database.DB、database.RedisClient 和 database.Config 会被替换，使用该包的测试不能并行执行
+package testenv
+
+import (
+	"LanshanClass1.3/global/database"
+	"LanshanClass1.3/utils"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Env 测试环境
+type Env struct {
+	Redis *miniredis.Miniredis
+}
+
+// Setup 初始化配置、数据库、Redis 和 JWT 密钥，测试结束时自动清理
+// 配置使用默认值，密码哈希改用最低成本的 bcrypt 以加快测试
+func Setup(t testing.TB) *Env {
+	t.Helper()
+	dir := t.TempDir()
+
+	database.Config = viper.New()
+	database.SetDefaults(database.Config)
+	database.Config.Set("password.algorithm", "bcrypt")
+	database.Config.Set("password.bcrypt.cost", 4)
+
+	keyFile := filepath.Join(dir, "jwt.pem")
+	if err := writeSigningKey(keyFile); err != nil {
+		t.Fatalf("failed to write signing key: %v", err)
+	}
+	database.Config.Set("jwt.signing_key_file", keyFile)
+	database.Config.Set("jwt.signing_key_id", "test")
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	database.DB = db
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	mr := miniredis.RunT(t)
+	database.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { database.RedisClient.Close() })
+
+	utils.InitJWT()
+	return &Env{Redis: mr}
+}
+
+// writeSigningKey 生成 Ed25519 私钥并以 PKCS#8 PEM 格式写入文件
+func writeSigningKey(path string) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
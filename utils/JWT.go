@@ -3,26 +3,146 @@
 package utils
 
 import (
+	"LanshanClass1.3/global/database"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"time"
 )
 
-var JwtSecret = []byte("114514") // JWT 密钥
+var (
+	// ErrTokenRevoked 表示 Token 已被注销
+	ErrTokenRevoked = errors.New("token has been revoked")
+
+	// ErrNoSigningKey 表示当前服务没有配置签名私钥
+	ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+	// ErrNotAccessToken 表示 Token 不是访问 Token
+	ErrNotAccessToken = errors.New("not an access token")
+)
 
 // Claims 自定义 JWT Claims
 type Claims struct {
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // 所属登录会话
 	jwt.StandardClaims
 }
 
-func GenerateToken(username string) string {
+// AccessTokenTTL 返回访问 Token 的有效期
+func AccessTokenTTL() time.Duration {
+	if database.Config == nil {
+		return 15 * time.Minute
+	}
+	return database.Config.GetDuration("jwt.access_ttl")
+}
+
+// RefreshTokenTTL 返回刷新 Token 的有效期
+func RefreshTokenTTL() time.Duration {
+	if database.Config == nil {
+		return 7 * 24 * time.Hour
+	}
+	return database.Config.GetDuration("jwt.refresh_ttl")
+}
+
+// GenerateToken 生成短期有效的访问 Token，角色和会话 ID 写入 Claims，Header 中带有签名密钥的 kid
+func GenerateToken(username, role, sessionID string) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		Username: username,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+			Id:        randomHex(16),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL()).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString(JwtSecret)
-	return tokenString
+	return SignClaims(claims)
+}
+
+// SignClaims 使用当前签名私钥签发任意 Claims
+func SignClaims(claims jwt.Claims) (string, error) {
+	if jwtKeys.signingKey == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(jwtKeys.signingMethod, claims)
+	token.Header["kid"] = jwtKeys.signingKID
+	return token.SignedString(jwtKeys.signingKey)
+}
+
+// VerifyClaims 按 kid 选择验签公钥校验 Token 并解析到 claims 中
+func VerifyClaims(tokenString string, claims jwt.Claims) error {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys.verifyKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		// 防止使用与密钥类型不符的算法
+		method, err := signingMethodFor(key)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+		}
+		return key, nil
+	})
+	return err
+}
+
+// ParseToken 解析并校验访问 Token，已注销的 Token 返回 ErrTokenRevoked，被禁用用户返回 ErrUserDisabled
+func ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := VerifyClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	// 一次性操作 Token 使用同一签名密钥，带有 aud，不能当作访问 Token 使用
+	if claims.Username == "" || claims.Audience != "" {
+		return nil, ErrNotAccessToken
+	}
+
+	revoked, err := IsTokenRevoked(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	// 会话被注销后，属于该会话的访问 Token 立即失效
+	if claims.SessionID != "" {
+		exists, err := sessionExists(claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check session: %w", err)
+		}
+		if !exists {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	disabled, err := IsUserDisabled(claims.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user status: %w", err)
+	}
+	if disabled {
+		return nil, ErrUserDisabled
+	}
+	return claims, nil
+}
+
+// randomHex 生成 n 字节的随机十六进制串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 一次性操作 Token 的用途，写入 aud，防止不同用途的 Token 互相冒用
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// usedActionTokenKeyPrefix 已使用的操作 Token（按 jti），保留到 Token 过期为止
+const usedActionTokenKeyPrefix = "auth:used_action:"
+
+// ErrInvalidActionToken 表示操作 Token 无效、已过期或已被使用
+var ErrInvalidActionToken = errors.New("invalid, expired or already used token")
+
+// ActionClaims 邮箱验证、重置密码等一次性操作 Token 的 Claims，sub 为用户名
+type ActionClaims struct {
+	Email string `json:"email,omitempty"`
+	jwt.StandardClaims
+}
+
+// GenerateActionToken 签发一次性操作 Token
+func GenerateActionToken(purpose, username, email string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return SignClaims(ActionClaims{
+		Email: email,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  purpose,
+			Subject:   username,
+			Id:        randomHex(16),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+	})
+}
+
+// VerifyActionToken 校验操作 Token 且尚未使用，但不标记为已使用
+// 后续操作可能因请求参数不合法而失败时先调用本函数，操作确定执行前再调用 MarkActionTokenUsed
+func VerifyActionToken(purpose, tokenString string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	if err := VerifyClaims(tokenString, claims); err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	if claims.Audience != purpose || claims.Subject == "" || claims.Id == "" {
+		return nil, ErrInvalidActionToken
+	}
+
+	used, err := database.RedisClient.Exists(context.Background(), usedActionTokenKeyPrefix+claims.Id).Result()
+	if err != nil {
+		return nil, err
+	}
+	if used > 0 {
+		return nil, ErrInvalidActionToken
+	}
+	return claims, nil
+}
+
+// MarkActionTokenUsed 将已校验的操作 Token 标记为已使用，并发请求中只有一个会成功
+func MarkActionTokenUsed(claims *ActionClaims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	first, err := database.RedisClient.SetNX(context.Background(), usedActionTokenKeyPrefix+claims.Id, 1, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !first {
+		return ErrInvalidActionToken
+	}
+	return nil
+}
+
+// ConsumeActionToken 校验操作 Token 并将其标记为已使用，每个 Token 只能成功使用一次
+func ConsumeActionToken(purpose, tokenString string) (*ActionClaims, error) {
+	claims, err := VerifyActionToken(purpose, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := MarkActionTokenUsed(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
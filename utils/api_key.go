@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// APIKeyScheme 是 API Key 在 Authorization 头中使用的认证方案
+const APIKeyScheme = "ApiKey"
+
+// apiKeyPrefix 所有 API Key 的固定开头，便于在代码仓库和日志中扫描泄露的密钥
+const apiKeyPrefix = "lsk_"
+
+// apiKeyTouchInterval 最近使用时间的更新间隔，避免每次调用都写数据库
+const apiKeyTouchInterval = time.Minute
+
+// ErrInvalidAPIKey 表示 API Key 不存在、已撤销、已过期或所属账号不可用
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// GenerateAPIKey 生成新的 API Key，格式为 lsk_<8 位前缀>_<64 位密钥>
+// 返回完整密钥、用于查找的公开前缀和需要保存的哈希
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 36)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(buf[:4])
+	key = prefix + "_" + hex.EncodeToString(buf[4:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey 计算 API Key 的哈希；密钥本身是高熵随机数，无需慢哈希
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyScopes 拆分数据库中逗号分隔的权限范围
+func APIKeyScopes(scopes string) []string {
+	if scopes == "" {
+		return nil
+	}
+	return strings.Split(scopes, ",")
+}
+
+// VerifyAPIKey 校验 API Key 并返回对应的服务账号调用者
+func VerifyAPIKey(key string) (*Principal, error) {
+	i := strings.LastIndex(key, "_")
+	if !strings.HasPrefix(key, apiKeyPrefix) || i <= len(apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	record, err := database.GetAPIKeyByPrefix(key[:i])
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(record.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if !record.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := database.GetUser(record.Username)
+	if err != nil || user.Disabled || !user.ServiceAccount {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= apiKeyTouchInterval {
+		if err := database.TouchAPIKey(record.ID, now); err != nil {
+			log.Printf("TouchAPIKey failed: %v", err)
+		}
+	}
+
+	return &Principal{
+		Username: user.Username,
+		Role:     user.Role,
+		Owner:    user.Owner,
+		Scopes:   APIKeyScopes(record.Scopes),
+		APIKeyID: record.ID,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"log"
+	"net"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// AuditEntry 一条待记录的审计事件，IP 和 User-Agent 从请求元数据中读取
+type AuditEntry struct {
+	Actor   string // 为空时使用当前调用者
+	Action  string
+	Target  string
+	Outcome string
+	Detail  string
+}
+
+// RecordAudit 写入审计事件，写入失败只记录日志，不影响业务请求
+func RecordAudit(ctx context.Context, entry AuditEntry) {
+	if entry.Actor == "" {
+		if p, ok := PrincipalFromContext(ctx); ok {
+			entry.Actor = p.Username
+		}
+	}
+	event := &database.AuditEvent{
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Outcome:   entry.Outcome,
+		IP:        ClientIP(ctx),
+		UserAgent: truncate(UserAgent(ctx), 512),
+		Detail:    truncate(entry.Detail, 1000),
+	}
+	if err := database.RecordAuditEvent(event); err != nil {
+		log.Printf("RecordAudit failed: %v (%+v)", err, entry)
+	}
+}
+
+// ClientIP 获取客户端 IP
+// 只有调用方是 network.trusted_gateways 中的网关时才使用其转发的 x-forwarded-for，否则任何调用方都能伪造 IP 绕过按 IP 的登录限制
+func ClientIP(ctx context.Context) string {
+	peerIP := peerAddr(ctx)
+	if isTrustedGateway(peerIP) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+				return strings.TrimSpace(strings.Split(forwarded[0], ",")[0])
+			}
+		}
+	}
+	return peerIP
+}
+
+// peerAddr 获取 gRPC 连接的对端 IP
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// isTrustedGateway 判断 IP 是否属于配置的可信网关，配置项可以是 IP 或 CIDR
+func isTrustedGateway(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range database.Config.GetStringSlice("network.trusted_gateways") {
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(entry); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserAgent 获取客户端 User-Agent，优先使用网关转发的 x-forwarded-user-agent
+// user-agent 是 gRPC 保留的请求头，网关无法通过它转发浏览器的 User-Agent
+func UserAgent(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if forwarded := md.Get("x-forwarded-user-agent"); len(forwarded) > 0 {
+		return forwarded[0]
+	}
+	if ua := md.Get("user-agent"); len(ua) > 0 {
+		return ua[0]
+	}
+	return ""
+}
+
+// truncate 按字符截断字符串，避免超出数据库字段长度
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误，用户不存在时同样返回该错误
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrDirectoryUnavailable 外部目录无法连接
+	ErrDirectoryUnavailable = errors.New("directory unavailable")
+	// ErrNoDirectoryRole 目录账号不属于任何映射到角色的组
+	ErrNoDirectoryRole = errors.New("no role mapped for directory account")
+)
+
+// Authenticator 校验用户名和密码，成功时返回对应的本地用户
+type Authenticator interface {
+	Authenticate(ctx context.Context, username, password string) (*database.User, error)
+}
+
+// NewAuthenticator 按配置 auth.backend 创建认证后端
+func NewAuthenticator() Authenticator {
+	switch backend := database.Config.GetString("auth.backend"); backend {
+	case "ldap":
+		var local Authenticator
+		if database.Config.GetBool("auth.ldap.allow_local") {
+			local = MySQLAuthenticator{}
+		}
+		return &LDAPAuthenticator{Config: LDAPConfigFromViper(), Local: local}
+	case "mysql", "":
+		return MySQLAuthenticator{}
+	default:
+		log.Fatalf("unknown auth.backend %q", backend)
+		return nil
+	}
+}
+
+// MySQLAuthenticator 使用本地数据库中的密码哈希校验
+type MySQLAuthenticator struct{}
+
+// Authenticate 校验本地密码，外部目录创建的账号没有可用的本地密码
+func (MySQLAuthenticator) Authenticate(ctx context.Context, username, password string) (*database.User, error) {
+	ok, err := database.VerifyPassword(username, password)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := database.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.External() {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// randomPassword 生成外部账号使用的随机密码，生成后即丢弃
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
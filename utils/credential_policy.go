@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldViolation 某个请求字段不符合规则的原因
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// 密码必须包含的字符类别，对应配置 password_policy.required_classes
+const (
+	ClassLower  = "lower"  // 小写字母
+	ClassUpper  = "upper"  // 大写字母
+	ClassLetter = "letter" // 任意字母
+	ClassDigit  = "digit"  // 数字
+	ClassSymbol = "symbol" // 字母和数字以外的字符
+)
+
+// classDescriptions 字符类别在错误信息中的名称
+var classDescriptions = map[string]string{
+	ClassLower:  "小写字母",
+	ClassUpper:  "大写字母",
+	ClassLetter: "字母",
+	ClassDigit:  "数字",
+	ClassSymbol: "符号",
+}
+
+// CredentialPolicy 用户名和密码规则
+type CredentialPolicy struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	UsernamePattern   *regexp.Regexp // 用户名允许的字符
+	UsernameCharset   string         // 错误信息中展示的允许字符说明
+
+	PasswordMinLength int
+	PasswordMaxLength int
+	RequiredClasses   []string
+	Blocklist         map[string]struct{} // 常见密码，小写保存
+}
+
+var (
+	credentialPolicy     *CredentialPolicy
+	credentialPolicyOnce sync.Once
+)
+
+// InitCredentialPolicy 在启动时加载用户名和密码规则，配置有误时终止启动
+func InitCredentialPolicy() {
+	GetCredentialPolicy()
+}
+
+// GetCredentialPolicy 返回按配置加载的用户名和密码规则，只在第一次调用时读取配置和常见密码文件
+func GetCredentialPolicy() *CredentialPolicy {
+	credentialPolicyOnce.Do(func() {
+		credentialPolicy = loadCredentialPolicy()
+	})
+	return credentialPolicy
+}
+
+// loadCredentialPolicy 读取配置 username_policy 和 password_policy，配置有误时终止启动
+func loadCredentialPolicy() *CredentialPolicy {
+	c := database.Config
+	pattern, err := regexp.Compile(c.GetString("username_policy.pattern"))
+	if err != nil {
+		log.Fatalf("invalid username_policy.pattern: %v", err)
+	}
+	policy := &CredentialPolicy{
+		UsernameMinLength: c.GetInt("username_policy.min_length"),
+		UsernameMaxLength: c.GetInt("username_policy.max_length"),
+		UsernamePattern:   pattern,
+		UsernameCharset:   c.GetString("username_policy.charset_description"),
+		PasswordMinLength: c.GetInt("password_policy.min_length"),
+		PasswordMaxLength: c.GetInt("password_policy.max_length"),
+		RequiredClasses:   c.GetStringSlice("password_policy.required_classes"),
+		Blocklist:         make(map[string]struct{}),
+	}
+	for _, class := range policy.RequiredClasses {
+		if _, ok := classDescriptions[class]; !ok {
+			log.Fatalf("unknown password_policy.required_classes entry %q", class)
+		}
+	}
+	// 用户名最终写入 varchar(100) 列
+	if policy.UsernameMaxLength <= 0 || policy.UsernameMaxLength > 100 {
+		policy.UsernameMaxLength = 100
+	}
+	// bcrypt 只使用前 72 字节，超出部分不参与校验
+	if database.Config.GetString("password.algorithm") == "bcrypt" && (policy.PasswordMaxLength <= 0 || policy.PasswordMaxLength > 72) {
+		policy.PasswordMaxLength = 72
+	}
+
+	if path := c.GetString("password_policy.blocklist_file"); path != "" {
+		if err := policy.loadBlocklist(path); err != nil {
+			log.Fatalf("failed to load password_policy.blocklist_file: %v", err)
+		}
+	}
+	return policy
+}
+
+// loadBlocklist 读取常见密码文件，每行一个，忽略空行和 # 开头的注释
+func (p *CredentialPolicy) loadBlocklist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.Blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// ValidateUsername 检查用户名的长度和字符
+func (p *CredentialPolicy) ValidateUsername(field, username string) []FieldViolation {
+	var violations []FieldViolation
+	length := utf8.RuneCountInString(username)
+	switch {
+	case length == 0:
+		return []FieldViolation{{field, "用户名不能为空"}}
+	case length < p.UsernameMinLength:
+		violations = append(violations, FieldViolation{field, fmt.Sprintf("用户名至少 %d 个字符", p.UsernameMinLength)})
+	case length > p.UsernameMaxLength:
+		violations = append(violations, FieldViolation{field, fmt.Sprintf("用户名最多 %d 个字符", p.UsernameMaxLength)})
+	}
+	if !p.UsernamePattern.MatchString(username) {
+		violations = append(violations, FieldViolation{field, "用户名只能包含" + p.UsernameCharset})
+	}
+	return violations
+}
+
+// ValidatePassword 检查密码的长度、字符类别、是否为常见密码以及是否包含用户名
+func (p *CredentialPolicy) ValidatePassword(field, password, username string) []FieldViolation {
+	var violations []FieldViolation
+	length := utf8.RuneCountInString(password)
+	switch {
+	case length == 0:
+		return []FieldViolation{{field, "密码不能为空"}}
+	case length < p.PasswordMinLength:
+		violations = append(violations, FieldViolation{field, fmt.Sprintf("密码至少 %d 个字符", p.PasswordMinLength)})
+	case p.PasswordMaxLength > 0 && len(password) > p.PasswordMaxLength:
+		violations = append(violations, FieldViolation{field, fmt.Sprintf("密码最多 %d 个字节", p.PasswordMaxLength)})
+	}
+
+	var missing []string
+	for _, class := range p.RequiredClasses {
+		if !strings.ContainsFunc(password, classMatcher(class)) {
+			missing = append(missing, classDescriptions[class])
+		}
+	}
+	if len(missing) > 0 {
+		violations = append(violations, FieldViolation{field, "密码必须包含" + strings.Join(missing, "、")})
+	}
+
+	if _, ok := p.Blocklist[strings.ToLower(password)]; ok {
+		violations = append(violations, FieldViolation{field, "密码过于常见，请换一个"})
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, FieldViolation{field, "密码不能包含用户名"})
+	}
+	return violations
+}
+
+// classMatcher 返回判断字符是否属于某个类别的函数
+func classMatcher(class string) func(rune) bool {
+	switch class {
+	case ClassLower:
+		return unicode.IsLower
+	case ClassUpper:
+		return unicode.IsUpper
+	case ClassLetter:
+		return unicode.IsLetter
+	case ClassDigit:
+		return unicode.IsDigit
+	default:
+		return func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) }
+	}
+}
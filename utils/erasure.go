@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// UserErasedChannel 账号删除完成后发布事件的 Redis 频道，直播服务据此替换内存中的用户名
+const UserErasedChannel = "user:erased"
+
+// UserErased 账号删除事件
+type UserErased struct {
+	Username  string `json:"username"`
+	Pseudonym string `json:"pseudonym"`
+}
+
+// PublishUserErased 发布账号删除事件
+func PublishUserErased(ctx context.Context, ev UserErased) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if err := database.RedisClient.Publish(ctx, UserErasedChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish user erased event: %w", err)
+	}
+	return nil
+}
+
+// SubscribeUserErased 订阅账号删除事件，在 ctx 结束前持续调用 handle
+func SubscribeUserErased(ctx context.Context, handle func(UserErased)) {
+	sub := database.RedisClient.Subscribe(ctx, UserErasedChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var ev UserErased
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				log.Printf("invalid user erased event: %v", err)
+				continue
+			}
+			handle(ev)
+		}
+	}
+}
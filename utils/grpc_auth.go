@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Principal 表示通过认证的调用者
+type Principal struct {
+	Username string
+	Role     string
+	Claims   *Claims // 访问 Token 的完整 Claims，使用 API Key 调用时为空
+
+	// 以下字段只在使用 API Key 调用时设置
+	Owner    string   // 服务账号的管理者
+	Scopes   []string // API Key 的权限范围
+	APIKeyID uint
+}
+
+// HasRole 判断调用者是否拥有任一角色，管理员拥有全部角色
+func (p *Principal) HasRole(roles ...string) bool {
+	if p.Role == database.RoleAdmin {
+		return true
+	}
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 判断 API Key 是否拥有任一权限范围
+func (p *Principal) HasScope(scopes ...string) bool {
+	for _, have := range p.Scopes {
+		for _, want := range scopes {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActsFor 判断调用者是否为该用户本人，或该用户管理的服务账号
+func (p *Principal) ActsFor(username string) bool {
+	return p.Username == username || (p.Owner != "" && p.Owner == username)
+}
+
+// MethodPolicy 描述单个 RPC 的访问策略
+type MethodPolicy struct {
+	Public bool     // 无需认证即可调用
+	Roles  []string // 允许调用的角色，为空表示任意已登录用户；管理员始终允许
+	Scopes []string // 使用 API Key 调用时需要的权限范围（任一即可），为空表示不接受 API Key
+}
+
+// principalKey 是 Principal 在 context 中的键
+type principalKey struct{}
+
+// PrincipalFromContext 读取认证拦截器写入 context 的调用者
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// RequirePrincipal 读取调用者，不存在时返回 Unauthenticated 错误
+func RequirePrincipal(ctx context.Context) (*Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthenticated")
+	}
+	return p, nil
+}
+
+// UnaryAuthInterceptor 按策略表校验一元 RPC 的 Bearer Token 或 API Key，并把调用者写入 context
+func UnaryAuthInterceptor(policies map[string]MethodPolicy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, info.FullMethod, policies)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor 按策略表校验流式 RPC 的 Bearer Token 或 API Key，并把调用者写入 context
+func StreamAuthInterceptor(policies map[string]MethodPolicy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), info.FullMethod, policies)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authServerStream 替换流的 context，使处理函数能读取到调用者
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize 认证调用者并检查角色，未在策略表中声明的方法一律拒绝
+func authorize(ctx context.Context, method string, policies map[string]MethodPolicy) (context.Context, error) {
+	policy, ok := policies[method]
+	if !ok {
+		log.Printf("Permission denied: no policy for %s", method)
+		return nil, status.Errorf(codes.PermissionDenied, "no access policy for %s", method)
+	}
+	if policy.Public {
+		return ctx, nil
+	}
+
+	principal, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if principal.APIKeyID != 0 && !principal.HasScope(policy.Scopes...) {
+		log.Printf("Permission denied: api_key=%d user=%s method=%s", principal.APIKeyID, principal.Username, method)
+		return nil, status.Errorf(codes.PermissionDenied, "api key is not allowed to call %s", method)
+	}
+	if len(policy.Roles) > 0 && !principal.HasRole(policy.Roles...) {
+		log.Printf("Permission denied: user=%s role=%s method=%s", principal.Username, principal.Role, method)
+		return nil, status.Errorf(codes.PermissionDenied, "role %q is not allowed to call %s", principal.Role, method)
+	}
+
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+// authenticate 从 authorization 元数据中解析并校验 Bearer Token 或 API Key
+func authenticate(ctx context.Context) (*Principal, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+	authHeaders := md.Get("authorization")
+	if len(authHeaders) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "missing authorization header")
+	}
+
+	// 使用 Fields 而不是 Split 处理多个空格
+	parts := strings.Fields(authHeaders[0])
+	if len(parts) != 2 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+	}
+	if strings.EqualFold(parts[0], APIKeyScheme) {
+		principal, err := VerifyAPIKey(parts[1])
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+		}
+		return principal, nil
+	}
+	if strings.ToLower(parts[0]) != "bearer" {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	claims, err := ParseToken(parts[1])
+	if err != nil {
+		log.Printf("Token validation failed: %v", err)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if claims.SessionID != "" {
+		if err := TouchSession(claims.SessionID); err != nil {
+			log.Printf("TouchSession failed: %v", err)
+		}
+	}
+	return &Principal{Username: claims.Username, Role: claims.Role, Claims: claims}, nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwtKeys 保存签名私钥和所有可用于验签的公钥
+var jwtKeys struct {
+	signingKID    string
+	signingKey    crypto.Signer
+	signingMethod jwt.SigningMethod
+	verifyKeys    map[string]crypto.PublicKey // kid -> 公钥，轮换期内同时包含新旧公钥
+}
+
+// verificationKeyConfig 对应配置项 jwt.verification_keys 中的一项
+type verificationKeyConfig struct {
+	KID  string `mapstructure:"kid"`
+	File string `mapstructure:"file"`
+}
+
+// InitJWT 从配置文件指定的路径加载签名私钥和验签公钥，需在 database.Init 之后调用
+func InitJWT() {
+	jwtKeys.verifyKeys = make(map[string]crypto.PublicKey)
+
+	// 签名私钥，只负责验签的服务可以不配置
+	if file := database.Config.GetString("jwt.signing_key_file"); file != "" {
+		kid := database.Config.GetString("jwt.signing_key_id")
+		if kid == "" {
+			log.Fatal("jwt.signing_key_id is required when jwt.signing_key_file is set")
+		}
+		key, err := loadPrivateKey(file)
+		if err != nil {
+			log.Fatalf("Error loading JWT signing key: %s", err)
+		}
+		method, err := signingMethodFor(key.Public())
+		if err != nil {
+			log.Fatalf("Error loading JWT signing key: %s", err)
+		}
+		jwtKeys.signingKID = kid
+		jwtKeys.signingKey = key
+		jwtKeys.signingMethod = method
+		jwtKeys.verifyKeys[kid] = key.Public()
+	}
+
+	// 轮换期内仍然接受的验签公钥
+	var verificationKeys []verificationKeyConfig
+	if err := database.Config.UnmarshalKey("jwt.verification_keys", &verificationKeys); err != nil {
+		log.Fatalf("Error reading jwt.verification_keys: %s", err)
+	}
+	for _, vk := range verificationKeys {
+		key, err := loadPublicKey(vk.File)
+		if err != nil {
+			log.Fatalf("Error loading JWT verification key %s: %s", vk.KID, err)
+		}
+		if _, err := signingMethodFor(key); err != nil {
+			log.Fatalf("Error loading JWT verification key %s: %s", vk.KID, err)
+		}
+		jwtKeys.verifyKeys[vk.KID] = key
+	}
+
+	if len(jwtKeys.verifyKeys) == 0 {
+		log.Fatal("no JWT keys configured, see jwt.signing_key_file and jwt.verification_keys")
+	}
+	log.Printf("JWT keys loaded: signing kid=%q, %d verification key(s)", jwtKeys.signingKID, len(jwtKeys.verifyKeys))
+}
+
+// signingMethodFor 根据公钥类型确定签名算法
+func signingMethodFor(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 are supported", key)
+	}
+}
+
+// loadPrivateKey 读取 PKCS#8 或 PKCS#1 格式的 PEM 私钥
+func loadPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", file, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// loadPublicKey 读取 PEM 公钥，文件中是私钥时取其公钥部分
+func loadPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := loadPrivateKey(file)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+	return block, nil
+}
+
+// JWK 表示 JSON Web Key（RFC 7517）中的一个公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+}
+
+// JWKSet 表示 /.well-known/jwks.json 的响应体
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回当前所有验签公钥，供其他服务在不持有私钥的情况下验证 Token
+func JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(jwtKeys.verifyKeys))}
+	for kid, key := range jwtKeys.verifyKeys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Kid: kid,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(k),
+			})
+		}
+	}
+	return set
+}
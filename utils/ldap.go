@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+// LDAPConfig LDAP 目录的连接、查询和角色映射配置
+type LDAPConfig struct {
+	URL                  string // ldap:// 或 ldaps:// 地址
+	StartTLS             bool
+	InsecureSkipVerify   bool
+	Timeout              time.Duration
+	BindDN               string // 查询用户使用的服务账号，为空时匿名查询
+	BindPassword         string
+	BaseDN               string // 用户查询的起点
+	UserFilter           string // 查询用户的过滤器，{username} 替换为转义后的登录名
+	UsernameAttribute    string // 本地用户名取自该属性，保证大小写等写法一致
+	DisplayNameAttribute string
+	EmailAttribute       string
+	GroupAttribute       string              // 用户条目上记录所属组的属性，如 memberOf
+	GroupBaseDN          string              // 不为空时额外按 GroupFilter 查询用户所属的组
+	GroupFilter          string              // {dn} 替换为用户 DN，{username} 替换为用户名
+	RoleGroups           map[string][]string // 角色到组 DN 的映射
+	DefaultRole          string              // 不属于任何映射组时的角色，为空时拒绝登录
+}
+
+// LDAPConfigFromViper 从配置 auth.ldap 读取 LDAP 配置
+func LDAPConfigFromViper() LDAPConfig {
+	c := database.Config
+	roleGroups := make(map[string][]string)
+	for _, role := range []string{database.RoleAdmin, database.RoleTeacher, database.RoleStudent} {
+		roleGroups[role] = c.GetStringSlice("auth.ldap.role_groups." + role)
+	}
+	return LDAPConfig{
+		URL:                  c.GetString("auth.ldap.url"),
+		StartTLS:             c.GetBool("auth.ldap.start_tls"),
+		InsecureSkipVerify:   c.GetBool("auth.ldap.insecure_skip_verify"),
+		Timeout:              c.GetDuration("auth.ldap.timeout"),
+		BindDN:               c.GetString("auth.ldap.bind_dn"),
+		BindPassword:         c.GetString("auth.ldap.bind_password"),
+		BaseDN:               c.GetString("auth.ldap.base_dn"),
+		UserFilter:           c.GetString("auth.ldap.user_filter"),
+		UsernameAttribute:    c.GetString("auth.ldap.username_attribute"),
+		DisplayNameAttribute: c.GetString("auth.ldap.display_name_attribute"),
+		EmailAttribute:       c.GetString("auth.ldap.email_attribute"),
+		GroupAttribute:       c.GetString("auth.ldap.group_attribute"),
+		GroupBaseDN:          c.GetString("auth.ldap.group_base_dn"),
+		GroupFilter:          c.GetString("auth.ldap.group_filter"),
+		RoleGroups:           roleGroups,
+		DefaultRole:          c.GetString("auth.ldap.default_role"),
+	}
+}
+
+// RoleFor 按所属的组确定角色，同时属于多个映射组时取权限最高的角色
+func (c LDAPConfig) RoleFor(groups []string) string {
+	for _, role := range []string{database.RoleAdmin, database.RoleTeacher, database.RoleStudent} {
+		for _, mapped := range c.RoleGroups[role] {
+			for _, group := range groups {
+				if sameDN(mapped, group) {
+					return role
+				}
+			}
+		}
+	}
+	return c.DefaultRole
+}
+
+// DirectoryAccount 目录中的账号
+type DirectoryAccount struct {
+	DN          string
+	Username    string
+	DisplayName string
+	Email       string
+	Groups      []string // 所属组的 DN
+}
+
+// LDAPAuthenticator 通过 LDAP 绑定校验密码，首次登录时创建本地用户，每次登录按组同步角色
+type LDAPAuthenticator struct {
+	Config LDAPConfig
+	// Local 校验本地账号（如初始管理员），为 nil 时本地账号不能登录
+	Local Authenticator
+}
+
+// Authenticate 校验用户名和密码
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*database.User, error) {
+	// 空密码的简单绑定在 LDAP 中是匿名绑定，目录会返回成功
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	// 本地账号不经过目录，也避免目录中的同名账号接管本地账号
+	user, err := database.GetUser(username)
+	if err == nil && !user.External() {
+		if a.Local == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return a.Local.Authenticate(ctx, username, password)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account, err := a.Lookup(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	role := a.Config.RoleFor(account.Groups)
+	if role == "" {
+		return nil, ErrNoDirectoryRole
+	}
+
+	pw, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	user, previousRole, err := database.SyncExternalUser(database.ExternalUser{
+		Username:    account.Username,
+		Source:      database.SourceLDAP,
+		DisplayName: truncate(account.DisplayName, 50),
+		Email:       truncate(account.Email, 255),
+		Role:        role,
+	}, pw)
+	if errors.Is(err, database.ErrSourceConflict) {
+		log.Printf("LDAP account %s conflicts with a local account", account.Username)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if previousRole != "" && previousRole != role {
+		RecordAudit(ctx, AuditEntry{
+			Actor:   user.Username,
+			Action:  database.AuditRoleChange,
+			Target:  user.Username,
+			Outcome: database.AuditSuccess,
+			Detail:  fmt.Sprintf("ldap groups: %s -> %s", previousRole, role),
+		})
+	}
+	return user, nil
+}
+
+// Lookup 在目录中查询用户并以其 DN 绑定校验密码，返回账号信息和所属的组
+func (a *LDAPAuthenticator) Lookup(ctx context.Context, username, password string) (*DirectoryAccount, error) {
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryUnavailable, err)
+	}
+	defer conn.Close()
+
+	if err := a.bindService(conn); err != nil {
+		return nil, err
+	}
+	c := a.Config
+	filter := strings.ReplaceAll(c.UserFilter, "{username}", ldap.EscapeFilter(username))
+	attributes := []string{c.UsernameAttribute, c.DisplayNameAttribute, c.EmailAttribute, c.GroupAttribute}
+	result, err := conn.Search(ldap.NewSearchRequest(c.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(c.Timeout.Seconds()), false, filter, attributes, nil))
+	// 匹配到多个条目时无法确定是哪个账号，按凭证错误处理
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		log.Printf("LDAP filter %s matched more than one entry", filter)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: search user: %v", ErrDirectoryUnavailable, err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: bind user: %v", ErrDirectoryUnavailable, err)
+	}
+
+	account := &DirectoryAccount{
+		DN:          entry.DN,
+		Username:    entry.GetAttributeValue(c.UsernameAttribute),
+		DisplayName: entry.GetAttributeValue(c.DisplayNameAttribute),
+		Email:       entry.GetAttributeValue(c.EmailAttribute),
+	}
+	if account.Username == "" {
+		account.Username = username
+	}
+	if c.GroupAttribute != "" {
+		account.Groups = entry.GetAttributeValues(c.GroupAttribute)
+	}
+	if c.GroupBaseDN != "" {
+		groups, err := a.searchGroups(conn, account)
+		if err != nil {
+			return nil, err
+		}
+		account.Groups = append(account.Groups, groups...)
+	}
+	return account, nil
+}
+
+// searchGroups 按 GroupFilter 查询用户所属的组，配置了服务账号时以服务账号身份查询
+func (a *LDAPAuthenticator) searchGroups(conn *ldap.Conn, account *DirectoryAccount) ([]string, error) {
+	if err := a.bindService(conn); err != nil {
+		return nil, err
+	}
+	c := a.Config
+	filter := strings.NewReplacer(
+		"{dn}", ldap.EscapeFilter(account.DN),
+		"{username}", ldap.EscapeFilter(account.Username),
+	).Replace(c.GroupFilter)
+	result, err := conn.Search(ldap.NewSearchRequest(c.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(c.Timeout.Seconds()), false, filter, []string{"1.1"}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("%w: search groups: %v", ErrDirectoryUnavailable, err)
+	}
+	groups := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// bindService 以服务账号绑定，未配置服务账号时保持匿名
+func (a *LDAPAuthenticator) bindService(conn *ldap.Conn) error {
+	if a.Config.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(a.Config.BindDN, a.Config.BindPassword); err != nil {
+		return fmt.Errorf("%w: bind service account: %v", ErrDirectoryUnavailable, err)
+	}
+	return nil
+}
+
+// dial 连接目录，按配置启用 StartTLS
+func (a *LDAPAuthenticator) dial(ctx context.Context) (*ldap.Conn, error) {
+	c := a.Config
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth.ldap.url: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: c.InsecureSkipVerify}
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(c.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(c.Timeout)
+	if c.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// sameDN 判断两个 DN 是否相同，忽略大小写和空白
+func sameDN(a, b string) bool {
+	da, errA := ldap.ParseDN(a)
+	db, errB := ldap.ParseDN(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	return da.EqualFold(db)
+}
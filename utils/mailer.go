@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail 一封纯文本邮件
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, m Mail) error
+}
+
+// NewMailer 按配置 mail.driver 创建邮件发送器
+func NewMailer() Mailer {
+	from := database.Config.GetString("mail.from")
+	switch driver := database.Config.GetString("mail.driver"); driver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     database.Config.GetString("mail.smtp.host"),
+			Port:     database.Config.GetInt("mail.smtp.port"),
+			Username: database.Config.GetString("mail.smtp.username"),
+			Password: database.Config.GetString("mail.smtp.password"),
+			From:     from,
+		}
+	case "file":
+		return &FileMailer{Path: database.Config.GetString("mail.file_path"), From: from}
+	case "log", "":
+		return &LogMailer{From: from}
+	default:
+		log.Fatalf("unknown mail.driver %q", driver)
+		return nil
+	}
+}
+
+// SMTPMailer 通过 SMTP 服务器发送邮件，服务器支持时自动使用 STARTTLS
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg Mail) error {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid mail.from: %w", err)
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{msg.To}, formatMail(m.From, msg)); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// FileMailer 将邮件追加写入文件，用于开发和测试
+type FileMailer struct {
+	Path string
+	From string
+	mu   sync.Mutex
+}
+
+// Send 写入邮件
+func (m *FileMailer) Send(ctx context.Context, msg Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(formatMail(m.From, msg), "\r\n"...)); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer 只把邮件打印到日志，用于开发
+type LogMailer struct {
+	From string
+}
+
+// Send 打印邮件
+func (m *LogMailer) Send(ctx context.Context, msg Mail) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// formatMail 生成 RFC 5322 格式的邮件内容
+func formatMail(from string, msg Mail) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
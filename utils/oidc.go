@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// OIDC 支持的 scope
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// ErrInvalidOIDCToken 表示不是本服务签发给 OIDC 客户端的 Access Token
+var ErrInvalidOIDCToken = errors.New("invalid oidc access token")
+
+// OIDCIssuer 返回 OIDC 签发者地址，不含末尾的斜杠
+func OIDCIssuer() string {
+	return strings.TrimRight(database.Config.GetString("oidc.issuer"), "/")
+}
+
+// OIDCAccessClaims 签发给 OIDC 客户端的 Access Token，只能用于 userinfo
+// aud 为 client_id，因此 ParseToken 不会把它当作 LanshanClass 的访问 Token
+type OIDCAccessClaims struct {
+	Scope string `json:"scope"`
+	jwt.StandardClaims
+}
+
+// IDTokenClaims ID Token 的 Claims，profile 和 email 中的声明只在授权了对应 scope 时出现
+type IDTokenClaims struct {
+	Nonce             string `json:"nonce,omitempty"`
+	AuthTime          int64  `json:"auth_time"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Role              string `json:"role,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	jwt.StandardClaims
+}
+
+// HasScope 判断以空格分隔的 scope 列表中是否包含指定 scope
+func HasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateOIDCAccessToken 签发 OIDC Access Token
+func GenerateOIDCAccessToken(username, clientID, scope string) (string, error) {
+	now := time.Now()
+	return SignClaims(OIDCAccessClaims{
+		Scope: scope,
+		StandardClaims: jwt.StandardClaims{
+			Id:        randomHex(16),
+			Issuer:    OIDCIssuer(),
+			Subject:   username,
+			Audience:  clientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(database.Config.GetDuration("oidc.access_token_ttl")).Unix(),
+		},
+	})
+}
+
+// ParseOIDCAccessToken 校验 OIDC Access Token
+func ParseOIDCAccessToken(tokenString string) (*OIDCAccessClaims, error) {
+	claims := &OIDCAccessClaims{}
+	if err := VerifyClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	// ID Token 和一次性操作 Token 没有 scope，不能用于 userinfo
+	if claims.Issuer != OIDCIssuer() || claims.Subject == "" || claims.Audience == "" || !HasScope(claims.Scope, ScopeOpenID) {
+		return nil, ErrInvalidOIDCToken
+	}
+	return claims, nil
+}
+
+// GenerateIDToken 为用户签发 ID Token
+func GenerateIDToken(user *database.User, clientID, scope, nonce string, authTime time.Time) (string, error) {
+	now := time.Now()
+	claims := IDTokenClaims{
+		Nonce:    nonce,
+		AuthTime: authTime.Unix(),
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    OIDCIssuer(),
+			Subject:   user.Username,
+			Audience:  clientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(database.Config.GetDuration("oidc.id_token_ttl")).Unix(),
+		},
+	}
+	if HasScope(scope, ScopeProfile) {
+		claims.PreferredUsername = user.Username
+		claims.Name = user.Name()
+		claims.Picture = user.AvatarURL
+		claims.Role = user.Role
+	}
+	if HasScope(scope, ScopeEmail) && user.Email != "" {
+		verified := user.EmailVerified
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	return SignClaims(claims)
+}
+
+// VerifyPKCE 按 S256 方法校验 code_verifier 与 code_challenge 是否匹配
+func VerifyPKCE(verifier, challenge string) bool {
+	// RFC 7636：code_verifier 长度为 43 到 128 个字符
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// SigningAlgorithms 返回当前验签公钥使用的算法，用于 OIDC 发现文档
+func SigningAlgorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range JWKS().Keys {
+		if !seen[key.Alg] {
+			seen[key.Alg] = true
+			algs = append(algs, key.Alg)
+		}
+	}
+	return algs
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrSessionNotFound 表示会话不存在、已过期或不属于该用户
+var ErrSessionNotFound = errors.New("session not found")
+
+// Redis 键前缀
+const (
+	sessionKeyPrefix      = "auth:session:"       // 会话 ID -> 会话信息（哈希）
+	userSessionsKeyPrefix = "auth:user_sessions:" // 用户名 -> 该用户的会话 ID 集合
+)
+
+// Session 登录会话，每次登录创建一个，访问 Token 和刷新 Token 都属于某个会话
+type Session struct {
+	ID         string
+	Username   string
+	Device     string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// touchSessionScript 只在会话仍存在时更新最近活跃时间，避免重新创建已删除的会话
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1])
+end
+return 0
+`)
+
+// CreateSession 创建会话
+func CreateSession(username, device, ip, userAgent string) (*Session, error) {
+	ctx := context.Background()
+	now := time.Now()
+	session := &Session{
+		ID:         randomHex(16),
+		Username:   username,
+		Device:     device,
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	ttl := RefreshTokenTTL()
+
+	key := sessionKeyPrefix + session.ID
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"username":     username,
+		"device":       device,
+		"ip":           ip,
+		"user_agent":   userAgent,
+		"created_at":   now.Unix(),
+		"last_seen_at": now.Unix(),
+	})
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, userSessionsKeyPrefix+username, session.ID)
+	pipe.Expire(ctx, userSessionsKeyPrefix+username, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// GetSession 查询会话
+func GetSession(id string) (*Session, error) {
+	fields, err := database.RedisClient.HGetAll(context.Background(), sessionKeyPrefix+id).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrSessionNotFound
+	}
+	return sessionFromHash(id, fields), nil
+}
+
+// ListSessions 查询用户的全部会话，按最近活跃时间倒序排列，同时清理已过期的会话 ID
+func ListSessions(username string) ([]Session, error) {
+	ctx := context.Background()
+
+	ids, err := database.RedisClient.SMembers(ctx, userSessionsKeyPrefix+username).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	pipe := database.RedisClient.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, sessionKeyPrefix+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	var expired []interface{}
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			expired = append(expired, ids[i])
+			continue
+		}
+		sessions = append(sessions, *sessionFromHash(ids[i], fields))
+	}
+	if len(expired) > 0 {
+		database.RedisClient.SRem(ctx, userSessionsKeyPrefix+username, expired...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 删除用户的某个会话及其刷新 Token，该会话的访问 Token 随即失效
+func RevokeSession(username, id string) error {
+	ctx := context.Background()
+	key := sessionKeyPrefix + id
+
+	fields, err := database.RedisClient.HMGet(ctx, key, "username", "refresh_token").Result()
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	owner, _ := fields[0].(string)
+	if owner == "" || owner != username {
+		return ErrSessionNotFound
+	}
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	if refreshToken, _ := fields[1].(string); refreshToken != "" {
+		pipe.Del(ctx, refreshTokenKeyPrefix+refreshToken)
+	}
+	pipe.SRem(ctx, userSessionsKeyPrefix+username, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession 更新会话的最近活跃时间
+func TouchSession(id string) error {
+	return touchSessionScript.Run(context.Background(), database.RedisClient,
+		[]string{sessionKeyPrefix + id}, time.Now().Unix()).Err()
+}
+
+// sessionExists 检查会话是否仍然有效
+func sessionExists(id string) (bool, error) {
+	n, err := database.RedisClient.Exists(context.Background(), sessionKeyPrefix+id).Result()
+	return n > 0, err
+}
+
+// sessionFromHash 将 Redis 哈希转换为会话
+func sessionFromHash(id string, fields map[string]string) *Session {
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	lastSeenAt, _ := strconv.ParseInt(fields["last_seen_at"], 10, 64)
+	return &Session{
+		ID:         id,
+		Username:   fields["username"],
+		Device:     fields["device"],
+		IP:         fields["ip"],
+		UserAgent:  fields["user_agent"],
+		CreatedAt:  time.Unix(createdAt, 0),
+		LastSeenAt: time.Unix(lastSeenAt, 0),
+	}
+}
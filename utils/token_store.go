@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"LanshanClass1.3/global/database"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrInvalidRefreshToken 表示刷新 Token 不存在、已过期或已被使用
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// Redis 键前缀
+const (
+	refreshTokenKeyPrefix = "auth:refresh:"        // 刷新 Token -> 所属会话 ID
+	revokedTokenKeyPrefix = "auth:revoked:"        // 已注销的访问 Token（按 jti）
+	revokedUserKeyPrefix  = "auth:revoked_before:" // 用户名 -> 在此时间之前签发的 Token 全部失效
+	disabledUsersKey      = "auth:disabled_users"  // 被管理员禁用的用户名集合
+)
+
+// ErrUserDisabled 表示用户已被管理员禁用
+var ErrUserDisabled = errors.New("user has been disabled")
+
+// GenerateRefreshToken 为会话生成刷新 Token 并保存到 Redis，会话及用户会话索引的有效期随之延长
+// 索引必须一并续期，否则长期刷新的会话会从索引中消失，RevokeUserTokens 和 ListSessions 都找不到它
+func GenerateRefreshToken(session *Session) (string, error) {
+	ctx := context.Background()
+	refreshToken := randomHex(32)
+	ttl := RefreshTokenTTL()
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Set(ctx, refreshTokenKeyPrefix+refreshToken, session.ID, ttl)
+	pipe.HSet(ctx, sessionKeyPrefix+session.ID, "refresh_token", refreshToken)
+	pipe.Expire(ctx, sessionKeyPrefix+session.ID, ttl)
+	pipe.SAdd(ctx, userSessionsKeyPrefix+session.Username, session.ID)
+	pipe.Expire(ctx, userSessionsKeyPrefix+session.Username, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return refreshToken, nil
+}
+
+// RotateRefreshToken 使旧的刷新 Token 失效并签发新的刷新 Token，返回其所属会话
+func RotateRefreshToken(refreshToken string) (*Session, string, error) {
+	ctx := context.Background()
+
+	// GETDEL 保证每个刷新 Token 只能使用一次
+	sessionID, err := database.RedisClient.GetDel(ctx, refreshTokenKeyPrefix+refreshToken).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load refresh token: %w", err)
+	}
+
+	// 会话已被注销时刷新 Token 一并失效
+	session, err := GetSession(sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	newRefreshToken, err := GenerateRefreshToken(session)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, newRefreshToken, nil
+}
+
+// RevokeRefreshToken 删除刷新 Token
+func RevokeRefreshToken(refreshToken string) error {
+	ctx := context.Background()
+
+	sessionID, err := database.RedisClient.GetDel(ctx, refreshTokenKeyPrefix+refreshToken).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return database.RedisClient.HDel(ctx, sessionKeyPrefix+sessionID, "refresh_token").Err()
+}
+
+// RevokeToken 注销单个访问 Token，记录保留到 Token 自然过期为止
+func RevokeToken(claims *Claims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 || claims.Id == "" {
+		return nil
+	}
+	return database.RedisClient.Set(context.Background(), revokedTokenKeyPrefix+claims.Id, 1, ttl).Err()
+}
+
+// RevokeUserTokens 注销用户的全部会话，以及当前持有的全部访问 Token 和刷新 Token
+func RevokeUserTokens(username string) error {
+	ctx := context.Background()
+
+	sessionIDs, err := database.RedisClient.SMembers(ctx, userSessionsKeyPrefix+username).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	pipe := database.RedisClient.Pipeline()
+	refreshCmds := make([]*redis.StringCmd, len(sessionIDs))
+	for i, id := range sessionIDs {
+		refreshCmds[i] = pipe.HGet(ctx, sessionKeyPrefix+id, "refresh_token")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+
+	tx := database.RedisClient.TxPipeline()
+	for i, id := range sessionIDs {
+		if refreshToken := refreshCmds[i].Val(); refreshToken != "" {
+			tx.Del(ctx, refreshTokenKeyPrefix+refreshToken)
+		}
+		tx.Del(ctx, sessionKeyPrefix+id)
+	}
+	tx.Del(ctx, userSessionsKeyPrefix+username)
+	// 访问 Token 有效期较短，标记保留一个访问 Token 有效期即可
+	tx.Set(ctx, revokedUserKeyPrefix+username, time.Now().Unix(), AccessTokenTTL())
+	if _, err := tx.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked 检查访问 Token 是否已被注销
+func IsTokenRevoked(claims *Claims) (bool, error) {
+	ctx := context.Background()
+
+	if claims.Id != "" {
+		n, err := database.RedisClient.Exists(ctx, revokedTokenKeyPrefix+claims.Id).Result()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	revokedBefore, err := database.RedisClient.Get(ctx, revokedUserKeyPrefix+claims.Username).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	before, err := strconv.ParseInt(revokedBefore, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	return claims.IssuedAt < before, nil
+}
+
+// SetUserDisabled 标记用户被禁用或解除禁用，被禁用用户的 Token 一律拒绝
+// 数据库中的 User.Disabled 是权威数据，登录和刷新 Token 时以数据库为准
+func SetUserDisabled(username string, disabled bool) error {
+	ctx := context.Background()
+	if disabled {
+		return database.RedisClient.SAdd(ctx, disabledUsersKey, username).Err()
+	}
+	return database.RedisClient.SRem(ctx, disabledUsersKey, username).Err()
+}
+
+// IsUserDisabled 检查用户是否被禁用
+func IsUserDisabled(username string) (bool, error) {
+	return database.RedisClient.SIsMember(context.Background(), disabledUsersKey, username).Result()
+}
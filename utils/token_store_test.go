@@ -0,0 +1,60 @@
+package utils_test
+
+import (
+	"LanshanClass1.3/test/testenv"
+	"LanshanClass1.3/utils"
+	"errors"
+	"testing"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	testenv.Setup(t)
+
+	session, err := utils.CreateSession("alice", "laptop", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	first, err := utils.GenerateRefreshToken(session)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	rotated, second, err := utils.RotateRefreshToken(first)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if rotated.ID != session.ID || rotated.Username != "alice" {
+		t.Errorf("rotated session = %+v, want %s of alice", rotated, session.ID)
+	}
+	if second == "" || second == first {
+		t.Fatalf("rotation returned %q, want a new token", second)
+	}
+
+	// 旧 Token 只能使用一次
+	if _, _, err := utils.RotateRefreshToken(first); !errors.Is(err, utils.ErrInvalidRefreshToken) {
+		t.Errorf("reusing the old token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, third, err := utils.RotateRefreshToken(second); err != nil || third == "" {
+		t.Errorf("rotating the new token: %q, %v", third, err)
+	}
+}
+
+func TestRotateRefreshTokenRevokedSession(t *testing.T) {
+	testenv.Setup(t)
+
+	session, err := utils.CreateSession("alice", "laptop", "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	token, err := utils.GenerateRefreshToken(session)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	if err := utils.RevokeSession("alice", session.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	if _, _, err := utils.RotateRefreshToken(token); !errors.Is(err, utils.ErrInvalidRefreshToken) {
+		t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+	}
+}